@@ -0,0 +1,66 @@
+package switchblade
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// The files that are written to the artifacts directory of a deployment on
+// both platforms. Each platform also writes its own description of the
+// deployment, which is container.json and result.json on Docker, and app.txt
+// and droplet.json on Cloud Foundry.
+const (
+	// StagingLogFile holds the logs returned from DeployProcess.Execute.
+	StagingLogFile = "staging.log"
+
+	// RuntimeLogFile holds the logs of the running application.
+	RuntimeLogFile = "runtime.log"
+
+	// EnvFile holds the environment of the application.
+	EnvFile = "env.txt"
+
+	// DropletArtifactFile holds the droplet when WithDropletArtifact is used.
+	DropletArtifactFile = "droplet.tgz"
+
+	// ArtifactsErrorsFile describes the artifacts that could not be
+	// collected.
+	ArtifactsErrorsFile = "errors.log"
+)
+
+// writeArtifacts writes the given files to the artifacts directory with their
+// secrets redacted, skipping those that are empty. The problems that occurred
+// while collecting them are written to ArtifactsErrorsFile, so that a failed
+// deployment still leaves behind whatever could be collected.
+func writeArtifacts(dir string, files map[string]string, problems error, redactor redactor) error {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+
+	if problems != nil {
+		files[ArtifactsErrorsFile] = problems.Error() + "\n"
+	}
+
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if files[name] == "" {
+			continue
+		}
+
+		err = os.WriteFile(filepath.Join(dir, name), []byte(redactor.redact(files[name])), 0644)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to write artifact: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -2,8 +2,11 @@ package switchblade
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
 )
@@ -13,13 +16,58 @@ import (
 //go:generate faux --package github.com/cloudfoundry/switchblade/internal/cloudfoundry --interface SetupPhase --name CloudFoundrySetupPhase --output fakes/cloudfoundry_setup_phase.go
 //go:generate faux --package github.com/cloudfoundry/switchblade/internal/cloudfoundry --interface StagePhase --name CloudFoundryStagePhase --output fakes/cloudfoundry_stage_phase.go
 //go:generate faux --package github.com/cloudfoundry/switchblade/internal/cloudfoundry --interface TeardownPhase --name CloudFoundryTeardownPhase --output fakes/cloudfoundry_teardown_phase.go
+//go:generate faux --package github.com/cloudfoundry/switchblade/internal/cloudfoundry --interface CheckPhase --name CloudFoundryCheckPhase --output fakes/cloudfoundry_check_phase.go
+//go:generate faux --package github.com/cloudfoundry/switchblade/internal/cloudfoundry --interface CollectPhase --name CloudFoundryCollectPhase --output fakes/cloudfoundry_collect_phase.go
 
+// CloudFoundryPhases are the phases that a Cloud Foundry platform created
+// with NewCloudFoundryWithPhases is made of. Initialize, Deinitialize, Setup,
+// Stage and Teardown are required. The other phases are optional, and the
+// features that rely on them return an error when they are nil.
+type CloudFoundryPhases struct {
+	Initialize   cloudfoundry.InitializePhase
+	Deinitialize cloudfoundry.DeinitializePhase
+	Setup        cloudfoundry.SetupPhase
+	Stage        cloudfoundry.StagePhase
+	Teardown     cloudfoundry.TeardownPhase
+
+	Check   cloudfoundry.CheckPhase
+	Collect cloudfoundry.CollectPhase
+}
+
+// NewCloudFoundry creates a Cloud Foundry platform from its required phases.
+// Use NewCloudFoundryWithPhases to also give the optional phases.
 func NewCloudFoundry(initialize cloudfoundry.InitializePhase, deinitialize cloudfoundry.DeinitializePhase, setup cloudfoundry.SetupPhase, stage cloudfoundry.StagePhase, teardown cloudfoundry.TeardownPhase, workspace string, cli cloudfoundry.Executable) Platform {
+	return NewCloudFoundryWithPhases(CloudFoundryPhases{
+		Initialize:   initialize,
+		Deinitialize: deinitialize,
+		Setup:        setup,
+		Stage:        stage,
+		Teardown:     teardown,
+	}, workspace, cli)
+}
+
+// NewCloudFoundryWithPhases creates a Cloud Foundry platform from the given
+// phases.
+func NewCloudFoundryWithPhases(phases CloudFoundryPhases, workspace string, cli cloudfoundry.Executable) Platform {
+	deployProcess := cloudFoundryDeployProcess{
+		setup:     phases.Setup,
+		stage:     phases.Stage,
+		collect:   phases.Collect,
+		workspace: workspace,
+		cli:       cli,
+		logLines:  DefaultErrorLogLines,
+	}
+	deleteProcess := cloudFoundryDeleteProcess{teardown: phases.Teardown, workspace: workspace}
+
 	return Platform{
-		initialize:   cloudFoundryInitializeProcess{initialize: initialize},
-		deinitialize: cloudFoundryDeinitializeProcess{deinitialize: deinitialize},
-		Deploy:       cloudFoundryDeployProcess{setup: setup, stage: stage, workspace: workspace, cli: cli},
-		Delete:       cloudFoundryDeleteProcess{teardown: teardown, workspace: workspace},
+		initialize:   cloudFoundryInitializeProcess{initialize: phases.Initialize},
+		deinitialize: cloudFoundryDeinitializeProcess{deinitialize: phases.Deinitialize},
+		info:         cloudFoundryInfoProcess{},
+		attach:       cloudFoundryAttachProcess{workspace: workspace, cli: cli},
+		check:        cloudFoundryCheckProcess{check: phases.Check},
+		detect:       cloudFoundryDetectProcess{deploy: deployProcess, delete: deleteProcess},
+		Deploy:       deployProcess,
+		Delete:       deleteProcess,
 	}
 }
 
@@ -47,15 +95,96 @@ func (p cloudFoundryDeinitializeProcess) Execute() error {
 	return p.deinitialize.Run()
 }
 
+// The lifecycle is managed by the Cloud Foundry deployment itself, so there is
+// nothing further to report.
+type cloudFoundryInfoProcess struct{}
+
+func (p cloudFoundryInfoProcess) Execute() (PlatformInfo, error) {
+	return PlatformInfo{Type: CloudFoundry}, nil
+}
+
+// cloudFoundryDetectProcess detects an application by staging it, since the
+// cloud controller only runs the bin/detect scripts of buildpacks as part of
+// staging.
+type cloudFoundryDetectProcess struct {
+	deploy DeployProcess
+	delete DeleteProcess
+}
+
+func (p cloudFoundryDetectProcess) Execute(path string, buildpacks ...string) ([]DetectResult, error) {
+	name, err := RandomName()
+	if err != nil {
+		return nil, err
+	}
+
+	// The buildpacks are not given to the deployment, because the cloud
+	// controller skips detection for buildpacks that are given explicitly.
+	deployment, _, err := p.deploy.WithoutStart().Execute(name, path)
+
+	deleteErr := p.delete.Execute(name)
+	if deleteErr != nil {
+		deleteErr = fmt.Errorf("failed to delete application: %w", deleteErr)
+	}
+
+	if err != nil {
+		var stagingErr StagingError
+		if !errors.As(err, &stagingErr) || !stagingErr.NoBuildpackDetected() {
+			return nil, errors.Join(fmt.Errorf("failed to stage application: %w", err), deleteErr)
+		}
+	}
+
+	if deleteErr != nil {
+		return nil, deleteErr
+	}
+
+	if len(buildpacks) == 0 {
+		for _, buildpack := range deployment.Droplet.Buildpacks {
+			buildpacks = append(buildpacks, buildpack.Name)
+		}
+	}
+
+	// When the application was staged, the buildpacks that come after the one
+	// that detected it in the order of the platform were never run, and that
+	// order is not known, so the others are reported as unknown. Otherwise,
+	// every buildpack was run and none of them detected it.
+	var results []DetectResult
+	for _, bpName := range buildpacks {
+		result := DetectResult{Buildpack: bpName, Unknown: err == nil}
+		for _, buildpack := range deployment.Droplet.Buildpacks {
+			if buildpack.Name == bpName {
+				result.Detected = true
+				result.Unknown = false
+				result.Output = buildpack.DetectOutput
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
 type cloudFoundryDeployProcess struct {
-	setup     cloudfoundry.SetupPhase
-	stage     cloudfoundry.StagePhase
-	workspace string
-	cli       cloudfoundry.Executable
+	setup           cloudfoundry.SetupPhase
+	stage           cloudfoundry.StagePhase
+	collect         cloudfoundry.CollectPhase
+	workspace       string
+	cli             cloudfoundry.Executable
+	buildpacks      []string
+	logLines        int
+	env             map[string]string
+	secretEnv       map[string]string
+	services        map[string]Service
+	artifactsDir    string
+	dropletArtifact bool
+	observer        Observer
+	withoutStart    bool
+	droplet         string
 }
 
 func (p cloudFoundryDeployProcess) WithBuildpacks(buildpacks ...string) DeployProcess {
 	p.setup = p.setup.WithBuildpacks(buildpacks...)
+	p.buildpacks = buildpacks
 	return p
 }
 
@@ -65,7 +194,14 @@ func (p cloudFoundryDeployProcess) WithStack(stack string) DeployProcess {
 }
 
 func (p cloudFoundryDeployProcess) WithEnv(env map[string]string) DeployProcess {
-	p.setup = p.setup.WithEnv(env)
+	p.env = env
+	p.setup = p.setup.WithEnv(mergeEnv(p.env, p.secretEnv))
+	return p
+}
+
+func (p cloudFoundryDeployProcess) WithSecretEnv(env map[string]string) DeployProcess {
+	p.secretEnv = env
+	p.setup = p.setup.WithEnv(mergeEnv(p.env, p.secretEnv))
 	return p
 }
 
@@ -80,6 +216,7 @@ func (p cloudFoundryDeployProcess) WithServices(services map[string]Service) Dep
 		s[name] = service
 	}
 
+	p.services = services
 	p.setup = p.setup.WithServices(s)
 	return p
 }
@@ -94,35 +231,290 @@ func (p cloudFoundryDeployProcess) WithHealthCheckType(healthCheckType string) D
 	return p
 }
 
+func (p cloudFoundryDeployProcess) WithErrorLogLines(lines int) DeployProcess {
+	p.logLines = lines
+	return p
+}
+
+func (p cloudFoundryDeployProcess) WithDroplet(path string) DeployProcess {
+	p.setup = p.setup.WithDroplet(path)
+	p.droplet = path
+	return p
+}
+
+func (p cloudFoundryDeployProcess) WithoutStart() DeployProcess {
+	p.setup = p.setup.WithoutRoutes()
+	p.stage = p.stage.WithoutStart()
+	p.withoutStart = true
+	return p
+}
+
+func (p cloudFoundryDeployProcess) WithObserver(observer Observer) DeployProcess {
+	p.observer = observer
+	return p
+}
+
+func (p cloudFoundryDeployProcess) WithArtifactsDir(dir string) DeployProcess {
+	p.artifactsDir = dir
+	return p
+}
+
+func (p cloudFoundryDeployProcess) WithDropletArtifact() DeployProcess {
+	p.dropletArtifact = true
+	return p
+}
+
 func (p cloudFoundryDeployProcess) Execute(name, source string) (Deployment, fmt.Stringer, error) {
-	logs := bytes.NewBuffer(nil)
+	if p.withoutStart && p.droplet != "" {
+		return Deployment{}, bytes.NewBuffer(nil), fmt.Errorf("failed to deploy droplet: a droplet cannot be deployed without starting it")
+	}
+
+	if p.withoutStart && p.collect == nil {
+		return Deployment{}, bytes.NewBuffer(nil), fmt.Errorf("failed to deploy without starting: %w", errMissingPhase("collect"))
+	}
+
 	home := filepath.Join(p.workspace, name)
+	redactor := newRedactor(p.secretEnv, p.services)
 
-	internalURL, err := p.setup.Run(logs, home, name, source)
+	timer := newPhaseTimer(CloudFoundry, name, p.observer)
+
+	deployment, logs, err := p.deploy(home, name, source, redactor, timer)
+
+	if p.artifactsDir != "" {
+		artifactsErr := p.writeArtifacts(home, name, logs.String(), redactor)
+		if artifactsErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to write artifacts: %w", artifactsErr))
+		} else if p.dropletArtifact && deployment.Droplet.GUID != "" {
+			deployment.Droplet.Path = filepath.Join(p.artifactsDir, name, DropletArtifactFile)
+		}
+	}
+
+	return deployment, logs, err
+}
+
+func (p cloudFoundryDeployProcess) ExecuteImage(name, image string) (Deployment, fmt.Stringer, error) {
+	if p.withoutStart {
+		return Deployment{}, bytes.NewBuffer(nil), fmt.Errorf("failed to deploy image: an image cannot be deployed without starting it")
+	}
+
+	p.setup = p.setup.WithImage(image)
+
+	return p.Execute(name, "")
+}
+
+// writeArtifacts writes the artifacts of the deployment to its directory
+// within the artifacts directory.
+func (p cloudFoundryDeployProcess) writeArtifacts(home, name, logs string, redactor redactor) error {
+	if p.collect == nil {
+		return errMissingPhase("collect")
+	}
+
+	dir := filepath.Join(p.artifactsDir, name)
+
+	var droplet string
+	if p.dropletArtifact {
+		err := os.MkdirAll(dir, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("failed to create artifacts directory: %w", err)
+		}
+
+		droplet = filepath.Join(dir, DropletArtifactFile)
+	}
+
+	artifacts, err := p.collect.Run(home, name, droplet)
+	problems := []error{err}
+
+	runtimeLogs, err := Deployment{Name: name, platform: CloudFoundry, workspace: home, cfCLI: p.cli}.RuntimeLogs()
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	return writeArtifacts(dir, map[string]string{
+		StagingLogFile: logs,
+		RuntimeLogFile: runtimeLogs,
+		EnvFile:        string(artifacts.Env),
+		"app.txt":      string(artifacts.App),
+		"droplet.json": string(artifacts.Droplet),
+	}, errors.Join(problems...), redactor)
+}
+
+func (p cloudFoundryDeployProcess) deploy(home, name, source string, redactor redactor, timer *phaseTimer) (Deployment, *bytes.Buffer, error) {
+	logs := bytes.NewBuffer(nil)
+
+	timer.Started(PhaseSetup)
+	internalURL, err := p.setup.WithObserver(timer).Run(logs, home, name, source)
+	timer.Finished(PhaseSetup, err)
 	if err != nil {
-		return Deployment{}, logs, err
+		logs = redactor.logs(logs)
+		return Deployment{}, logs, SetupError{
+			Platform: CloudFoundry,
+			Logs:     logs.String(),
+			Err:      withLogs(redactor.error(err), name, logs.String(), p.logLines),
+		}
 	}
 
+	timer.Started(PhaseStage)
 	externalURL, err := p.stage.Run(logs, home, name)
+	timer.Finished(PhaseStage, err)
 	if err != nil {
-		return Deployment{}, logs, err
+		logs = redactor.logs(logs)
+
+		var startErr cloudfoundry.StartError
+		if !errors.As(err, &startErr) {
+			return Deployment{}, logs, PlatformError{
+				Platform: CloudFoundry,
+				Phase:    "stage",
+				Logs:     logs.String(),
+				Err:      redactor.error(err),
+			}
+		}
+
+		// The output of `cf start`, including the recent logs of the
+		// application, is only in the deployment logs.
+		err = withLogs(redactor.error(err), name, logs.String(), p.logLines)
+
+		// `cf start` both stages and starts the application, so the staging
+		// error reported by the cloud controller tells the two apart.
+		for _, failure := range stagingFailures {
+			if strings.Contains(logs.String(), failure.marker) {
+				return Deployment{}, logs, StagingError{
+					Platform:  CloudFoundry,
+					ExitCode:  failure.code,
+					Buildpack: failedBuildpack(p.buildpacks, logs.String()),
+					Logs:      logs.String(),
+					Err:       err,
+				}
+			}
+		}
+
+		// Without starting, the only command that was run is `cf stage`, so
+		// the failure is a staging error even when it is not recognized.
+		if p.withoutStart {
+			return Deployment{}, logs, StagingError{
+				Platform:  CloudFoundry,
+				Buildpack: failedBuildpack(p.buildpacks, logs.String()),
+				Logs:      logs.String(),
+				Err:       err,
+			}
+		}
+
+		return Deployment{}, logs, StartError{
+			Platform: CloudFoundry,
+			Logs:     logs.String(),
+			Err:      err,
+		}
+	}
+
+	if p.withoutStart {
+		droplet, err := p.collect.Droplet(home, name)
+		if err != nil {
+			logs = redactor.logs(logs)
+			return Deployment{}, logs, PlatformError{
+				Platform: CloudFoundry,
+				Phase:    "stage",
+				Logs:     logs.String(),
+				Err:      redactor.error(fmt.Errorf("failed to read droplet: %w", err)),
+			}
+		}
+
+		var buildpacks []DropletBuildpack
+		for _, buildpack := range droplet.Buildpacks {
+			buildpacks = append(buildpacks, DropletBuildpack(buildpack))
+		}
+
+		return Deployment{
+			Name:    name,
+			Timings: timer.Timings(),
+			Droplet: Droplet{
+				GUID:         droplet.GUID,
+				Stack:        droplet.Stack,
+				Buildpacks:   buildpacks,
+				ProcessTypes: droplet.ProcessTypes,
+				Metadata:     droplet.Metadata,
+			},
+			platform:  CloudFoundry,
+			workspace: home,
+			cfCLI:     p.cli,
+			redactor:  redactor,
+		}, redactor.logs(logs), nil
 	}
 
 	return Deployment{
 		Name:        name,
 		ExternalURL: externalURL,
 		InternalURL: internalURL,
+		Timings:     timer.Timings(),
 		platform:    CloudFoundry,
 		workspace:   home,
 		cfCLI:       p.cli,
-	}, logs, nil
+		droplet:     p.droplet,
+		redactor:    redactor,
+	}, redactor.logs(logs), nil
+}
+
+type cloudFoundryCheckProcess struct {
+	check cloudfoundry.CheckPhase
+}
+
+func (p cloudFoundryCheckProcess) Execute() error {
+	if p.check == nil {
+		return fmt.Errorf("cf platform is not ready:\n%w", errMissingPhase("check"))
+	}
+
+	err := p.check.Run()
+	if err != nil {
+		return fmt.Errorf("cf platform is not ready:\n%w", err)
+	}
+
+	return nil
+}
+
+type cloudFoundryAttachProcess struct {
+	workspace string
+	cli       cloudfoundry.Executable
+}
+
+func (p cloudFoundryAttachProcess) Execute(name string) Deployment {
+	return Deployment{
+		Name:      name,
+		platform:  CloudFoundry,
+		workspace: filepath.Join(p.workspace, name),
+		cfCLI:     p.cli,
+	}
+}
+
+// stagingFailures are the markers of the staging errors that the cloud
+// controller reports, along with the buildpackapplifecycle exit codes that
+// they correspond to, most specific first.
+var stagingFailures = []struct {
+	marker string
+	code   int
+}{
+	{marker: "NoAppDetectedError", code: DetectFailCode},
+	{marker: "An app was not successfully detected by any available buildpack", code: DetectFailCode},
+	{marker: "BuildpackCompileFailed", code: CompileFailCode},
+	{marker: "BuildpackReleaseFailed", code: ReleaseFailCode},
+	{marker: "StagingError"},
+	{marker: "Error staging application"},
 }
 
 type cloudFoundryDeleteProcess struct {
 	teardown  cloudfoundry.TeardownPhase
 	workspace string
+	observer  Observer
+}
+
+func (p cloudFoundryDeleteProcess) WithObserver(observer Observer) DeleteProcess {
+	p.observer = observer
+	return p
 }
 
 func (p cloudFoundryDeleteProcess) Execute(name string) error {
-	return p.teardown.Run(filepath.Join(p.workspace, name), name)
+	timer := newPhaseTimer(CloudFoundry, name, p.observer)
+
+	timer.Started(PhaseTeardown)
+	err := p.teardown.Run(filepath.Join(p.workspace, name), name)
+	timer.Finished(PhaseTeardown, err)
+
+	return err
 }
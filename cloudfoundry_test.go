@@ -6,6 +6,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/cloudfoundry/switchblade"
@@ -28,6 +30,8 @@ func testCloudFoundry(t *testing.T, context spec.G, it spec.S) {
 		setup        *fakes.CloudFoundrySetupPhase
 		stage        *fakes.CloudFoundryStagePhase
 		teardown     *fakes.CloudFoundryTeardownPhase
+		check        *fakes.CloudFoundryCheckPhase
+		collect      *fakes.CloudFoundryCollectPhase
 		cli          *cffakes.Executable
 		workspace    string
 
@@ -40,19 +44,52 @@ func testCloudFoundry(t *testing.T, context spec.G, it spec.S) {
 		setup = &fakes.CloudFoundrySetupPhase{}
 		stage = &fakes.CloudFoundryStagePhase{}
 		teardown = &fakes.CloudFoundryTeardownPhase{}
+		check = &fakes.CloudFoundryCheckPhase{}
+		collect = &fakes.CloudFoundryCollectPhase{}
 		cli = &cffakes.Executable{}
 
+		setup.WithObserverCall.Returns.SetupPhase = setup
+
 		var err error
 		workspace, err = os.MkdirTemp("", "workspace")
 		Expect(err).NotTo(HaveOccurred())
 
-		platform = switchblade.NewCloudFoundry(initialize, deinitialize, setup, stage, teardown, workspace, cli)
+		platform = switchblade.NewCloudFoundryWithPhases(switchblade.CloudFoundryPhases{
+			Initialize:   initialize,
+			Deinitialize: deinitialize,
+			Setup:        setup,
+			Stage:        stage,
+			Teardown:     teardown,
+			Check:        check,
+			Collect:      collect,
+		}, workspace, cli)
 	})
 
 	it.After(func() {
 		Expect(os.RemoveAll(workspace)).To(Succeed())
 	})
 
+	context("NewCloudFoundry", func() {
+		it.Before(func() {
+			platform = switchblade.NewCloudFoundry(initialize, deinitialize, setup, stage, teardown, workspace, cli)
+		})
+
+		it("creates a platform that deploys with only the required phases", func() {
+			stage.RunCall.Returns.Url = "some-external-url"
+
+			deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deployment.ExternalURL).To(Equal("some-external-url"))
+		})
+
+		it("returns an error from the features that rely on an optional phase", func() {
+			Expect(platform.Check()).To(MatchError("cf platform is not ready:\nthe platform was created without the check phase"))
+
+			_, _, err := platform.Deploy.WithoutStart().Execute("some-app", "/some/path/to/my/app")
+			Expect(err).To(MatchError("failed to deploy without starting: the platform was created without the collect phase"))
+		})
+	})
+
 	context("Initialize", func() {
 		it("initializes the buildpacks", func() {
 			err := platform.Initialize(
@@ -120,6 +157,121 @@ func testCloudFoundry(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("Info", func() {
+		it("reports the platform type", func() {
+			platformInfo, err := platform.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platformInfo).To(Equal(switchblade.PlatformInfo{Type: switchblade.CloudFoundry}))
+		})
+	})
+
+	context("Check", func() {
+		it("checks the platform", func() {
+			err := platform.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(check.RunCall.CallCount).To(Equal(1))
+		})
+
+		context("failure cases", func() {
+			context("the check phase finds problems", func() {
+				it.Before(func() {
+					check.RunCall.Returns.Error = errors.New("no TCP router group exists")
+				})
+
+				it("returns an error", func() {
+					err := platform.Check()
+					Expect(err).To(MatchError("cf platform is not ready:\nno TCP router group exists"))
+				})
+			})
+		})
+	})
+
+	context("Detect", func() {
+		it.Before(func() {
+			setup.WithoutRoutesCall.Returns.SetupPhase = setup
+			stage.WithoutStartCall.Returns.StagePhase = stage
+
+			collect.DropletCall.Returns.Droplet = cloudfoundry.Droplet{
+				Buildpacks: []cloudfoundry.DropletBuildpack{
+					{Name: "some-buildpack", Version: "1.2.3", DetectOutput: "some-output"},
+				},
+			}
+		})
+
+		it("stages the app without starting it and reports the buildpack that detected", func() {
+			results, err := platform.Detect("/some/path/to/my/app", "some-buildpack", "other-buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(Equal([]switchblade.DetectResult{
+				{Buildpack: "some-buildpack", Detected: true, Output: "some-output"},
+				{Buildpack: "other-buildpack", Detected: false, Unknown: true},
+			}))
+
+			Expect(setup.WithBuildpacksCall.CallCount).To(Equal(0))
+			Expect(setup.WithoutRoutesCall.CallCount).To(Equal(1))
+			Expect(stage.WithoutStartCall.CallCount).To(Equal(1))
+			Expect(setup.RunCall.Receives.Name).To(HavePrefix("switchblade-"))
+			Expect(setup.RunCall.Receives.Source).To(Equal("/some/path/to/my/app"))
+
+			Expect(teardown.RunCall.Receives.Name).To(Equal(setup.RunCall.Receives.Name))
+		})
+
+		context("when no buildpacks are given", func() {
+			it("reports the buildpack that detected", func() {
+				results, err := platform.Detect("/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(results).To(Equal([]switchblade.DetectResult{
+					{Buildpack: "some-buildpack", Detected: true, Output: "some-output"},
+				}))
+			})
+		})
+
+		context("when no buildpack detects the app", func() {
+			it.Before(func() {
+				stage.RunCall.Stub = func(logs io.Writer, home, name string) (string, error) {
+					fmt.Fprintln(logs, "Error staging application: NoAppDetectedError - An app was not successfully detected by any available buildpack")
+					return "", cloudfoundry.StartError{Err: errors.New("exit status 1")}
+				}
+			})
+
+			it("reports that none of the buildpacks detected", func() {
+				results, err := platform.Detect("/some/path/to/my/app", "some-buildpack", "other-buildpack")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(results).To(Equal([]switchblade.DetectResult{
+					{Buildpack: "some-buildpack", Detected: false},
+					{Buildpack: "other-buildpack", Detected: false},
+				}))
+
+				Expect(teardown.RunCall.CallCount).To(Equal(1))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the app cannot be staged", func() {
+				it.Before(func() {
+					setup.RunCall.Returns.Err = errors.New("failed to push")
+				})
+
+				it("returns an error and deletes the app", func() {
+					_, err := platform.Detect("/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to stage application: failed to push")))
+
+					Expect(teardown.RunCall.CallCount).To(Equal(1))
+				})
+			})
+
+			context("when the app cannot be deleted", func() {
+				it.Before(func() {
+					teardown.RunCall.Returns.Error = errors.New("failed to teardown")
+				})
+
+				it("returns an error", func() {
+					_, err := platform.Detect("/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to delete application: failed to teardown"))
+				})
+			})
+		})
+	})
+
 	context("Deploy", func() {
 		var home string
 
@@ -200,6 +352,34 @@ func testCloudFoundry(t *testing.T, context spec.G, it spec.S) {
 			Expect(logsCallReceived.Env).To(ContainElement(ContainSubstring("CF_HOME=")))
 		})
 
+		it("redacts secrets from the runtime logs and the errors of the deployment", func() {
+			setup.WithEnvCall.Returns.SetupPhase = setup
+			cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				switch execution.Args[0] {
+				case "logs":
+					fmt.Fprintln(execution.Stdout, "Using token secret-value")
+				case "create-user-provided-service":
+					fmt.Fprintln(execution.Stdout, "Invalid credentials service-password")
+					return errors.New("exit status 1")
+				}
+				return nil
+			}
+
+			deployment, _, err := platform.Deploy.
+				WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value"}).
+				Execute("some-app", "/some/path/to/my/app")
+			Expect(err).NotTo(HaveOccurred())
+
+			runtimeLogs, err := deployment.RuntimeLogs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtimeLogs).To(Equal("Using token [REDACTED]\n"))
+
+			_, err = deployment.BindService("some-service", switchblade.Service{"password": "service-password"})
+			Expect(err).To(MatchError(ContainSubstring("failed to bind service: failed to create-user-provided-service: exit status 1")))
+			Expect(err).To(MatchError(ContainSubstring("Invalid credentials [REDACTED]")))
+			Expect(err).NotTo(MatchError(ContainSubstring("service-password")))
+		})
+
 		context("WithBuildpacks", func() {
 			it("uses those buildpacks", func() {
 				platform.Deploy.WithBuildpacks("some-buildpack", "other-buildpack")
@@ -221,6 +401,22 @@ func testCloudFoundry(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("WithSecretEnv", func() {
+			it.Before(func() {
+				setup.WithEnvCall.Returns.SetupPhase = setup
+			})
+
+			it("uses those environment variables along with the others", func() {
+				platform.Deploy.
+					WithEnv(map[string]string{"SOME_KEY": "some-value"}).
+					WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value"})
+				Expect(setup.WithEnvCall.Receives.Env).To(Equal(map[string]string{
+					"SOME_KEY":   "some-value",
+					"SECRET_KEY": "secret-value",
+				}))
+			})
+		})
+
 		context("WithStartCommand", func() {
 			it("uses that start command", func() {
 				platform.Deploy.WithStartCommand("some-start-command")
@@ -235,6 +431,316 @@ func testCloudFoundry(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("WithObserver", func() {
+			it("reports and records the time taken by each phase", func() {
+				setup.RunCall.Stub = func(logs io.Writer, home, name, source string) (string, error) {
+					setup.WithObserverCall.Receives.Observer.Started("push")
+					setup.WithObserverCall.Receives.Observer.Finished("push", nil)
+					return "some-internal-url", nil
+				}
+
+				observer := &recordingObserver{}
+				deployment, _, err := platform.WithObserver(observer).Deploy.Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(observer.events).To(Equal([]string{
+					"started setup",
+					"started push",
+					"finished push",
+					"finished setup",
+					"started stage",
+					"finished stage",
+				}))
+				Expect(observer.finished[0].Platform).To(Equal(switchblade.CloudFoundry))
+
+				Expect(deployment.Timings).To(HaveLen(3))
+				Expect(deployment.Timings[1].Phase).To(Equal(switchblade.PhasePush))
+			})
+		})
+
+		context("WithDroplet", func() {
+			it.Before(func() {
+				setup.WithDropletCall.Returns.SetupPhase = setup
+			})
+
+			it("pushes that droplet", func() {
+				deployment, _, err := platform.Deploy.WithDroplet("/some/droplet.tgz").Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deployment.ExternalURL).To(Equal("some-external-url"))
+
+				Expect(setup.WithDropletCall.Receives.Path).To(Equal("/some/droplet.tgz"))
+				Expect(stage.RunCall.Receives.Name).To(Equal("some-app"))
+			})
+
+			it("restarts rather than restages the app when a service is bound or unbound", func() {
+				var executions []pexec.Execution
+				cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					executions = append(executions, execution)
+					return nil
+				}
+
+				deployment, _, err := platform.Deploy.WithDroplet("/some/droplet.tgz").Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				deployment, err = deployment.BindService("some-service", switchblade.Service{"some-key": "some-value"})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = deployment.UnbindService("some-service")
+				Expect(err).NotTo(HaveOccurred())
+
+				var args [][]string
+				for _, execution := range executions {
+					args = append(args, execution.Args)
+				}
+
+				Expect(args).To(Equal([][]string{
+					{"create-user-provided-service", "some-app-some-service", "-p", `{"some-key":"some-value"}`},
+					{"bind-service", "some-app", "some-app-some-service"},
+					{"restart", "some-app"},
+					{"unbind-service", "some-app", "some-app-some-service"},
+					{"delete-service", "some-app-some-service", "-f"},
+					{"restart", "some-app"},
+				}))
+			})
+
+			context("when the deployment is without starting", func() {
+				it.Before(func() {
+					setup.WithoutRoutesCall.Returns.SetupPhase = setup
+					stage.WithoutStartCall.Returns.StagePhase = stage
+				})
+
+				it("returns an error", func() {
+					_, _, err := platform.Deploy.WithDroplet("/some/droplet.tgz").WithoutStart().Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to deploy droplet: a droplet cannot be deployed without starting it"))
+					Expect(setup.RunCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("ExecuteImage", func() {
+			it.Before(func() {
+				setup.WithImageCall.Returns.SetupPhase = setup
+			})
+
+			it("pushes that image", func() {
+				deployment, _, err := platform.Deploy.ExecuteImage("some-app", "some-org/some-image:some-tag")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deployment.Name).To(Equal("some-app"))
+				Expect(deployment.ExternalURL).To(Equal("some-external-url"))
+
+				Expect(setup.WithImageCall.Receives.Image).To(Equal("some-org/some-image:some-tag"))
+				Expect(setup.RunCall.Receives.Name).To(Equal("some-app"))
+				Expect(setup.RunCall.Receives.Source).To(BeEmpty())
+				Expect(stage.RunCall.Receives.Name).To(Equal("some-app"))
+			})
+
+			context("when the deployment is without starting", func() {
+				it.Before(func() {
+					setup.WithoutRoutesCall.Returns.SetupPhase = setup
+					stage.WithoutStartCall.Returns.StagePhase = stage
+				})
+
+				it("returns an error", func() {
+					_, _, err := platform.Deploy.WithoutStart().ExecuteImage("some-app", "some-org/some-image:some-tag")
+					Expect(err).To(MatchError("failed to deploy image: an image cannot be deployed without starting it"))
+					Expect(setup.RunCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("WithoutStart", func() {
+			it.Before(func() {
+				setup.WithoutRoutesCall.Returns.SetupPhase = setup
+				stage.WithoutStartCall.Returns.StagePhase = stage
+
+				setup.RunCall.Stub = func(logs io.Writer, home, name, source string) (string, error) {
+					fmt.Fprintln(logs, "Setting up...")
+					return "", nil
+				}
+
+				stage.RunCall.Stub = func(logs io.Writer, home, name string) (string, error) {
+					fmt.Fprintln(logs, "Staging...")
+					return "", nil
+				}
+
+				collect.DropletCall.Returns.Droplet = cloudfoundry.Droplet{
+					GUID:         "some-droplet-guid",
+					Stack:        "some-stack",
+					Buildpacks:   []cloudfoundry.DropletBuildpack{{Name: "some-buildpack", Version: "1.2.3"}},
+					ProcessTypes: map[string]string{"web": "some-command"},
+					Metadata:     []byte(`{"guid": "some-droplet-guid"}`),
+				}
+			})
+
+			it("stages the app without routes and without starting it", func() {
+				deployment, logs, err := platform.Deploy.WithoutStart().Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logs).To(ContainLines(
+					"Setting up...",
+					"Staging...",
+				))
+
+				Expect(setup.WithoutRoutesCall.CallCount).To(Equal(1))
+				Expect(stage.WithoutStartCall.CallCount).To(Equal(1))
+
+				Expect(deployment.Name).To(Equal("some-app"))
+				Expect(deployment.ExternalURL).To(BeEmpty())
+				Expect(deployment.InternalURL).To(BeEmpty())
+				Expect(deployment.Droplet).To(Equal(switchblade.Droplet{
+					GUID:         "some-droplet-guid",
+					Stack:        "some-stack",
+					Buildpacks:   []switchblade.DropletBuildpack{{Name: "some-buildpack", Version: "1.2.3"}},
+					ProcessTypes: map[string]string{"web": "some-command"},
+					Metadata:     []byte(`{"guid": "some-droplet-guid"}`),
+				}))
+
+				Expect(collect.DropletCall.Receives.Home).To(Equal(filepath.Join(workspace, "some-app")))
+				Expect(collect.DropletCall.Receives.Name).To(Equal("some-app"))
+			})
+
+			context("when staging fails for an unrecognized reason", func() {
+				it.Before(func() {
+					stage.RunCall.Stub = func(logs io.Writer, home, name string) (string, error) {
+						fmt.Fprintln(logs, "Staging... errored")
+						return "", cloudfoundry.StartError{Err: errors.New("exit status 1")}
+					}
+				})
+
+				it("returns a staging error", func() {
+					_, _, err := platform.Deploy.WithoutStart().Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to start: exit status 1")))
+
+					var stagingErr switchblade.StagingError
+					Expect(errors.As(err, &stagingErr)).To(BeTrue())
+					Expect(stagingErr.Platform).To(Equal(switchblade.CloudFoundry))
+
+					var startErr switchblade.StartError
+					Expect(errors.As(err, &startErr)).To(BeFalse())
+				})
+			})
+
+			context("when the droplet cannot be read", func() {
+				it.Before(func() {
+					collect.DropletCall.Returns.Error = errors.New("app some-app has no droplets")
+				})
+
+				it("returns a platform error", func() {
+					_, _, err := platform.Deploy.WithoutStart().Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to read droplet: app some-app has no droplets")))
+
+					var platformErr switchblade.PlatformError
+					Expect(errors.As(err, &platformErr)).To(BeTrue())
+					Expect(platformErr.Phase).To(Equal("stage"))
+				})
+			})
+		})
+
+		context("WithArtifactsDir", func() {
+			var artifactsDir string
+
+			it.Before(func() {
+				var err error
+				artifactsDir, err = os.MkdirTemp("", "artifacts")
+				Expect(err).NotTo(HaveOccurred())
+
+				cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					if execution.Args[0] == "logs" {
+						fmt.Fprintln(execution.Stdout, "Application started successfully")
+					}
+					return nil
+				}
+
+				collect.RunCall.Returns.Artifacts = cloudfoundry.Artifacts{
+					App:     []byte("requested state: started\n"),
+					Env:     []byte("SOME_KEY: some-value\n"),
+					Droplet: []byte(`{"state": "STAGED"}`),
+				}
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(artifactsDir)).To(Succeed())
+			})
+
+			it("writes the artifacts of the deployment to that directory", func() {
+				_, logs, err := platform.Deploy.
+					WithArtifactsDir(artifactsDir).
+					WithDropletArtifact().
+					Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				dir := filepath.Join(artifactsDir, "some-app")
+				Expect(collect.RunCall.Receives.Home).To(Equal(filepath.Join(workspace, "some-app")))
+				Expect(collect.RunCall.Receives.Name).To(Equal("some-app"))
+				Expect(collect.RunCall.Receives.Droplet).To(Equal(filepath.Join(dir, switchblade.DropletArtifactFile)))
+
+				files := map[string]string{
+					switchblade.StagingLogFile: logs.String(),
+					switchblade.RuntimeLogFile: "Application started successfully\n",
+					switchblade.EnvFile:        "SOME_KEY: some-value\n",
+					"app.txt":                  "requested state: started\n",
+					"droplet.json":             `{"state": "STAGED"}`,
+				}
+				for name, content := range files {
+					actual, err := os.ReadFile(filepath.Join(dir, name))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(actual)).To(Equal(content), name)
+				}
+			})
+
+			context("when the app is staged without starting", func() {
+				it.Before(func() {
+					setup.WithoutRoutesCall.Returns.SetupPhase = setup
+					stage.WithoutStartCall.Returns.StagePhase = stage
+
+					collect.DropletCall.Returns.Droplet = cloudfoundry.Droplet{
+						GUID: "some-droplet-guid",
+					}
+				})
+
+				it("downloads the staged droplet and returns its path", func() {
+					deployment, _, err := platform.Deploy.
+						WithoutStart().
+						WithArtifactsDir(artifactsDir).
+						WithDropletArtifact().
+						Execute("some-app", "/some/path/to/my/app")
+					Expect(err).NotTo(HaveOccurred())
+
+					droplet := filepath.Join(artifactsDir, "some-app", switchblade.DropletArtifactFile)
+					Expect(collect.RunCall.Receives.Droplet).To(Equal(droplet))
+					Expect(deployment.Droplet.GUID).To(Equal("some-droplet-guid"))
+					Expect(deployment.Droplet.Path).To(Equal(droplet))
+				})
+			})
+
+			context("when the deployment fails", func() {
+				it.Before(func() {
+					setup.RunCall.Stub = func(logs io.Writer, home, name, source string) (string, error) {
+						fmt.Fprintln(logs, "Setting up... errored")
+						return "", errors.New("failed to setup")
+					}
+
+					collect.RunCall.Returns.Artifacts = cloudfoundry.Artifacts{}
+					collect.RunCall.Returns.Error = errors.New("failed to run cf app: exit status 1")
+				})
+
+				it("writes the artifacts that could be collected along with the problems", func() {
+					_, _, err := platform.Deploy.
+						WithArtifactsDir(artifactsDir).
+						Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(HavePrefix("failed to setup")))
+
+					dir := filepath.Join(artifactsDir, "some-app")
+					content, err := os.ReadFile(filepath.Join(dir, switchblade.StagingLogFile))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(Equal("Setting up... errored\n"))
+
+					content, err = os.ReadFile(filepath.Join(dir, switchblade.ArtifactsErrorsFile))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(Equal("failed to run cf app: exit status 1\n"))
+				})
+			})
+		})
+
 		context("WithoutServices", func() {
 			it("binds those services to the app", func() {
 				platform.Deploy.WithServices(map[string]switchblade.Service{
@@ -261,8 +767,36 @@ func testCloudFoundry(t *testing.T, context spec.G, it spec.S) {
 
 				it("returns an error", func() {
 					_, logs, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
-					Expect(err).To(MatchError("failed to setup"))
+					Expect(err).To(MatchError("failed to setup\n\nOutput:\nSetting up... errored\n"))
+					Expect(strings.Count(err.Error(), "Setting up... errored")).To(Equal(1))
 					Expect(logs).To(ContainLines("Setting up... errored"))
+
+					var setupErr switchblade.SetupError
+					Expect(errors.As(err, &setupErr)).To(BeTrue())
+					Expect(setupErr.Platform).To(Equal(switchblade.CloudFoundry))
+				})
+
+				context("when the logs are longer than the error log lines", func() {
+					it.Before(func() {
+						setup.RunCall.Stub = func(logs io.Writer, home, name, source string) (string, error) {
+							for i := 1; i <= 4; i++ {
+								fmt.Fprintf(logs, "Setting up line %d\n", i)
+							}
+							return "", errors.New("failed to setup")
+						}
+					})
+
+					it("includes the tail of the logs and spills the full log to a file", func() {
+						_, _, err := platform.Deploy.WithErrorLogLines(1).Execute("some-app", "/some/path/to/my/app")
+						Expect(err).To(MatchError(MatchRegexp(`^failed to setup\n\nOutput \(last 1 of 4 lines, full log at .+\):\nSetting up line 4\n$`)))
+
+						path := regexp.MustCompile(`full log at (.+)\)`).FindStringSubmatch(err.Error())[1]
+						defer os.Remove(path)
+
+						content, err := os.ReadFile(path)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(string(content)).To(ContainSubstring("Setting up line 1"))
+					})
 				})
 			})
 
@@ -281,8 +815,183 @@ func testCloudFoundry(t *testing.T, context spec.G, it spec.S) {
 						"Setting up...",
 						"Staging... errored",
 					))
+
+					var platformErr switchblade.PlatformError
+					Expect(errors.As(err, &platformErr)).To(BeTrue())
+					Expect(platformErr.Phase).To(Equal("stage"))
+				})
+			})
+
+			context("when the app fails to stage", func() {
+				it.Before(func() {
+					setup.WithBuildpacksCall.Returns.SetupPhase = setup
+					stage.RunCall.Stub = func(logs io.Writer, home, name string) (string, error) {
+						fmt.Fprintln(logs, "Error staging application: NoAppDetectedError - An app was not successfully detected by any available buildpack")
+						return "", cloudfoundry.StartError{Err: errors.New("exit status 1")}
+					}
+				})
+
+				it("returns a staging error", func() {
+					_, _, err := platform.Deploy.WithBuildpacks("some-buildpack").Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to start: exit status 1\n\nOutput:\n")))
+					Expect(err).To(MatchError(ContainSubstring("NoAppDetectedError")))
+
+					var stagingErr switchblade.StagingError
+					Expect(errors.As(err, &stagingErr)).To(BeTrue())
+					Expect(stagingErr.NoBuildpackDetected()).To(BeTrue())
+					Expect(stagingErr.Platform).To(Equal(switchblade.CloudFoundry))
+					Expect(stagingErr.Buildpack).To(Equal("some-buildpack"))
+					Expect(stagingErr.Logs).To(ContainSubstring("NoAppDetectedError"))
 				})
 			})
+
+			context("when the logs contain secrets", func() {
+				it.Before(func() {
+					setup.WithEnvCall.Returns.SetupPhase = setup
+					setup.WithServicesCall.Returns.SetupPhase = setup
+					setup.RunCall.Stub = func(logs io.Writer, home, name, source string) (string, error) {
+						fmt.Fprintln(logs, "Setting up with secret-value")
+						return "", errors.New("failed to create service with service-password")
+					}
+				})
+
+				it("redacts them from the logs and the error", func() {
+					_, logs, err := platform.Deploy.
+						WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value"}).
+						WithServices(map[string]switchblade.Service{
+							"some-service": {"password": "service-password"},
+						}).
+						Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to create service with [REDACTED]\n\nOutput:\nSetting up with [REDACTED]\n"))
+					Expect(logs).To(ContainLines("Setting up with [REDACTED]"))
+
+					var setupErr switchblade.SetupError
+					Expect(errors.As(err, &setupErr)).To(BeTrue())
+					Expect(setupErr.Logs).To(Equal("Setting up with [REDACTED]\n"))
+				})
+			})
+
+			context("when the app fails to start", func() {
+				it.Before(func() {
+					stage.RunCall.Stub = func(logs io.Writer, home, name string) (string, error) {
+						fmt.Fprintln(logs, "Start unsuccessful")
+						return "", cloudfoundry.StartError{Err: errors.New("exit status 1")}
+					}
+				})
+
+				it("returns a start error", func() {
+					_, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+
+					var startErr switchblade.StartError
+					Expect(errors.As(err, &startErr)).To(BeTrue())
+					Expect(startErr.Platform).To(Equal(switchblade.CloudFoundry))
+					Expect(startErr.Logs).To(ContainSubstring("Start unsuccessful"))
+				})
+			})
+		})
+	})
+
+	context("Attach", func() {
+		it("retrieves runtime logs from an existing deployment", func() {
+			cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				fmt.Fprintln(execution.Stdout, "Runtime log line 1")
+				return nil
+			}
+
+			deployment := platform.Attach("some-app")
+			Expect(deployment.Name).To(Equal("some-app"))
+
+			logs, err := deployment.RuntimeLogs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logs).To(Equal("Runtime log line 1\n"))
+
+			Expect(cli.ExecuteCall.Receives.Execution.Args).To(Equal([]string{"logs", "some-app", "--recent"}))
+			Expect(cli.ExecuteCall.Receives.Execution.Env).To(ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-app"))))
+		})
+
+		it("changes the environment of an existing deployment and restarts it", func() {
+			var executions []pexec.Execution
+			cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				executions = append(executions, execution)
+				return nil
+			}
+
+			deployment, err := platform.Attach("some-app").SetEnv(map[string]string{"SOME_KEY": "some-value"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deployment.Name).To(Equal("some-app"))
+
+			_, err = deployment.UnsetEnv("SOME_KEY")
+			Expect(err).NotTo(HaveOccurred())
+
+			var args [][]string
+			for _, execution := range executions {
+				args = append(args, execution.Args)
+				Expect(execution.Env).To(ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-app"))))
+			}
+
+			Expect(args).To(Equal([][]string{
+				{"set-env", "some-app", "SOME_KEY", "some-value"},
+				{"restart", "some-app"},
+				{"unset-env", "some-app", "SOME_KEY"},
+				{"restart", "some-app"},
+			}))
+		})
+
+		context("when the application cannot be restarted", func() {
+			it.Before(func() {
+				cli.ExecuteCall.Returns.Error = errors.New("exit status 1")
+			})
+
+			it("returns an error", func() {
+				_, err := platform.Attach("some-app").Restart()
+				Expect(err).To(MatchError(ContainSubstring("failed to restart application: failed to restart: exit status 1")))
+			})
+		})
+
+		it("binds and unbinds a service of an existing deployment and restages it", func() {
+			var executions []pexec.Execution
+			cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				executions = append(executions, execution)
+				return nil
+			}
+
+			deployment, err := platform.Attach("some-app").BindService("some-service", switchblade.Service{"some-key": "some-value"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deployment.Name).To(Equal("some-app"))
+
+			_, err = deployment.UnbindService("some-service")
+			Expect(err).NotTo(HaveOccurred())
+
+			var args [][]string
+			for _, execution := range executions {
+				args = append(args, execution.Args)
+				Expect(execution.Env).To(ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-app"))))
+			}
+
+			Expect(args).To(Equal([][]string{
+				{"create-user-provided-service", "some-app-some-service", "-p", `{"some-key":"some-value"}`},
+				{"bind-service", "some-app", "some-app-some-service"},
+				{"restage", "some-app"},
+				{"unbind-service", "some-app", "some-app-some-service"},
+				{"delete-service", "some-app-some-service", "-f"},
+				{"restage", "some-app"},
+			}))
+		})
+
+		context("when the service cannot be bound", func() {
+			it.Before(func() {
+				cli.ExecuteCall.Returns.Error = errors.New("exit status 1")
+			})
+
+			it("returns an error", func() {
+				_, err := platform.Attach("some-app").BindService("some-service", switchblade.Service{})
+				Expect(err).To(MatchError(ContainSubstring("failed to bind service: failed to create-user-provided-service: exit status 1")))
+			})
+		})
+
+		it("does not support exporting an image", func() {
+			err := platform.Attach("some-app").ExportImage("some-image:some-tag")
+			Expect(err).To(MatchError(`exporting an image is not supported on the "cf" platform`))
 		})
 	})
 
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/switchblade"
+)
+
+func check(args []string, stdout, stderr io.Writer) error {
+	var platformFlags platformFlags
+
+	set := newFlagSet("check", "", stderr)
+	platformFlags.register(set)
+
+	_, err := parseArgs(set, args, 0)
+	if err != nil {
+		return err
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	platform, err := switchblade.NewPlatformWithConfig(config)
+	if err != nil {
+		return err
+	}
+
+	err = platform.Check()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%s platform is ready\n", config.Type)
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cloudfoundry/switchblade"
+)
+
+// defaultPruneAge keeps the files that recent deployments are still likely to
+// reuse, so that a bare cleanup does not empty the whole cache.
+const defaultPruneAge = 7 * 24 * time.Hour
+
+func cleanup(args []string, stdout, stderr io.Writer) error {
+	var (
+		platformFlags platformFlags
+		olderThan     time.Duration
+	)
+
+	set := newFlagSet("cleanup", "", stderr)
+	platformFlags.register(set)
+	set.DurationVar(&olderThan, "older-than", defaultPruneAge, "only prune cached files that have not been modified within this duration, or every cached file when 0 (docker only)")
+
+	_, err := parseArgs(set, args, 0)
+	if err != nil {
+		return err
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	platform, err := switchblade.NewPlatformWithConfig(config)
+	if err != nil {
+		return err
+	}
+
+	names, err := listDeployments(config)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = platform.Delete.Execute(name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "deleted %s\n", name)
+	}
+
+	err = platform.Deinitialize()
+	if err != nil {
+		return err
+	}
+
+	if config.Type == switchblade.Docker {
+		if config.Workspace != "" {
+			err = switchblade.PruneCacheIn(config.Workspace, olderThan)
+		} else {
+			err = switchblade.PruneCache(olderThan)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/switchblade"
+)
+
+func remove(args []string, stdout, stderr io.Writer) error {
+	var platformFlags platformFlags
+
+	set := newFlagSet("delete", "NAME", stderr)
+	platformFlags.register(set)
+
+	positional, err := parseArgs(set, args, 1)
+	if err != nil {
+		return err
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	platform, err := switchblade.NewPlatformWithConfig(config)
+	if err != nil {
+		return err
+	}
+
+	err = platform.Delete.Execute(positional[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "deleted %s\n", positional[0])
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry/switchblade"
+)
+
+func deploy(args []string, stdout, stderr io.Writer) error {
+	var (
+		platformFlags   platformFlags
+		buildpacks      stringList
+		env             stringList
+		secretEnv       stringList
+		services        stringList
+		startCommand    string
+		healthCheckType string
+		noInternet      bool
+		artifactsDir    string
+		dropletArtifact bool
+		withoutStart    bool
+		droplet         string
+	)
+
+	set := newFlagSet("deploy", "NAME PATH", stderr)
+	platformFlags.register(set)
+	set.Var(&buildpacks, "buildpack", "buildpack to use, either a NAME or NAME=URI of a custom buildpack (repeatable)")
+	set.Var(&env, "env", "environment variable to set, as KEY=VALUE (repeatable)")
+	set.Var(&secretEnv, "secret-env", "environment variable to set whose value is redacted from the output, as KEY=VALUE (repeatable)")
+	set.Var(&services, "service", "service to bind, as NAME=JSON, or NAME=@FILE to read the JSON credentials from a file (repeatable)")
+	set.StringVar(&startCommand, "start-command", "", "command to start the application with")
+	set.StringVar(&healthCheckType, "health-check-type", "", "health check type to use (cf only)")
+	set.BoolVar(&noInternet, "no-internet", false, "run the application without internet access")
+	set.StringVar(&artifactsDir, "artifacts-dir", "", "directory to write the logs, environment, and metadata of the deployment to")
+	set.BoolVar(&dropletArtifact, "droplet-artifact", false, "also write the droplet to the artifacts directory")
+	set.BoolVar(&withoutStart, "without-start", false, "stage the application into a droplet without starting it")
+	set.StringVar(&droplet, "droplet", "", "droplet to start instead of staging the application")
+
+	positional, err := parseArgs(set, args, 2)
+	if err != nil {
+		return err
+	}
+	name, path := positional[0], positional[1]
+
+	names, custom, err := parseBuildpacks(buildpacks)
+	if err != nil {
+		return err
+	}
+
+	environment, err := parseEnv("env", env)
+	if err != nil {
+		return err
+	}
+
+	secretEnvironment, err := parseEnv("secret-env", secretEnv)
+	if err != nil {
+		return err
+	}
+
+	bindings, err := parseServices(services)
+	if err != nil {
+		return err
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	platform, err := switchblade.NewPlatformWithConfig(config)
+	if err != nil {
+		return err
+	}
+
+	err = platform.Initialize(custom...)
+	if err != nil {
+		return err
+	}
+
+	process := platform.Deploy.WithStack(config.Stack)
+	if len(names) > 0 {
+		process = process.WithBuildpacks(names...)
+	}
+	if len(environment) > 0 {
+		process = process.WithEnv(environment)
+	}
+	if len(secretEnvironment) > 0 {
+		process = process.WithSecretEnv(secretEnvironment)
+	}
+	if len(bindings) > 0 {
+		process = process.WithServices(bindings)
+	}
+	if startCommand != "" {
+		process = process.WithStartCommand(startCommand)
+	}
+	if healthCheckType != "" {
+		process = process.WithHealthCheckType(healthCheckType)
+	}
+	if noInternet {
+		process = process.WithoutInternetAccess()
+	}
+	if artifactsDir != "" {
+		process = process.WithArtifactsDir(artifactsDir)
+	}
+	if dropletArtifact {
+		process = process.WithDropletArtifact()
+	}
+	if withoutStart {
+		process = process.WithoutStart()
+	}
+	if droplet != "" {
+		process = process.WithDroplet(droplet)
+	}
+
+	// On failure, the end of the logs is part of the error, along with the
+	// path of a file that holds them in full.
+	deployment, logs, err := process.Execute(name, path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(stderr, logs)
+	fmt.Fprintf(stdout, "name:         %s\n", deployment.Name)
+
+	if withoutStart {
+		var buildpacks []string
+		for _, buildpack := range deployment.Droplet.Buildpacks {
+			buildpacks = append(buildpacks, fmt.Sprintf("%s@%s", buildpack.Name, buildpack.Version))
+		}
+
+		if deployment.Droplet.Path != "" {
+			fmt.Fprintf(stdout, "droplet:      %s\n", deployment.Droplet.Path)
+		}
+		if deployment.Droplet.GUID != "" {
+			fmt.Fprintf(stdout, "droplet:      %s\n", deployment.Droplet.GUID)
+		}
+		fmt.Fprintf(stdout, "buildpacks:   %s\n", strings.Join(buildpacks, ", "))
+
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "external url: %s\n", deployment.ExternalURL)
+	fmt.Fprintf(stdout, "internal url: %s\n", deployment.InternalURL)
+
+	return nil
+}
+
+// parseBuildpacks splits the values of -buildpack into the names of the
+// buildpacks to use and the custom buildpacks to initialize the platform with.
+func parseBuildpacks(values []string) ([]string, []switchblade.Buildpack, error) {
+	var (
+		names  []string
+		custom []switchblade.Buildpack
+	)
+	for _, buildpack := range values {
+		bpName, uri, found := strings.Cut(buildpack, "=")
+		if bpName == "" || (found && uri == "") {
+			return nil, nil, usageError{message: fmt.Sprintf("invalid -buildpack %q: expected NAME or NAME=URI", buildpack)}
+		}
+
+		names = append(names, bpName)
+		if found {
+			custom = append(custom, switchblade.Buildpack{Name: bpName, URI: uri})
+		}
+	}
+
+	return names, custom, nil
+}
+
+func parseEnv(flag string, values []string) (map[string]string, error) {
+	env := map[string]string{}
+	for _, value := range values {
+		key, val, found := strings.Cut(value, "=")
+		if !found || key == "" {
+			return nil, usageError{message: fmt.Sprintf("invalid -%s %q: expected KEY=VALUE", flag, value)}
+		}
+
+		env[key] = val
+	}
+
+	return env, nil
+}
+
+func parseServices(values []string) (map[string]switchblade.Service, error) {
+	services := map[string]switchblade.Service{}
+	for _, value := range values {
+		name, content, found := strings.Cut(value, "=")
+		if !found || name == "" || content == "" {
+			return nil, usageError{message: fmt.Sprintf("invalid -service %q: expected NAME=JSON or NAME=@FILE", value)}
+		}
+
+		if path, ok := strings.CutPrefix(content, "@"); ok {
+			file, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read service %q: %w", name, err)
+			}
+
+			content = string(file)
+		}
+
+		var service switchblade.Service
+		err := json.Unmarshal([]byte(content), &service)
+		if err != nil {
+			return nil, usageError{message: fmt.Sprintf("invalid -service %q: failed to parse JSON: %s", name, err)}
+		}
+
+		services[name] = service
+	}
+
+	return services, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/switchblade"
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// listDeployments returns the names of the deployments on the platform. On
+// Docker, these are the containers attached to the switchblade network. On
+// Cloud Foundry, these are the orgs that have a deployment home in the
+// temporary directory, since each deployment is given its own org.
+func listDeployments(config switchblade.Config) ([]string, error) {
+	var names []string
+
+	switch config.Type {
+	case switchblade.Docker:
+		containers, err := config.DockerClient.ContainerList(context.Background(), container.ListOptions{
+			All:     true,
+			Filters: filters.NewArgs(filters.Arg("network", docker.InternalNetworkName)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers: %w", err)
+		}
+
+		for _, c := range containers {
+			for _, name := range c.Names {
+				names = append(names, strings.TrimPrefix(name, "/"))
+			}
+		}
+
+	case switchblade.CloudFoundry:
+		env := os.Environ()
+		if config.CFHome != "" {
+			env = append(env, fmt.Sprintf("CF_HOME=%s", filepath.Dir(config.CFHome)))
+		}
+
+		buffer := bytes.NewBuffer(nil)
+		err := pexec.NewExecutable("cf").Execute(pexec.Execution{
+			Args:   []string{"curl", "/v3/organizations?per_page=5000"},
+			Stdout: buffer,
+			Stderr: buffer,
+			Env:    env,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to curl /v3/organizations: %w\n\nOutput:\n%s", err, buffer)
+		}
+
+		var orgs struct {
+			Resources []struct {
+				Name string `json:"name"`
+			} `json:"resources"`
+		}
+		err = json.NewDecoder(buffer).Decode(&orgs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode organizations json: %w", err)
+		}
+
+		for _, org := range orgs.Resources {
+			_, err := os.Stat(filepath.Join(cfTempDir(config), org.Name, ".cf"))
+			if err == nil {
+				names = append(names, org.Name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// execDeployment runs the command inside the running deployment, streaming
+// its output. A command that exits unsuccessfully is reported as an
+// exitError with the same status.
+func execDeployment(config switchblade.Config, name string, command []string, stdout, stderr io.Writer) error {
+	switch config.Type {
+	case switchblade.Docker:
+		ctx := context.Background()
+
+		resp, err := config.DockerClient.ContainerExecCreate(ctx, name, container.ExecOptions{
+			Cmd:          command,
+			AttachStdout: true,
+			AttachStderr: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create exec: %w", err)
+		}
+
+		attach, err := config.DockerClient.ContainerExecAttach(ctx, resp.ID, container.ExecAttachOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to attach to exec: %w", err)
+		}
+		defer attach.Close()
+
+		_, err = stdcopy.StdCopy(stdout, stderr, attach.Reader)
+		if err != nil {
+			return fmt.Errorf("failed to read exec output: %w", err)
+		}
+
+		inspect, err := config.DockerClient.ContainerExecInspect(ctx, resp.ID)
+		if err != nil {
+			return fmt.Errorf("failed to inspect exec: %w", err)
+		}
+
+		if inspect.ExitCode != 0 {
+			return exitError{code: inspect.ExitCode}
+		}
+
+	case switchblade.CloudFoundry:
+		err := pexec.NewExecutable("cf").Execute(pexec.Execution{
+			Args:   []string{"ssh", name, "-c", strings.Join(command, " ")},
+			Stdout: stdout,
+			Stderr: stderr,
+			Env:    append(os.Environ(), fmt.Sprintf("CF_HOME=%s", filepath.Join(cfTempDir(config), name))),
+		})
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				return exitError{code: exitErr.ExitCode()}
+			}
+
+			return fmt.Errorf("failed to run cf ssh: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func cfTempDir(config switchblade.Config) string {
+	if config.CFTempDir != "" {
+		return config.CFTempDir
+	}
+
+	return os.TempDir()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/switchblade"
+)
+
+func detect(args []string, stdout, stderr io.Writer) error {
+	var (
+		platformFlags platformFlags
+		buildpacks    stringList
+	)
+
+	set := newFlagSet("detect", "PATH", stderr)
+	platformFlags.register(set)
+	set.Var(&buildpacks, "buildpack", "buildpack to detect with, either a NAME or NAME=URI of a custom buildpack (repeatable)")
+
+	positional, err := parseArgs(set, args, 1)
+	if err != nil {
+		return err
+	}
+
+	names, custom, err := parseBuildpacks(buildpacks)
+	if err != nil {
+		return err
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	platform, err := switchblade.NewPlatformWithConfig(config)
+	if err != nil {
+		return err
+	}
+
+	err = platform.Initialize(custom...)
+	if err != nil {
+		return err
+	}
+
+	results, err := platform.Detect(positional[0], names...)
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		detected := "no"
+		switch {
+		case result.Detected:
+			detected = "yes"
+		case result.Unknown:
+			detected = "unknown"
+		}
+
+		fmt.Fprintf(stdout, "%s\t%s\t%s\n", result.Buildpack, detected, result.Output)
+	}
+
+	return nil
+}
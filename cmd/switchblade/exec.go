@@ -0,0 +1,39 @@
+package main
+
+import (
+	"io"
+)
+
+func execute(args []string, stdout, stderr io.Writer) error {
+	var platformFlags platformFlags
+
+	set := newFlagSet("exec", "NAME -- COMMAND [ARGS...]", stderr)
+	platformFlags.register(set)
+
+	// Everything after "--" is the command, so that its flags are not parsed
+	// as flags of exec.
+	var command []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, command = args[:i], args[i+1:]
+			break
+		}
+	}
+
+	positional, err := parseArgs(set, args, 1)
+	if err != nil {
+		return err
+	}
+
+	if len(command) == 0 {
+		set.Usage()
+		return usageError{message: "expected a command after --"}
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	return execDeployment(config, positional[0], command, stdout, stderr)
+}
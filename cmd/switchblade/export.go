@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/switchblade"
+)
+
+func export(args []string, stdout, stderr io.Writer) error {
+	var platformFlags platformFlags
+
+	set := newFlagSet("export", "NAME TAG", stderr)
+	platformFlags.register(set)
+
+	positional, err := parseArgs(set, args, 2)
+	if err != nil {
+		return err
+	}
+	name, tag := positional[0], positional[1]
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	platform, err := switchblade.NewPlatformWithConfig(config)
+	if err != nil {
+		return err
+	}
+
+	err = platform.Attach(name).ExportImage(tag)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "docker run --rm -p 8080:8080 %s\n", tag)
+
+	return nil
+}
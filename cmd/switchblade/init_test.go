@@ -0,0 +1,38 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/onsi/gomega/format"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	. "github.com/onsi/gomega"
+)
+
+var path string
+
+func TestSwitchblade(t *testing.T) {
+	var Expect = NewWithT(t).Expect
+
+	format.MaxLength = 0
+
+	dir, err := os.MkdirTemp("", "switchblade")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	path = filepath.Join(dir, "switchblade")
+	err = pexec.NewExecutable("go").Execute(pexec.Execution{
+		Args:   []string{"build", "-buildvcs=false", "-o", path, "."},
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	suite := spec.New("switchblade", spec.Report(report.Terminal{}), spec.Parallel())
+	suite("Main", testMain)
+	suite.Run(t)
+}
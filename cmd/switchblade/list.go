@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+func list(args []string, stdout, stderr io.Writer) error {
+	var platformFlags platformFlags
+
+	set := newFlagSet("list", "", stderr)
+	platformFlags.register(set)
+
+	_, err := parseArgs(set, args, 0)
+	if err != nil {
+		return err
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	names, err := listDeployments(config)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		fmt.Fprintln(stdout, name)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/switchblade"
+)
+
+func logs(args []string, stdout, stderr io.Writer) error {
+	var platformFlags platformFlags
+
+	set := newFlagSet("logs", "NAME", stderr)
+	platformFlags.register(set)
+
+	positional, err := parseArgs(set, args, 1)
+	if err != nil {
+		return err
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	platform, err := switchblade.NewPlatformWithConfig(config)
+	if err != nil {
+		return err
+	}
+
+	output, err := platform.Attach(positional[0]).RuntimeLogs()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(stdout, output)
+
+	return nil
+}
@@ -0,0 +1,211 @@
+// Command switchblade deploys applications to Cloud Foundry or to a local
+// Docker daemon that emulates it, using the same Platform API that tests use.
+//
+// The platform is configured from the environment as described by
+// switchblade.ConfigFromEnv, and can be overridden with flags.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry/switchblade"
+	"github.com/docker/docker/client"
+)
+
+const usage = `Usage: switchblade <command> [flags] [args]
+
+Commands:
+  deploy   NAME PATH        stage and start the application at PATH
+  detect   PATH             report which buildpacks detect the application at PATH
+  logs     NAME             print the runtime logs of a deployment
+  restart  NAME             restart a deployment, optionally changing its environment
+  export   NAME TAG         export a deployment as a local image (docker only)
+  exec     NAME -- COMMAND  run a command inside a deployment
+  delete   NAME             delete a deployment
+  list                      list deployments
+  cleanup                   delete all deployments and prune cached files
+  check                     check that the platform is ready to deploy
+
+Run "switchblade <command> -h" for the flags of a command.
+`
+
+type command func(args []string, stdout, stderr io.Writer) error
+
+// usageError is returned when a command is invoked with invalid arguments.
+type usageError struct {
+	message string
+}
+
+func (e usageError) Error() string {
+	return e.message
+}
+
+// exitError is returned when a command should exit with a specific status,
+// such as the status of a command run by exec.
+type exitError struct {
+	code int
+}
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	commands := map[string]command{
+		"deploy":  deploy,
+		"detect":  detect,
+		"logs":    logs,
+		"restart": restart,
+		"export":  export,
+		"exec":    execute,
+		"delete":  remove,
+		"list":    list,
+		"cleanup": cleanup,
+		"check":   check,
+	}
+
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	err := cmd(args[1:], stdout, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		var exitErr exitError
+		if errors.As(err, &exitErr) {
+			return exitErr.code
+		}
+
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			// Errors from parsing flags have already been printed along with the
+			// usage of the command.
+			if usageErr.message != "" {
+				fmt.Fprintf(stderr, "switchblade %s: %s\n", args[0], usageErr.message)
+			}
+
+			return 2
+		}
+
+		fmt.Fprintf(stderr, "switchblade %s: %s\n", args[0], err)
+
+		return 1
+	}
+
+	return 0
+}
+
+// platformFlags are the flags that every command accepts to configure the
+// platform on top of the environment.
+type platformFlags struct {
+	platform string
+	stack    string
+	offline  bool
+}
+
+func (f *platformFlags) register(set *flag.FlagSet) {
+	set.StringVar(&f.platform, "platform", "", fmt.Sprintf("platform to use, either %q or %q (defaults to $%s)", switchblade.CloudFoundry, switchblade.Docker, switchblade.PlatformEnv))
+	set.StringVar(&f.stack, "stack", "", fmt.Sprintf("stack to use (defaults to $%s or %q)", switchblade.StackEnv, switchblade.DefaultStack))
+	set.BoolVar(&f.offline, "offline", false, "resolve buildpacks only from the local cache (docker only)")
+}
+
+// config returns the platform Config from the environment with the flags
+// applied. For the Docker platform, a client is created so that commands can
+// share it with the Platform.
+func (f platformFlags) config() (switchblade.Config, error) {
+	var (
+		config switchblade.Config
+		err    error
+	)
+	if f.platform != "" {
+		config, err = switchblade.ConfigFromEnvWithType(f.platform)
+	} else {
+		config, err = switchblade.ConfigFromEnv()
+	}
+	if err != nil {
+		return switchblade.Config{}, err
+	}
+
+	if f.stack != "" {
+		config.Stack = f.stack
+	}
+
+	config.Offline = f.offline
+
+	if config.Type == switchblade.Docker {
+		config.DockerClient, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return switchblade.Config{}, fmt.Errorf("failed to create docker client: %w", err)
+		}
+	}
+
+	return config, nil
+}
+
+func newFlagSet(name, args string, stderr io.Writer) *flag.FlagSet {
+	set := flag.NewFlagSet(name, flag.ContinueOnError)
+	set.SetOutput(stderr)
+	set.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: switchblade %s [flags] %s\n\nFlags:\n", name, args)
+		set.PrintDefaults()
+	}
+
+	return set
+}
+
+// parseArgs parses the flags and checks that exactly the given number of
+// positional arguments remain.
+func parseArgs(set *flag.FlagSet, args []string, count int) ([]string, error) {
+	err := set.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+
+		return nil, usageError{}
+	}
+
+	if set.NArg() != count {
+		set.Usage()
+		return nil, usageError{message: fmt.Sprintf("expected %d argument(s), got %d", count, set.NArg())}
+	}
+
+	return set.Args(), nil
+}
+
+// stringList is a flag that can be given multiple times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
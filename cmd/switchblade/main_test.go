@@ -0,0 +1,160 @@
+package main_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testMain(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		stdout *bytes.Buffer
+		stderr *bytes.Buffer
+	)
+
+	it.Before(func() {
+		stdout = bytes.NewBuffer(nil)
+		stderr = bytes.NewBuffer(nil)
+	})
+
+	run := func(args ...string) int {
+		cmd := exec.Command(path, args...)
+		cmd.Env = []string{"PATH=", "HOME=" + os.TempDir()}
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode()
+		}
+		Expect(err).NotTo(HaveOccurred())
+
+		return 0
+	}
+
+	it("prints the usage", func() {
+		Expect(run("--help")).To(Equal(0))
+		Expect(stdout.String()).To(ContainSubstring("Usage: switchblade <command> [flags] [args]"))
+		Expect(stdout.String()).To(ContainSubstring("deploy   NAME PATH"))
+	})
+
+	it("prints the usage of a command", func() {
+		Expect(run("deploy", "-h")).To(Equal(0))
+		Expect(stderr.String()).To(ContainSubstring("Usage: switchblade deploy [flags] NAME PATH"))
+		Expect(stderr.String()).To(ContainSubstring("-buildpack"))
+		Expect(stderr.String()).To(ContainSubstring("-offline"))
+	})
+
+	it("keeps recently modified cached files by default when cleaning up", func() {
+		Expect(run("cleanup", "-h")).To(Equal(0))
+		Expect(stderr.String()).To(ContainSubstring("(default 168h0m0s)"))
+	})
+
+	context("failure cases", func() {
+		context("when no command is given", func() {
+			it("prints the usage and fails", func() {
+				Expect(run()).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring("Usage: switchblade <command> [flags] [args]"))
+			})
+		})
+
+		context("when the command is unknown", func() {
+			it("fails", func() {
+				Expect(run("some-command")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring(`unknown command "some-command"`))
+			})
+		})
+
+		context("when the arguments are missing", func() {
+			it("fails", func() {
+				Expect(run("deploy", "some-app")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring("switchblade deploy: expected 2 argument(s), got 1"))
+			})
+		})
+
+		context("when a -buildpack value to detect with is malformed", func() {
+			it("fails", func() {
+				Expect(run("detect", "-buildpack", "some-buildpack=", "/some/path")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring(`switchblade detect: invalid -buildpack "some-buildpack=": expected NAME or NAME=URI`))
+			})
+		})
+
+		context("when a flag is not defined", func() {
+			it("fails", func() {
+				Expect(run("list", "-some-flag")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring("flag provided but not defined: -some-flag"))
+			})
+		})
+
+		context("when an env var is malformed", func() {
+			it("fails", func() {
+				Expect(run("deploy", "-env", "SOME_KEY", "some-app", "/some/path")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring(`switchblade deploy: invalid -env "SOME_KEY": expected KEY=VALUE`))
+			})
+		})
+
+		context("when a secret env var is malformed", func() {
+			it("fails", func() {
+				Expect(run("deploy", "-secret-env", "=some-value", "some-app", "/some/path")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring(`switchblade deploy: invalid -secret-env "=some-value": expected KEY=VALUE`))
+			})
+		})
+
+		context("when a service is malformed", func() {
+			it("fails", func() {
+				Expect(run("deploy", "-service", "some-service={", "some-app", "/some/path")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring(`switchblade deploy: invalid -service "some-service": failed to parse JSON`))
+			})
+		})
+
+		context("when a service file cannot be read", func() {
+			it("fails", func() {
+				Expect(run("deploy", "-service", "some-service=@"+filepath.Join(os.TempDir(), "no-such-file"), "some-app", "/some/path")).To(Equal(1))
+				Expect(stderr.String()).To(ContainSubstring(`switchblade deploy: failed to read service "some-service"`))
+			})
+		})
+
+		context("when an env var to restart with is malformed", func() {
+			it("fails", func() {
+				Expect(run("restart", "-env", "SOME_KEY", "some-app")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring(`switchblade restart: invalid -env "SOME_KEY": expected KEY=VALUE`))
+			})
+		})
+
+		context("when export is not given a tag", func() {
+			it("fails", func() {
+				Expect(run("export", "some-app")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring("switchblade export: expected 2 argument(s), got 1"))
+			})
+		})
+
+		context("when exec is not given a command", func() {
+			it("fails", func() {
+				Expect(run("exec", "some-app")).To(Equal(2))
+				Expect(stderr.String()).To(ContainSubstring("switchblade exec: expected a command after --"))
+			})
+		})
+
+		context("when the platform is not configured", func() {
+			it("fails", func() {
+				Expect(run("list")).To(Equal(1))
+				Expect(stderr.String()).To(ContainSubstring(`switchblade list: SWITCHBLADE_PLATFORM must be set to "cf" or "docker"`))
+			})
+		})
+
+		context("when the platform is not supported", func() {
+			it("fails", func() {
+				Expect(run("delete", "-platform", "some-platform", "some-app")).To(Equal(1))
+				Expect(stderr.String()).To(ContainSubstring(`switchblade delete: unsupported platform "some-platform": use "cf" or "docker"`))
+			})
+		})
+	})
+}
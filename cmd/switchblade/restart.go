@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry/switchblade"
+)
+
+func restart(args []string, stdout, stderr io.Writer) error {
+	var (
+		platformFlags platformFlags
+		env           stringList
+		unset         stringList
+	)
+
+	set := newFlagSet("restart", "NAME", stderr)
+	platformFlags.register(set)
+	set.Var(&env, "env", "environment variable to set before restarting, as KEY=VALUE (repeatable)")
+	set.Var(&unset, "unset", "environment variable to remove before restarting (repeatable)")
+
+	positional, err := parseArgs(set, args, 1)
+	if err != nil {
+		return err
+	}
+
+	environment, err := parseEnv("env", env)
+	if err != nil {
+		return err
+	}
+
+	config, err := platformFlags.config()
+	if err != nil {
+		return err
+	}
+
+	platform, err := switchblade.NewPlatformWithConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// Each change restarts the application, so it is only restarted on its
+	// own when there are no changes.
+	deployment := platform.Attach(positional[0])
+	if len(environment) > 0 {
+		deployment, err = deployment.SetEnv(environment)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(unset) > 0 {
+		deployment, err = deployment.UnsetEnv(unset...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(environment) == 0 && len(unset) == 0 {
+		deployment, err = deployment.Restart()
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(stdout, "name:         %s\n", deployment.Name)
+	if deployment.ExternalURL != "" {
+		fmt.Fprintf(stdout, "external url: %s\n", deployment.ExternalURL)
+		fmt.Fprintf(stdout, "internal url: %s\n", deployment.InternalURL)
+	}
+
+	return nil
+}
@@ -0,0 +1,368 @@
+package switchblade
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/docker/docker/client"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+const (
+	DefaultGitHubAPI   = "https://api.github.com"
+	DefaultRegistryTTL = 24 * time.Hour
+)
+
+// Config describes a Platform created with NewPlatformWithConfig. Only Type is
+// required. Every other field falls back to the same default as NewPlatform,
+// and the home directory is only consulted for fields that are left empty.
+type Config struct {
+	// Type is the kind of platform to create, either CloudFoundry or Docker.
+	Type string
+
+	// Token is the GitHub API token used by the Docker platform to look up
+	// buildpack releases.
+	Token string
+
+	// Stack is the default stack that applications are deployed onto.
+	Stack string
+
+	// Workspace is the directory that the Docker platform keeps its
+	// lifecycle, buildpacks, and per-application files in. It defaults to
+	// ~/.switchblade.
+	Workspace string
+
+	// CFHome is the directory containing the .cf configuration of the logged
+	// in cf CLI. It defaults to ~/.cf.
+	CFHome string
+
+	// CFTempDir is the directory that per-application cf CLI homes are
+	// created in. It defaults to os.TempDir().
+	CFTempDir string
+
+	// DockerClient is the client used to reach the Docker daemon. When nil, a
+	// client is created from DockerHost or the standard DOCKER_* environment
+	// variables.
+	DockerClient *client.Client
+
+	// DockerHost is the address of the Docker daemon, such as
+	// "unix:///var/run/docker.sock". It is ignored when DockerClient is set.
+	DockerHost string
+
+	// GitHubAPI is the base URL of the GitHub API. It defaults to
+	// DefaultGitHubAPI.
+	GitHubAPI string
+
+	// HTTPClient is used by the Docker platform to download the lifecycle and
+	// buildpacks. It defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// LifecycleSource is the remote zip archive, local checkout, or directory
+	// of prebuilt binaries that the Docker platform builds the lifecycle from.
+	// It defaults to the tip of the buildpackapplifecycle main branch.
+	LifecycleSource string
+
+	// Architecture is the CPU architecture that the Docker platform builds the
+	// lifecycle for and runs the stack image as. It defaults to the
+	// architecture of the Docker daemon, which is looked up the first time a
+	// phase needs it.
+	Architecture string
+
+	// BuildpackCatalog replaces the default set of buildpacks that the Docker
+	// platform makes available.
+	BuildpackCatalog []CatalogEntry
+
+	// BuildpackCatalogFile is a JSON catalog file that replaces the default
+	// set of buildpacks that the Docker platform makes available. It cannot be
+	// combined with BuildpackCatalog.
+	BuildpackCatalogFile string
+
+	// RegistryTTL is how long resolved buildpack releases are reused. It
+	// defaults to DefaultRegistryTTL. A negative value disables reuse.
+	RegistryTTL time.Duration
+
+	// Offline resolves buildpacks and the lifecycle on the Docker platform
+	// entirely from disk.
+	Offline bool
+}
+
+// PlatformOption configures optional behavior of a Platform created with
+// NewPlatform.
+type PlatformOption func(*Config)
+
+// CatalogEntry describes a buildpack that the Docker platform resolves from
+// the GitHub releases of its repository. The Repository is given as
+// "org/repo" and defaults to "cloudfoundry/<name>-buildpack". When Tag is
+// empty, the latest release is used.
+type CatalogEntry struct {
+	Name       string
+	Repository string
+	Tag        string
+}
+
+// WithLifecycleRevision pins the buildpackapplifecycle used by the Docker
+// platform to the given branch, tag, or commit SHA instead of the tip of the
+// main branch.
+func WithLifecycleRevision(ref string) PlatformOption {
+	return func(c *Config) {
+		c.LifecycleSource = docker.LifecycleArchiveURL(ref)
+	}
+}
+
+// WithLifecyclePath builds the buildpackapplifecycle used by the Docker
+// platform from a local checkout. If the directory instead contains prebuilt
+// builder and launcher binaries, those are used as-is.
+func WithLifecyclePath(path string) PlatformOption {
+	return func(c *Config) {
+		c.LifecycleSource = path
+	}
+}
+
+// WithArchitecture sets the CPU architecture, such as "amd64" or "arm64", that
+// the Docker platform builds the lifecycle for and runs the stack image as.
+// When unset, the architecture of the Docker daemon is used.
+func WithArchitecture(arch string) PlatformOption {
+	return func(c *Config) {
+		c.Architecture = arch
+	}
+}
+
+// WithBuildpackCatalog replaces the default set of buildpacks that the Docker
+// platform makes available. Buildpacks are ordered as they are given.
+func WithBuildpackCatalog(entries ...CatalogEntry) PlatformOption {
+	return func(c *Config) {
+		c.BuildpackCatalog = entries
+	}
+}
+
+// WithBuildpackCatalogFile replaces the default set of buildpacks that the
+// Docker platform makes available with those listed in a JSON catalog file.
+// It cannot be combined with WithBuildpackCatalog.
+func WithBuildpackCatalogFile(path string) PlatformOption {
+	return func(c *Config) {
+		c.BuildpackCatalogFile = path
+	}
+}
+
+// WithRegistryTTL sets how long buildpack releases resolved by the Docker
+// platform are reused from the index under the Switchblade workspace before
+// they are looked up again. It defaults to 24 hours, and a negative ttl
+// disables reuse.
+func WithRegistryTTL(ttl time.Duration) PlatformOption {
+	return func(c *Config) {
+		c.RegistryTTL = ttl
+	}
+}
+
+// WithOffline resolves buildpacks on the Docker platform entirely from the
+// index and buildpack cache under the Switchblade workspace, and reuses the
+// lifecycle that was already built there, without making any network
+// requests. Deployments fail with a list of the buildpacks that are missing
+// from disk.
+func WithOffline() PlatformOption {
+	return func(c *Config) {
+		c.Offline = true
+	}
+}
+
+// NewPlatformWithConfig creates a Platform from the given Config.
+func NewPlatformWithConfig(config Config) (Platform, error) {
+	switch config.Type {
+	case CloudFoundry:
+		cfHome := config.CFHome
+		if cfHome == "" {
+			var err error
+			cfHome, err = homeDir(".cf")
+			if err != nil {
+				return Platform{}, err
+			}
+		}
+
+		tmpDir := config.CFTempDir
+		if tmpDir == "" {
+			tmpDir = os.TempDir()
+		}
+
+		cli := pexec.NewExecutable("cf")
+
+		initialize := cloudfoundry.NewInitialize(cli, config.Stack)
+		deinitialize := cloudfoundry.NewDeinitialize()
+		setup := cloudfoundry.NewSetup(cli, cfHome, config.Stack)
+		stage := cloudfoundry.NewStage(cli)
+		teardown := cloudfoundry.NewTeardown(cli)
+		check := cloudfoundry.NewCheck(cli, cfHome)
+		collect := cloudfoundry.NewCollect(cli)
+
+		return NewCloudFoundryWithPhases(CloudFoundryPhases{
+			Initialize:   initialize,
+			Deinitialize: deinitialize,
+			Setup:        setup,
+			Stage:        stage,
+			Teardown:     teardown,
+			Check:        check,
+			Collect:      collect,
+		}, tmpDir, cli), nil
+	case Docker:
+		if config.BuildpackCatalogFile != "" && config.BuildpackCatalog != nil {
+			return Platform{}, errors.New("only one of BuildpackCatalog and BuildpackCatalogFile may be set")
+		}
+
+		workspace, err := config.workspace()
+		if err != nil {
+			return Platform{}, err
+		}
+
+		dockerClient := config.DockerClient
+		if dockerClient == nil {
+			opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+			if config.DockerHost != "" {
+				opts = append(opts, client.WithHost(config.DockerHost))
+			}
+
+			var err error
+			dockerClient, err = client.NewClientWithOpts(opts...)
+			if err != nil {
+				return Platform{}, err
+			}
+		}
+
+		// The daemon host is taken from the client so that it reflects
+		// DockerHost, a given DockerClient, or DOCKER_HOST alike.
+		daemonHost := dockerClient.DaemonHost()
+
+		githubAPI := config.GitHubAPI
+		if githubAPI == "" {
+			githubAPI = DefaultGitHubAPI
+		}
+
+		httpClient := config.HTTPClient
+		if httpClient == nil {
+			httpClient = http.DefaultClient
+		}
+
+		lifecycleSource := config.LifecycleSource
+		if lifecycleSource == "" {
+			lifecycleSource = docker.BuildpackAppLifecycleRepoURL
+		}
+
+		registryTTL := config.RegistryTTL
+		if registryTTL == 0 {
+			registryTTL = DefaultRegistryTTL
+		}
+
+		// Without an explicit architecture, the phases ask the daemon for its
+		// architecture the first time they run, so that creating the platform
+		// does not require a reachable daemon.
+		arch := docker.NormalizeArchitecture(config.Architecture)
+		archResolver := docker.NewArchitectureResolver(dockerClient)
+
+		archiver := docker.NewTGZArchiver()
+
+		// Prefer the host Go toolchain, but fall back to compiling the
+		// lifecycle in a container so that Docker is the only prerequisite.
+		check := docker.NewCheck(dockerClient, config.Stack, githubAPI, config.Token).WithHTTPClient(httpClient)
+		if config.Offline {
+			check = check.WithOffline()
+		}
+
+		var compiler docker.LifecycleCompiler = docker.NewContainerCompiler(dockerClient, archiver, docker.DefaultGolangImage)
+		if _, err := exec.LookPath("go"); err == nil {
+			compiler = docker.NewGoCompiler(pexec.NewExecutable("go"))
+		} else {
+			check = check.WithCompilerImage(docker.DefaultGolangImage)
+		}
+
+		lifecycleManager := docker.NewLifecycleManager(compiler, archiver).WithHTTPClient(httpClient)
+		if config.Offline {
+			lifecycleManager = lifecycleManager.WithOffline()
+		}
+		buildpacksCache := docker.NewBuildpacksCache(filepath.Join(workspace, "buildpacks-cache")).WithHTTPClient(httpClient)
+		buildpacksRegistry := docker.NewBuildpacksRegistry(githubAPI, config.Token).
+			WithHTTPClient(httpClient).
+			WithPersistentIndex(filepath.Join(workspace, "buildpacks-index.json"), registryTTL)
+		if config.Offline {
+			buildpacksRegistry = buildpacksRegistry.WithOffline(filepath.Join(workspace, "buildpacks-cache"))
+		}
+
+		if config.BuildpackCatalogFile != "" {
+			catalog, err := docker.LoadCatalog(config.BuildpackCatalogFile)
+			if err != nil {
+				return Platform{}, err
+			}
+
+			buildpacksRegistry = buildpacksRegistry.WithCatalog(catalog)
+		}
+
+		if config.BuildpackCatalog != nil {
+			var catalog []docker.CatalogEntry
+			for _, entry := range config.BuildpackCatalog {
+				catalog = append(catalog, docker.CatalogEntry{
+					Name:       entry.Name,
+					Repository: entry.Repository,
+					Tag:        entry.Tag,
+				})
+			}
+
+			buildpacksRegistry = buildpacksRegistry.WithCatalog(catalog)
+		}
+
+		check = check.WithRegistry(buildpacksRegistry)
+
+		buildpacksManager := docker.NewBuildpacksManager(archiver, buildpacksCache, buildpacksRegistry)
+		networkManager := docker.NewNetworkManager(dockerClient)
+
+		initialize := docker.NewInitialize(buildpacksRegistry, networkManager)
+		deinitialize := docker.NewDeinitialize(networkManager)
+		setup := docker.NewSetup(dockerClient, lifecycleManager, buildpacksManager, archiver, networkManager, workspace, config.Stack).WithLifecycleSource(lifecycleSource).WithArchitecture(arch).WithArchitectureResolver(archResolver)
+		stage := docker.NewStage(dockerClient, archiver, workspace)
+		start := docker.NewStart(dockerClient, networkManager, workspace, daemonHost, config.Stack).WithArchitecture(arch).WithArchitectureResolver(archResolver)
+		teardown := docker.NewTeardown(dockerClient, workspace)
+		collect := docker.NewCollect(dockerClient, workspace)
+		detect := docker.NewDetect(dockerClient, buildpacksManager, archiver, workspace, config.Stack).WithArchitecture(arch).WithArchitectureResolver(archResolver)
+		export := docker.NewExport(dockerClient, workspace).WithArchitecture(arch).WithArchitectureResolver(archResolver)
+		restart := docker.NewRestart(dockerClient, networkManager, workspace, daemonHost).WithArchitecture(arch).WithArchitectureResolver(archResolver)
+		info := docker.NewInfo(lifecycleManager, lifecycleSource, workspace, arch).WithArchitectureResolver(archResolver)
+
+		return NewDockerWithPhases(DockerPhases{
+			Initialize:   initialize,
+			Deinitialize: deinitialize,
+			Setup:        setup,
+			Stage:        stage,
+			Start:        start,
+			Teardown:     teardown,
+			Info:         info,
+			Check:        check,
+			Collect:      collect,
+			Detect:       detect,
+			Export:       export,
+			Restart:      restart,
+		}, dockerClient), nil
+	}
+
+	return Platform{}, fmt.Errorf("unknown platform type: %q", config.Type)
+}
+
+// workspace returns the Workspace of the Docker platform, or its default.
+func (c Config) workspace() (string, error) {
+	if c.Workspace != "" {
+		return c.Workspace, nil
+	}
+
+	return homeDir(".switchblade")
+}
+
+func homeDir(dir string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine default %s directory: %w", dir, err)
+	}
+
+	return filepath.Join(home, dir), nil
+}
@@ -0,0 +1,133 @@
+package switchblade_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudfoundry/switchblade"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testConfig(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("NewPlatformWithConfig", func() {
+		var workspace string
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("creates a Docker platform that uses the configured workspace", func() {
+			Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle", "arm64"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "arm64", "revision"), []byte("some-revision"), 0600)).To(Succeed())
+
+			platform, err := switchblade.NewPlatformWithConfig(switchblade.Config{
+				Type:            switchblade.Docker,
+				Stack:           "some-stack",
+				Workspace:       workspace,
+				DockerHost:      "tcp://127.0.0.1:1",
+				LifecycleSource: "/some/lifecycle",
+				Architecture:    "arm64",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := platform.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(switchblade.PlatformInfo{
+				Type:         switchblade.Docker,
+				Architecture: "arm64",
+				Lifecycle: switchblade.LifecycleInfo{
+					Source:   "/some/lifecycle",
+					Revision: "some-revision",
+				},
+			}))
+		})
+
+		it("creates a Cloud Foundry platform that uses the configured directories", func() {
+			platform, err := switchblade.NewPlatformWithConfig(switchblade.Config{
+				Type:      switchblade.CloudFoundry,
+				Stack:     "some-stack",
+				CFHome:    filepath.Join(workspace, ".cf"),
+				CFTempDir: workspace,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := platform.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Type).To(Equal(switchblade.CloudFoundry))
+		})
+
+		context("failure cases", func() {
+			context("when the platform type is unknown", func() {
+				it("returns an error", func() {
+					_, err := switchblade.NewPlatformWithConfig(switchblade.Config{Type: "some-type"})
+					Expect(err).To(MatchError(`unknown platform type: "some-type"`))
+				})
+			})
+
+			context("when the buildpack catalog file cannot be loaded", func() {
+				it("returns an error", func() {
+					_, err := switchblade.NewPlatformWithConfig(switchblade.Config{
+						Type:                 switchblade.Docker,
+						Workspace:            workspace,
+						DockerHost:           "tcp://127.0.0.1:1",
+						Architecture:         "amd64",
+						BuildpackCatalogFile: filepath.Join(workspace, "missing.json"),
+					})
+					Expect(err).To(MatchError(ContainSubstring("failed to open buildpack catalog:")))
+				})
+			})
+
+			context("when both a buildpack catalog and catalog file are given", func() {
+				it("returns an error", func() {
+					_, err := switchblade.NewPlatformWithConfig(switchblade.Config{
+						Type:                 switchblade.Docker,
+						Workspace:            workspace,
+						DockerHost:           "tcp://127.0.0.1:1",
+						Architecture:         "amd64",
+						BuildpackCatalog:     []switchblade.CatalogEntry{{Name: "go"}},
+						BuildpackCatalogFile: filepath.Join(workspace, "catalog.json"),
+					})
+					Expect(err).To(MatchError("only one of BuildpackCatalog and BuildpackCatalogFile may be set"))
+				})
+			})
+		})
+	})
+
+	context("PruneCacheIn", func() {
+		var workspace string
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			old := time.Now().Add(-48 * time.Hour)
+			Expect(os.MkdirAll(filepath.Join(workspace, "droplets"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "droplets", "some-app.tar.gz"), nil, 0600)).To(Succeed())
+			Expect(os.Chtimes(filepath.Join(workspace, "droplets", "some-app.tar.gz"), old, old)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("prunes the given workspace", func() {
+			err := switchblade.PruneCacheIn(workspace, 24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(workspace, "droplets", "some-app.tar.gz")).NotTo(BeAnExistingFile())
+		})
+	})
+}
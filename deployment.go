@@ -6,6 +6,7 @@ import (
 	"io"
 
 	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
+	"github.com/cloudfoundry/switchblade/internal/docker"
 	"github.com/docker/docker/api/types/container"
 )
 
@@ -19,11 +20,67 @@ type Deployment struct {
 	ExternalURL string
 	InternalURL string
 
+	// Timings are the time taken by each phase of the deployment, in the
+	// order they started. They are not populated by Platform.Attach.
+	Timings []PhaseTiming
+
+	// Droplet is the droplet that the application was staged into. It is only
+	// populated when the application was deployed WithoutStart.
+	Droplet Droplet
+
 	// Internal fields for log retrieval
 	platform  string
 	workspace string
 	cfCLI     cloudfoundry.Executable
 	dockerCLI LogsClient
+
+	// Internal fields for image export and restarts
+	dockerExport  docker.ExportPhase
+	dockerRestart docker.RestartPhase
+	droplet       string
+	image         string
+	withoutStart  bool
+
+	// secretEnv are the names of the env variables that hold secrets, which
+	// are left out of exported images.
+	secretEnv []string
+
+	// redactor removes the secret env values and service credentials that the
+	// application was deployed with from its logs and errors.
+	redactor redactor
+}
+
+// Droplet describes the droplet that an application was staged into.
+type Droplet struct {
+	// Path is the droplet tarball on the Docker platform. It is in the
+	// workspace and is removed when the application is deleted, so a droplet
+	// that must outlive the deployment is copied out with WithDropletArtifact.
+	// On Cloud Foundry, it is only set when the droplet was downloaded with
+	// WithDropletArtifact.
+	Path string
+
+	// GUID and Stack identify the droplet on the Cloud Foundry platform.
+	GUID  string
+	Stack string
+
+	Buildpacks   []DropletBuildpack
+	ProcessTypes map[string]string
+
+	// Metadata is the raw metadata of the droplet, which is the result.json
+	// written by the builder on Docker, and the droplet resource of the v3
+	// API on Cloud Foundry.
+	Metadata []byte
+}
+
+// DropletBuildpack is a buildpack that staged a droplet. The Name is the name
+// of the buildpack that was given to stage the application, such as
+// "go_buildpack". The DetectOutput is what its bin/detect printed, which is
+// only recorded on Cloud Foundry when the buildpack was detected rather than
+// given.
+type DropletBuildpack struct {
+	Name         string
+	Version      string
+	DetectOutput string
 }
 
 // RuntimeLogs retrieves recent logs from the running application.
@@ -39,15 +96,198 @@ type Deployment struct {
 //
 // For build-time logs (staging, buildpack detection), use the logs
 // returned from platform.Deploy.Execute() instead.
+//
+// The secret env values and service credentials that the application was
+// deployed with are redacted from the logs.
 func (d Deployment) RuntimeLogs() (string, error) {
+	var (
+		logs string
+		err  error
+	)
 	switch d.platform {
 	case CloudFoundry:
-		return d.logsCloudFoundry()
+		logs, err = d.logsCloudFoundry()
 	case Docker:
-		return d.logsDocker()
+		logs, err = d.logsDocker()
 	default:
 		return "", fmt.Errorf("unknown platform type: %q", d.platform)
 	}
+	if err != nil {
+		return "", d.redactor.error(err)
+	}
+
+	return d.redactor.redact(logs), nil
+}
+
+// ExportImage exports the application as a local image with the given tag.
+// The image is built from the stack image, the lifecycle launcher and the
+// droplet of the application, with the same env and start command that it
+// was started with, so that it can be reproduced outside of the test with:
+//
+//	docker run --rm -p 8080:8080 <tag>
+//
+// The env variables given with WithSecretEnv, and VCAP_SERVICES when services
+// were given or bound, are left out of the image so that it does not hold
+// secrets, and have to be given to `docker run` with -e flags instead:
+//
+//	docker run --rm -p 8080:8080 -e API_TOKEN -e VCAP_SERVICES <tag>
+//
+// A Deployment returned by Platform.Attach does not know which of its env
+// variables are secret, so they are all included.
+//
+// It is only supported on the Docker platform, for applications that were
+// started from a droplet.
+func (d Deployment) ExportImage(tag string) error {
+	if d.platform != Docker {
+		return fmt.Errorf("exporting an image is not supported on the %q platform", d.platform)
+	}
+
+	if d.withoutStart {
+		return fmt.Errorf("failed to export image: %s was deployed without starting it, so it has no container to export; deploy it again WithDroplet(%q) first", d.Name, d.Droplet.Path)
+	}
+
+	if d.image != "" {
+		return fmt.Errorf("failed to export image: %s was deployed from the image %s rather than a droplet, so that image can be run instead", d.Name, d.image)
+	}
+
+	if d.dockerExport == nil {
+		return fmt.Errorf("failed to export image: %w", errMissingPhase("export"))
+	}
+
+	err := d.dockerExport.Run(context.Background(), d.Name, d.droplet, tag, d.secretEnv...)
+	if err != nil {
+		return d.redactor.error(fmt.Errorf("failed to export image: %w", err))
+	}
+
+	return nil
+}
+
+// SetEnv sets the given environment variables of the application and
+// restarts it, so that they are read at launch time, such as by the
+// .profile.d scripts of its buildpacks, without deploying it again.
+//
+// On Cloud Foundry, this is `cf set-env` followed by `cf restart`. On Docker,
+// the container of the application is recreated from the same droplet with
+// the new environment, which gives it new URLs, so the returned Deployment
+// should be used from then on.
+func (d Deployment) SetEnv(env map[string]string) (Deployment, error) {
+	return d.restart(env, nil)
+}
+
+// UnsetEnv removes the given environment variables of the application and
+// restarts it, in the same way as SetEnv.
+func (d Deployment) UnsetEnv(keys ...string) (Deployment, error) {
+	return d.restart(nil, keys)
+}
+
+// Restart restarts the application without changing its environment, in the
+// same way as SetEnv.
+func (d Deployment) Restart() (Deployment, error) {
+	return d.restart(nil, nil)
+}
+
+func (d Deployment) restart(env map[string]string, unset []string) (Deployment, error) {
+	switch d.platform {
+	case CloudFoundry:
+		err := cloudfoundry.Restart(d.cfCLI, d.workspace, d.Name, env, unset)
+		if err != nil {
+			return Deployment{}, d.redactor.error(fmt.Errorf("failed to restart application: %w", err))
+		}
+
+	case Docker:
+		if d.dockerRestart == nil {
+			return Deployment{}, fmt.Errorf("failed to restart application: %w", errMissingPhase("restart"))
+		}
+
+		externalURL, internalURL, err := d.dockerRestart.Run(context.Background(), d.Name, d.droplet, env, unset)
+		if err != nil {
+			return Deployment{}, d.redactor.error(fmt.Errorf("failed to restart application: %w", err))
+		}
+
+		d.ExternalURL = externalURL
+		d.InternalURL = internalURL
+
+	default:
+		return Deployment{}, fmt.Errorf("unknown platform type: %q", d.platform)
+	}
+
+	return d, nil
+}
+
+// BindService binds a user-provided service with the given name and
+// credentials to the application, in the same way as the services given with
+// WithServices at deploy time, or replaces the credentials of the service when
+// it is already bound. This can be used to test that an application picks up
+// rotated credentials, or a service that is only added after it is running.
+//
+// On Cloud Foundry, the service is created or updated, bound and the
+// application is restaged, so that its buildpacks see the service during
+// staging too. An application that was deployed WithDroplet has no source to
+// restage, so it is only restarted. On Docker, the application is not staged
+// again: its container is recreated from the same droplet with the service in
+// its VCAP_SERVICES, so only the running application sees the service, and it
+// gets new URLs, so the returned Deployment should be used from then on. In
+// all cases, the service is removed when the application is torn down.
+func (d Deployment) BindService(name string, service Service) (Deployment, error) {
+	d.redactor = d.redactor.with(nil, map[string]Service{name: service})
+	d.secretEnv = withServicesEnv(d.secretEnv)
+
+	switch d.platform {
+	case CloudFoundry:
+		err := cloudfoundry.BindService(d.cfCLI, d.workspace, d.Name, name, service, d.droplet == "")
+		if err != nil {
+			return Deployment{}, d.redactor.error(fmt.Errorf("failed to bind service: %w", err))
+		}
+
+	case Docker:
+		if d.dockerRestart == nil {
+			return Deployment{}, fmt.Errorf("failed to bind service: %w", errMissingPhase("restart"))
+		}
+
+		externalURL, internalURL, err := d.dockerRestart.BindService(context.Background(), d.Name, d.droplet, name, service)
+		if err != nil {
+			return Deployment{}, d.redactor.error(fmt.Errorf("failed to bind service: %w", err))
+		}
+
+		d.ExternalURL = externalURL
+		d.InternalURL = internalURL
+
+	default:
+		return Deployment{}, fmt.Errorf("unknown platform type: %q", d.platform)
+	}
+
+	return d, nil
+}
+
+// UnbindService unbinds the user-provided service with the given name from
+// the application, in the same way as BindService. On Cloud Foundry, the
+// service is also deleted.
+func (d Deployment) UnbindService(name string) (Deployment, error) {
+	switch d.platform {
+	case CloudFoundry:
+		err := cloudfoundry.UnbindService(d.cfCLI, d.workspace, d.Name, name, d.droplet == "")
+		if err != nil {
+			return Deployment{}, d.redactor.error(fmt.Errorf("failed to unbind service: %w", err))
+		}
+
+	case Docker:
+		if d.dockerRestart == nil {
+			return Deployment{}, fmt.Errorf("failed to unbind service: %w", errMissingPhase("restart"))
+		}
+
+		externalURL, internalURL, err := d.dockerRestart.UnbindService(context.Background(), d.Name, d.droplet, name)
+		if err != nil {
+			return Deployment{}, d.redactor.error(fmt.Errorf("failed to unbind service: %w", err))
+		}
+
+		d.ExternalURL = externalURL
+		d.InternalURL = internalURL
+
+	default:
+		return Deployment{}, fmt.Errorf("unknown platform type: %q", d.platform)
+	}
+
+	return d, nil
 }
 
 func (d Deployment) logsCloudFoundry() (string, error) {
@@ -75,3 +315,15 @@ func (d Deployment) logsDocker() (string, error) {
 
 	return string(logs), nil
 }
+
+// withServicesEnv returns the names of the env variables that hold secrets
+// with VCAP_SERVICES added, since it holds the credentials of the services.
+func withServicesEnv(secretEnv []string) []string {
+	for _, name := range secretEnv {
+		if name == "VCAP_SERVICES" {
+			return secretEnv
+		}
+	}
+
+	return append(append([]string{}, secretEnv...), "VCAP_SERVICES")
+}
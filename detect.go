@@ -0,0 +1,15 @@
+package switchblade
+
+// DetectResult describes whether a buildpack detected an application, along
+// with what its bin/detect script printed.
+type DetectResult struct {
+	Buildpack string
+	Detected  bool
+	Output    string
+
+	// Unknown is set when the bin/detect script of the buildpack may not have
+	// been run, so it is not known whether it would detect the application.
+	// Detected is then false. This only happens on Cloud Foundry, where
+	// staging stops at the first buildpack that detects.
+	Unknown bool
+}
@@ -3,7 +3,12 @@ package switchblade
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/cloudfoundry/switchblade/internal/docker"
 )
@@ -14,13 +19,66 @@ import (
 //go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface StagePhase --name DockerStagePhase --output fakes/docker_stage_phase.go
 //go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface StartPhase --name DockerStartPhase --output fakes/docker_start_phase.go
 //go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface TeardownPhase --name DockerTeardownPhase --output fakes/docker_teardown_phase.go
+//go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface InfoPhase --name DockerInfoPhase --output fakes/docker_info_phase.go
+//go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface CheckPhase --name DockerCheckPhase --output fakes/docker_check_phase.go
+//go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface CollectPhase --name DockerCollectPhase --output fakes/docker_collect_phase.go
+//go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface DetectPhase --name DockerDetectPhase --output fakes/docker_detect_phase.go
+//go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface ExportPhase --name DockerExportPhase --output fakes/docker_export_phase.go
+//go:generate faux --package github.com/cloudfoundry/switchblade/internal/docker --interface RestartPhase --name DockerRestartPhase --output fakes/docker_restart_phase.go
 
+// DockerPhases are the phases that a Docker platform created with
+// NewDockerWithPhases is made of. Initialize, Deinitialize, Setup, Stage,
+// Start and Teardown are required. The other phases are optional, and the
+// features that rely on them return an error when they are nil.
+type DockerPhases struct {
+	Initialize   docker.InitializePhase
+	Deinitialize docker.DeinitializePhase
+	Setup        docker.SetupPhase
+	Stage        docker.StagePhase
+	Start        docker.StartPhase
+	Teardown     docker.TeardownPhase
+
+	Info    docker.InfoPhase
+	Check   docker.CheckPhase
+	Collect docker.CollectPhase
+	Detect  docker.DetectPhase
+	Export  docker.ExportPhase
+	Restart docker.RestartPhase
+}
+
+// NewDocker creates a Docker platform from its required phases. Use
+// NewDockerWithPhases to also give the optional phases.
 func NewDocker(initialize docker.InitializePhase, deinitialize docker.DeinitializePhase, setup docker.SetupPhase, stage docker.StagePhase, start docker.StartPhase, teardown docker.TeardownPhase, client LogsClient) Platform {
+	return NewDockerWithPhases(DockerPhases{
+		Initialize:   initialize,
+		Deinitialize: deinitialize,
+		Setup:        setup,
+		Stage:        stage,
+		Start:        start,
+		Teardown:     teardown,
+	}, client)
+}
+
+// NewDockerWithPhases creates a Docker platform from the given phases.
+func NewDockerWithPhases(phases DockerPhases, client LogsClient) Platform {
 	return Platform{
-		initialize:   dockerInitializeProcess{initialize: initialize},
-		deinitialize: dockerDeinitializeProcess{deinitialize: deinitialize},
-		Deploy:       dockerDeployProcess{setup: setup, stage: stage, start: start, client: client},
-		Delete:       dockerDeleteProcess{teardown: teardown},
+		initialize:   dockerInitializeProcess{initialize: phases.Initialize},
+		deinitialize: dockerDeinitializeProcess{deinitialize: phases.Deinitialize},
+		info:         dockerInfoProcess{info: phases.Info},
+		attach:       dockerAttachProcess{export: phases.Export, restart: phases.Restart, client: client},
+		check:        dockerCheckProcess{check: phases.Check},
+		detect:       dockerDetectProcess{detect: phases.Detect},
+		Deploy: dockerDeployProcess{
+			setup:    phases.Setup,
+			stage:    phases.Stage,
+			start:    phases.Start,
+			collect:  phases.Collect,
+			export:   phases.Export,
+			restart:  phases.Restart,
+			client:   client,
+			logLines: DefaultErrorLogLines,
+		},
+		Delete: dockerDeleteProcess{teardown: phases.Teardown},
 	}
 }
 
@@ -32,8 +90,9 @@ func (p dockerInitializeProcess) Execute(buildpacks ...Buildpack) error {
 	var bps []docker.Buildpack
 	for _, buildpack := range buildpacks {
 		bps = append(bps, docker.Buildpack{
-			Name: buildpack.Name,
-			URI:  buildpack.URI,
+			Name:   buildpack.Name,
+			URI:    buildpack.URI,
+			SHA256: buildpack.SHA256,
 		})
 	}
 
@@ -48,15 +107,85 @@ func (p dockerDeinitializeProcess) Execute() error {
 	return p.deinitialize.Run()
 }
 
+type dockerInfoProcess struct {
+	info docker.InfoPhase
+}
+
+func (p dockerInfoProcess) Execute() (PlatformInfo, error) {
+	if p.info == nil {
+		return PlatformInfo{Type: Docker}, nil
+	}
+
+	info, err := p.info.Run()
+	if err != nil {
+		return PlatformInfo{}, err
+	}
+
+	return PlatformInfo{
+		Type:         Docker,
+		Architecture: info.Architecture,
+		Lifecycle: LifecycleInfo{
+			Source:   info.LifecycleSource,
+			Revision: info.LifecycleRevision,
+		},
+	}, nil
+}
+
+type dockerDetectProcess struct {
+	detect docker.DetectPhase
+}
+
+func (p dockerDetectProcess) Execute(path string, buildpacks ...string) ([]DetectResult, error) {
+	if p.detect == nil {
+		return nil, fmt.Errorf("failed to run detect phase: %w", errMissingPhase("detect"))
+	}
+
+	name, err := RandomName()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := p.detect.Run(context.Background(), name, path, buildpacks...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run detect phase: %w", err)
+	}
+
+	var detectResults []DetectResult
+	for _, result := range results {
+		detectResults = append(detectResults, DetectResult{
+			Buildpack: result.Buildpack,
+			Detected:  result.Detected,
+			Output:    result.Output,
+		})
+	}
+
+	return detectResults, nil
+}
+
 type dockerDeployProcess struct {
-	setup  docker.SetupPhase
-	stage  docker.StagePhase
-	start  docker.StartPhase
-	client LogsClient
+	setup           docker.SetupPhase
+	stage           docker.StagePhase
+	start           docker.StartPhase
+	collect         docker.CollectPhase
+	export          docker.ExportPhase
+	restart         docker.RestartPhase
+	client          LogsClient
+	buildpacks      []string
+	logLines        int
+	env             map[string]string
+	secretEnv       map[string]string
+	services        map[string]Service
+	artifactsDir    string
+	dropletArtifact bool
+	observer        Observer
+	withoutStart    bool
+	droplet         string
+	image           string
 }
 
 func (p dockerDeployProcess) WithBuildpacks(buildpacks ...string) DeployProcess {
 	p.setup = p.setup.WithBuildpacks(buildpacks...)
+	p.buildpacks = buildpacks
 	return p
 }
 
@@ -67,8 +196,16 @@ func (p dockerDeployProcess) WithStack(stack string) DeployProcess {
 }
 
 func (p dockerDeployProcess) WithEnv(env map[string]string) DeployProcess {
-	p.setup = p.setup.WithEnv(env)
-	p.start = p.start.WithEnv(env)
+	p.env = env
+	p.setup = p.setup.WithEnv(mergeEnv(p.env, p.secretEnv))
+	p.start = p.start.WithEnv(mergeEnv(p.env, p.secretEnv))
+	return p
+}
+
+func (p dockerDeployProcess) WithSecretEnv(env map[string]string) DeployProcess {
+	p.secretEnv = env
+	p.setup = p.setup.WithEnv(mergeEnv(p.env, p.secretEnv))
+	p.start = p.start.WithEnv(mergeEnv(p.env, p.secretEnv))
 	return p
 }
 
@@ -83,6 +220,7 @@ func (p dockerDeployProcess) WithServices(services map[string]Service) DeployPro
 		s[name] = service
 	}
 
+	p.services = services
 	p.setup = p.setup.WithServices(s)
 	p.start = p.start.WithServices(s)
 	return p
@@ -98,42 +236,296 @@ func (p dockerDeployProcess) WithHealthCheckType(healthCheckType string) DeployP
 	return p
 }
 
+func (p dockerDeployProcess) WithErrorLogLines(lines int) DeployProcess {
+	p.logLines = lines
+	return p
+}
+
+func (p dockerDeployProcess) WithDroplet(path string) DeployProcess {
+	p.setup = p.setup.WithoutStaging()
+	p.start = p.start.WithDroplet(path)
+	p.droplet = path
+	return p
+}
+
+func (p dockerDeployProcess) WithoutStart() DeployProcess {
+	p.withoutStart = true
+	return p
+}
+
+func (p dockerDeployProcess) WithObserver(observer Observer) DeployProcess {
+	p.observer = observer
+	return p
+}
+
+func (p dockerDeployProcess) WithArtifactsDir(dir string) DeployProcess {
+	p.artifactsDir = dir
+	return p
+}
+
+func (p dockerDeployProcess) WithDropletArtifact() DeployProcess {
+	p.dropletArtifact = true
+	return p
+}
+
 func (p dockerDeployProcess) Execute(name, path string) (Deployment, fmt.Stringer, error) {
+	if p.withoutStart && p.droplet != "" {
+		return Deployment{}, bytes.NewBuffer(nil), fmt.Errorf("failed to deploy droplet: a droplet cannot be deployed without starting it")
+	}
+
+	if p.withoutStart && p.collect == nil {
+		return Deployment{}, bytes.NewBuffer(nil), fmt.Errorf("failed to deploy without starting: %w", errMissingPhase("collect"))
+	}
+
 	ctx := context.Background()
-	logs := bytes.NewBuffer(nil)
+	redactor := newRedactor(p.secretEnv, p.services)
+
+	timer := newPhaseTimer(Docker, name, p.observer)
+
+	deployment, logs, err := p.deploy(ctx, name, path, redactor, timer)
 
-	containerID, err := p.setup.Run(ctx, logs, name, path)
+	if p.artifactsDir != "" {
+		artifactsErr := p.writeArtifacts(ctx, name, logs.String(), redactor)
+		if artifactsErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to write artifacts: %w", artifactsErr))
+		}
+	}
+
+	return deployment, logs, err
+}
+
+func (p dockerDeployProcess) ExecuteImage(name, image string) (Deployment, fmt.Stringer, error) {
+	if p.withoutStart {
+		return Deployment{}, bytes.NewBuffer(nil), fmt.Errorf("failed to deploy image: an image cannot be deployed without starting it")
+	}
+
+	p.setup = p.setup.WithImage(image)
+	p.start = p.start.WithImage(image)
+	p.image = image
+
+	return p.Execute(name, "")
+}
+
+// writeArtifacts writes the artifacts of the deployment to its directory
+// within the artifacts directory.
+func (p dockerDeployProcess) writeArtifacts(ctx context.Context, name, logs string, redactor redactor) error {
+	if p.collect == nil {
+		return errMissingPhase("collect")
+	}
+
+	dir := filepath.Join(p.artifactsDir, name)
+
+	var droplet string
+	if p.dropletArtifact {
+		err := os.MkdirAll(dir, os.ModePerm)
+		if err != nil {
+			return fmt.Errorf("failed to create artifacts directory: %w", err)
+		}
+
+		droplet = filepath.Join(dir, DropletArtifactFile)
+	}
+
+	artifacts, err := p.collect.Run(ctx, name, droplet)
+	problems := []error{err}
+
+	runtimeLogs, err := Deployment{Name: name, platform: Docker, dockerCLI: p.client}.RuntimeLogs()
 	if err != nil {
-		return Deployment{}, logs, fmt.Errorf("failed to run setup phase: %w\n\nOutput:\n%s", err, logs)
+		problems = append(problems, err)
+	}
+
+	var env string
+	if len(artifacts.Env) > 0 {
+		env = strings.Join(artifacts.Env, "\n") + "\n"
 	}
 
-	command, err := p.stage.Run(ctx, logs, containerID, name)
+	return writeArtifacts(dir, map[string]string{
+		StagingLogFile:   logs,
+		RuntimeLogFile:   runtimeLogs,
+		EnvFile:          env,
+		"container.json": string(artifacts.Container),
+		"result.json":    string(artifacts.Result),
+	}, errors.Join(problems...), redactor)
+}
+
+func (p dockerDeployProcess) deploy(ctx context.Context, name, path string, redactor redactor, timer *phaseTimer) (Deployment, *bytes.Buffer, error) {
+	logs := bytes.NewBuffer(nil)
+
+	timer.Started(PhaseSetup)
+	containerID, err := p.setup.WithObserver(timer).Run(ctx, logs, name, path)
+	timer.Finished(PhaseSetup, err)
 	if err != nil {
-		return Deployment{}, logs, fmt.Errorf("failed to run stage phase: %w\n\nOutput:\n%s", err, logs)
+		logs = redactor.logs(logs)
+		return Deployment{}, logs, SetupError{
+			Platform: Docker,
+			Logs:     logs.String(),
+			Err:      withLogs(redactor.error(fmt.Errorf("failed to run setup phase: %w", err)), name, logs.String(), p.logLines),
+		}
+	}
+
+	// A droplet that was staged before, or an image, is started without
+	// staging the app.
+	var command string
+	if p.droplet == "" && p.image == "" {
+		timer.Started(PhaseStage)
+		command, err = p.stage.Run(ctx, logs, containerID, name)
+		timer.Finished(PhaseStage, err)
+		if err != nil {
+			logs = redactor.logs(logs)
+			wrapped := withLogs(redactor.error(fmt.Errorf("failed to run stage phase: %w", err)), name, logs.String(), p.logLines)
+
+			var stagingErr docker.StagingError
+			if errors.As(err, &stagingErr) {
+				return Deployment{}, logs, StagingError{
+					Platform:  Docker,
+					ExitCode:  stagingErr.ExitCode,
+					Buildpack: failedBuildpack(p.buildpacks, logs.String()),
+					Logs:      logs.String(),
+					Err:       wrapped,
+				}
+			}
+
+			return Deployment{}, logs, PlatformError{
+				Platform: Docker,
+				Phase:    "stage",
+				Logs:     logs.String(),
+				Err:      wrapped,
+			}
+		}
+	}
+
+	if p.withoutStart {
+		droplet, err := p.collect.Droplet(name)
+		if err != nil {
+			logs = redactor.logs(logs)
+			return Deployment{}, logs, PlatformError{
+				Platform: Docker,
+				Phase:    "stage",
+				Logs:     logs.String(),
+				Err:      redactor.error(fmt.Errorf("failed to read droplet: %w", err)),
+			}
+		}
+
+		var buildpacks []DropletBuildpack
+		for _, buildpack := range droplet.Buildpacks {
+			buildpacks = append(buildpacks, DropletBuildpack{
+				Name:    buildpack.Name,
+				Version: buildpack.Version,
+			})
+		}
+
+		return Deployment{
+			Name:    name,
+			Timings: timer.Timings(),
+			Droplet: Droplet{
+				Path:         droplet.Path,
+				Buildpacks:   buildpacks,
+				ProcessTypes: droplet.ProcessTypes,
+				Metadata:     droplet.Metadata,
+			},
+			platform:      Docker,
+			dockerCLI:     p.client,
+			dockerExport:  p.export,
+			dockerRestart: p.restart,
+			withoutStart:  true,
+			redactor:      redactor,
+		}, redactor.logs(logs), nil
 	}
 
+	timer.Started(PhaseStart)
 	externalURL, internalURL, err := p.start.Run(ctx, logs, name, command)
+	timer.Finished(PhaseStart, err)
+	if err != nil {
+		logs = redactor.logs(logs)
+		return Deployment{}, logs, StartError{
+			Platform: Docker,
+			Logs:     logs.String(),
+			Err:      withLogs(redactor.error(fmt.Errorf("failed to run start phase: %w", err)), name, logs.String(), p.logLines),
+		}
+	}
+
+	return Deployment{
+		Name:          name,
+		ExternalURL:   externalURL,
+		InternalURL:   internalURL,
+		Timings:       timer.Timings(),
+		platform:      Docker,
+		dockerCLI:     p.client,
+		dockerExport:  p.export,
+		dockerRestart: p.restart,
+		droplet:       p.droplet,
+		image:         p.image,
+		secretEnv:     p.secretEnvNames(),
+		redactor:      redactor,
+	}, redactor.logs(logs), nil
+}
+
+// secretEnvNames returns the names of the env variables of the deployment
+// that hold secrets.
+func (p dockerDeployProcess) secretEnvNames() []string {
+	var names []string
+	for key := range p.secretEnv {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	if len(p.services) > 0 {
+		names = withServicesEnv(names)
+	}
+
+	return names
+}
+
+type dockerCheckProcess struct {
+	check docker.CheckPhase
+}
+
+func (p dockerCheckProcess) Execute() error {
+	if p.check == nil {
+		return fmt.Errorf("docker platform is not ready:\n%w", errMissingPhase("check"))
+	}
+
+	err := p.check.Run()
 	if err != nil {
-		return Deployment{}, logs, fmt.Errorf("failed to run start phase: %w\n\nOutput:\n%s", err, logs)
+		return fmt.Errorf("docker platform is not ready:\n%w", err)
 	}
 
+	return nil
+}
+
+type dockerAttachProcess struct {
+	export  docker.ExportPhase
+	restart docker.RestartPhase
+	client  LogsClient
+}
+
+func (p dockerAttachProcess) Execute(name string) Deployment {
 	return Deployment{
-		Name:        name,
-		ExternalURL: externalURL,
-		InternalURL: internalURL,
-		platform:    Docker,
-		dockerCLI:   p.client,
-	}, logs, nil
+		Name:          name,
+		platform:      Docker,
+		dockerCLI:     p.client,
+		dockerExport:  p.export,
+		dockerRestart: p.restart,
+	}
 }
 
 type dockerDeleteProcess struct {
 	teardown docker.TeardownPhase
+	observer Observer
+}
+
+func (p dockerDeleteProcess) WithObserver(observer Observer) DeleteProcess {
+	p.observer = observer
+	return p
 }
 
 func (p dockerDeleteProcess) Execute(name string) error {
 	ctx := context.Background()
 
+	timer := newPhaseTimer(Docker, name, p.observer)
+
+	timer.Started(PhaseTeardown)
 	err := p.teardown.Run(ctx, name)
+	timer.Finished(PhaseTeardown, err)
 	if err != nil {
 		return fmt.Errorf("failed to run teardown phase: %w", err)
 	}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -16,6 +19,7 @@ import (
 
 	. "github.com/cloudfoundry/switchblade/matchers"
 	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
 )
 
 func testDocker(t *testing.T, context spec.G, it spec.S) {
@@ -30,6 +34,12 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 		stage        *fakes.DockerStagePhase
 		start        *fakes.DockerStartPhase
 		teardown     *fakes.DockerTeardownPhase
+		info         *fakes.DockerInfoPhase
+		check        *fakes.DockerCheckPhase
+		collect      *fakes.DockerCollectPhase
+		detect       *fakes.DockerDetectPhase
+		export       *fakes.DockerExportPhase
+		restart      *fakes.DockerRestartPhase
 		client       *fakes.LogsClient
 	)
 
@@ -40,9 +50,65 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 		stage = &fakes.DockerStagePhase{}
 		start = &fakes.DockerStartPhase{}
 		teardown = &fakes.DockerTeardownPhase{}
+		info = &fakes.DockerInfoPhase{}
+		check = &fakes.DockerCheckPhase{}
+		collect = &fakes.DockerCollectPhase{}
+		detect = &fakes.DockerDetectPhase{}
+		export = &fakes.DockerExportPhase{}
+		restart = &fakes.DockerRestartPhase{}
 		client = &fakes.LogsClient{}
 
-		platform = switchblade.NewDocker(initialize, deinitialize, setup, stage, start, teardown, client)
+		setup.WithObserverCall.Returns.SetupPhase = setup
+
+		platform = switchblade.NewDockerWithPhases(switchblade.DockerPhases{
+			Initialize:   initialize,
+			Deinitialize: deinitialize,
+			Setup:        setup,
+			Stage:        stage,
+			Start:        start,
+			Teardown:     teardown,
+			Info:         info,
+			Check:        check,
+			Collect:      collect,
+			Detect:       detect,
+			Export:       export,
+			Restart:      restart,
+		}, client)
+	})
+
+	context("NewDocker", func() {
+		it.Before(func() {
+			platform = switchblade.NewDocker(initialize, deinitialize, setup, stage, start, teardown, client)
+
+			stage.RunCall.Returns.Command = "some-command"
+			start.RunCall.Returns.ExternalURL = "some-external-url"
+		})
+
+		it("creates a platform that deploys with only the required phases", func() {
+			deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deployment.ExternalURL).To(Equal("some-external-url"))
+
+			info, err := platform.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info).To(Equal(switchblade.PlatformInfo{Type: switchblade.Docker}))
+		})
+
+		it("returns an error from the features that rely on an optional phase", func() {
+			Expect(platform.Check()).To(MatchError("docker platform is not ready:\nthe platform was created without the check phase"))
+
+			_, err := platform.Detect("/some/path/to/my/app")
+			Expect(err).To(MatchError("failed to run detect phase: the platform was created without the detect phase"))
+
+			_, _, err = platform.Deploy.WithoutStart().Execute("some-app", "/some/path/to/my/app")
+			Expect(err).To(MatchError("failed to deploy without starting: the platform was created without the collect phase"))
+
+			deployment := platform.Attach("some-app")
+			Expect(deployment.ExportImage("some-image:some-tag")).To(MatchError("failed to export image: the platform was created without the export phase"))
+
+			_, err = deployment.Restart()
+			Expect(err).To(MatchError("failed to restart application: the platform was created without the restart phase"))
+		})
 	})
 
 	context("Initialize", func() {
@@ -53,8 +119,9 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 					URI:  "some-buildpack-uri",
 				},
 				switchblade.Buildpack{
-					Name: "other-buildpack-name",
-					URI:  "other-buildpack-uri",
+					Name:   "other-buildpack-name",
+					URI:    "other-buildpack-uri",
+					SHA256: "other-buildpack-checksum",
 				},
 			)
 			Expect(err).NotTo(HaveOccurred())
@@ -64,8 +131,9 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 					URI:  "some-buildpack-uri",
 				},
 				{
-					Name: "other-buildpack-name",
-					URI:  "other-buildpack-uri",
+					Name:   "other-buildpack-name",
+					URI:    "other-buildpack-uri",
+					SHA256: "other-buildpack-checksum",
 				},
 			}))
 		})
@@ -116,6 +184,99 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("Info", func() {
+		it.Before(func() {
+			info.RunCall.Returns.PlatformInfo = docker.PlatformInfo{
+				Architecture:      "arm64",
+				LifecycleSource:   "some-lifecycle-source",
+				LifecycleRevision: "some-lifecycle-revision",
+			}
+		})
+
+		it("reports the lifecycle that was used", func() {
+			platformInfo, err := platform.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platformInfo).To(Equal(switchblade.PlatformInfo{
+				Type:         switchblade.Docker,
+				Architecture: "arm64",
+				Lifecycle: switchblade.LifecycleInfo{
+					Source:   "some-lifecycle-source",
+					Revision: "some-lifecycle-revision",
+				},
+			}))
+		})
+
+		context("failure cases", func() {
+			context("the info phase fails", func() {
+				it.Before(func() {
+					info.RunCall.Returns.Error = errors.New("failed to get info")
+				})
+
+				it("returns an error", func() {
+					_, err := platform.Info()
+					Expect(err).To(MatchError("failed to get info"))
+				})
+			})
+		})
+	})
+
+	context("Check", func() {
+		it("checks the platform", func() {
+			err := platform.Check()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(check.RunCall.CallCount).To(Equal(1))
+		})
+
+		context("failure cases", func() {
+			context("the check phase finds problems", func() {
+				it.Before(func() {
+					check.RunCall.Returns.Error = errors.New("the Docker daemon is not reachable")
+				})
+
+				it("returns an error", func() {
+					err := platform.Check()
+					Expect(err).To(MatchError("docker platform is not ready:\nthe Docker daemon is not reachable"))
+				})
+			})
+		})
+	})
+
+	context("Detect", func() {
+		it.Before(func() {
+			detect.RunCall.Returns.DetectResultSlice = []docker.DetectResult{
+				{Buildpack: "some-buildpack", Detected: true, Output: "some-output"},
+				{Buildpack: "other-buildpack", Detected: false},
+			}
+		})
+
+		it("runs the detect phase", func() {
+			results, err := platform.Detect("/some/path/to/my/app", "some-buildpack", "other-buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(Equal([]switchblade.DetectResult{
+				{Buildpack: "some-buildpack", Detected: true, Output: "some-output"},
+				{Buildpack: "other-buildpack", Detected: false},
+			}))
+
+			Expect(detect.RunCall.Receives.Ctx).To(Equal(gocontext.Background()))
+			Expect(detect.RunCall.Receives.Name).To(HavePrefix("switchblade-"))
+			Expect(detect.RunCall.Receives.Path).To(Equal("/some/path/to/my/app"))
+			Expect(detect.RunCall.Receives.Buildpacks).To(Equal([]string{"some-buildpack", "other-buildpack"}))
+		})
+
+		context("failure cases", func() {
+			context("when the detect phase errors", func() {
+				it.Before(func() {
+					detect.RunCall.Returns.Error = errors.New("could not pull image")
+				})
+
+				it("returns an error", func() {
+					_, err := platform.Detect("/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to run detect phase: could not pull image"))
+				})
+			})
+		})
+	})
+
 	context("Deploy", func() {
 		it.Before(func() {
 			setup.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, name, path string) (string, error) {
@@ -194,6 +355,208 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 			Expect(client.ContainerLogsCall.Receives.Options.ShowStderr).To(BeTrue())
 		})
 
+		it("redacts secrets from the runtime logs and the errors of the deployment", func() {
+			setup.WithEnvCall.Returns.SetupPhase = setup
+			start.WithEnvCall.Returns.StartPhase = start
+			client.ContainerLogsCall.Stub = func(ctx gocontext.Context, container string, options container.LogsOptions) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("Using token secret-value\n")), nil
+			}
+			restart.RunCall.Returns.Err = errors.New("container exited with secret-value")
+			restart.BindServiceCall.Returns.Err = errors.New("could not connect with service-password")
+
+			deployment, _, err := platform.Deploy.
+				WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value"}).
+				Execute("some-app", "/some/path/to/my/app")
+			Expect(err).NotTo(HaveOccurred())
+
+			runtimeLogs, err := deployment.RuntimeLogs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtimeLogs).To(Equal("Using token [REDACTED]\n"))
+
+			_, err = deployment.Restart()
+			Expect(err).To(MatchError("failed to restart application: container exited with [REDACTED]"))
+
+			_, err = deployment.BindService("some-service", switchblade.Service{"password": "service-password"})
+			Expect(err).To(MatchError("failed to bind service: could not connect with [REDACTED]"))
+			Expect(errors.Is(err, restart.BindServiceCall.Returns.Err)).To(BeTrue())
+		})
+
+		it("exports the deployed application as an image", func() {
+			deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = deployment.ExportImage("some-image:some-tag")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(export.RunCall.Receives.Ctx).To(Equal(gocontext.Background()))
+			Expect(export.RunCall.Receives.Name).To(Equal("some-app"))
+			Expect(export.RunCall.Receives.Droplet).To(BeEmpty())
+			Expect(export.RunCall.Receives.Tag).To(Equal("some-image:some-tag"))
+			Expect(export.RunCall.Receives.Omit).To(BeEmpty())
+		})
+
+		context("when the application has secrets", func() {
+			it.Before(func() {
+				setup.WithEnvCall.Returns.SetupPhase = setup
+				setup.WithServicesCall.Returns.SetupPhase = setup
+				start.WithEnvCall.Returns.StartPhase = start
+				start.WithServicesCall.Returns.StartPhase = start
+			})
+
+			it("leaves the secret env and the services out of the image", func() {
+				deployment, _, err := platform.Deploy.
+					WithEnv(map[string]string{"SOME_KEY": "some-value"}).
+					WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value", "OTHER_SECRET_KEY": "other-secret-value"}).
+					WithServices(map[string]switchblade.Service{"some-service": {"password": "some-password"}}).
+					Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = deployment.ExportImage("some-image:some-tag")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(export.RunCall.Receives.Omit).To(Equal([]string{"OTHER_SECRET_KEY", "SECRET_KEY", "VCAP_SERVICES"}))
+			})
+
+			it("leaves the services that were bound afterwards out of the image", func() {
+				deployment, _, err := platform.Deploy.
+					WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value"}).
+					Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				deployment, err = deployment.BindService("some-service", switchblade.Service{"password": "some-password"})
+				Expect(err).NotTo(HaveOccurred())
+
+				err = deployment.ExportImage("some-image:some-tag")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(export.RunCall.Receives.Omit).To(Equal([]string{"SECRET_KEY", "VCAP_SERVICES"}))
+			})
+		})
+
+		context("when the environment of the application is changed", func() {
+			it.Before(func() {
+				restart.RunCall.Returns.ExternalURL = "other-external-url"
+				restart.RunCall.Returns.InternalURL = "other-internal-url"
+			})
+
+			it("recreates the application with the new environment", func() {
+				deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				deployment, err = deployment.SetEnv(map[string]string{"SOME_KEY": "some-value"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deployment.Name).To(Equal("some-app"))
+				Expect(deployment.ExternalURL).To(Equal("other-external-url"))
+				Expect(deployment.InternalURL).To(Equal("other-internal-url"))
+
+				Expect(restart.RunCall.Receives.Ctx).To(Equal(gocontext.Background()))
+				Expect(restart.RunCall.Receives.Name).To(Equal("some-app"))
+				Expect(restart.RunCall.Receives.Droplet).To(BeEmpty())
+				Expect(restart.RunCall.Receives.Env).To(Equal(map[string]string{"SOME_KEY": "some-value"}))
+				Expect(restart.RunCall.Receives.Unset).To(BeEmpty())
+
+				_, err = deployment.UnsetEnv("SOME_KEY", "OTHER_KEY")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(restart.RunCall.Receives.Env).To(BeEmpty())
+				Expect(restart.RunCall.Receives.Unset).To(Equal([]string{"SOME_KEY", "OTHER_KEY"}))
+
+				_, err = deployment.Restart()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(restart.RunCall.CallCount).To(Equal(3))
+				Expect(restart.RunCall.Receives.Env).To(BeEmpty())
+				Expect(restart.RunCall.Receives.Unset).To(BeEmpty())
+			})
+
+			context("when the application cannot be restarted", func() {
+				it.Before(func() {
+					restart.RunCall.Returns.Err = errors.New("could not create container")
+				})
+
+				it("returns an error", func() {
+					deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = deployment.Restart()
+					Expect(err).To(MatchError("failed to restart application: could not create container"))
+				})
+			})
+		})
+
+		context("when a service is bound to the application", func() {
+			it.Before(func() {
+				restart.BindServiceCall.Returns.ExternalURL = "other-external-url"
+				restart.BindServiceCall.Returns.InternalURL = "other-internal-url"
+				restart.UnbindServiceCall.Returns.ExternalURL = "another-external-url"
+				restart.UnbindServiceCall.Returns.InternalURL = "another-internal-url"
+			})
+
+			it("recreates the application with the new services", func() {
+				deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				deployment, err = deployment.BindService("some-service", switchblade.Service{"some-key": "some-value"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deployment.ExternalURL).To(Equal("other-external-url"))
+				Expect(deployment.InternalURL).To(Equal("other-internal-url"))
+
+				Expect(restart.BindServiceCall.Receives.Ctx).To(Equal(gocontext.Background()))
+				Expect(restart.BindServiceCall.Receives.Name).To(Equal("some-app"))
+				Expect(restart.BindServiceCall.Receives.Droplet).To(BeEmpty())
+				Expect(restart.BindServiceCall.Receives.Service).To(Equal("some-service"))
+				Expect(restart.BindServiceCall.Receives.Credentials).To(Equal(map[string]interface{}{"some-key": "some-value"}))
+
+				deployment, err = deployment.UnbindService("some-service")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deployment.ExternalURL).To(Equal("another-external-url"))
+				Expect(deployment.InternalURL).To(Equal("another-internal-url"))
+
+				Expect(restart.UnbindServiceCall.Receives.Name).To(Equal("some-app"))
+				Expect(restart.UnbindServiceCall.Receives.Service).To(Equal("some-service"))
+			})
+
+			context("when the service cannot be bound", func() {
+				it.Before(func() {
+					restart.BindServiceCall.Returns.Err = errors.New("could not create container")
+				})
+
+				it("returns an error", func() {
+					deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = deployment.BindService("some-service", switchblade.Service{})
+					Expect(err).To(MatchError("failed to bind service: could not create container"))
+				})
+			})
+
+			context("when the service cannot be unbound", func() {
+				it.Before(func() {
+					restart.UnbindServiceCall.Returns.Err = errors.New("could not create container")
+				})
+
+				it("returns an error", func() {
+					deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = deployment.UnbindService("some-service")
+					Expect(err).To(MatchError("failed to unbind service: could not create container"))
+				})
+			})
+		})
+
+		context("when the image cannot be exported", func() {
+			it.Before(func() {
+				export.RunCall.Returns.Error = errors.New("could not commit image")
+			})
+
+			it("returns an error", func() {
+				deployment, _, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = deployment.ExportImage("some-image:some-tag")
+				Expect(err).To(MatchError("failed to export image: could not commit image"))
+			})
+		})
+
 		context("WithBuildpacks", func() {
 			it("uses those buildpacks", func() {
 				platform.Deploy.WithBuildpacks("some-buildpack", "other-buildpack")
@@ -216,6 +579,27 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("WithSecretEnv", func() {
+			it.Before(func() {
+				setup.WithEnvCall.Returns.SetupPhase = setup
+				start.WithEnvCall.Returns.StartPhase = start
+			})
+
+			it("uses those environment variables along with the others", func() {
+				platform.Deploy.
+					WithEnv(map[string]string{"SOME_KEY": "some-value"}).
+					WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value"})
+				Expect(setup.WithEnvCall.Receives.Env).To(Equal(map[string]string{
+					"SOME_KEY":   "some-value",
+					"SECRET_KEY": "secret-value",
+				}))
+				Expect(start.WithEnvCall.Receives.Env).To(Equal(map[string]string{
+					"SOME_KEY":   "some-value",
+					"SECRET_KEY": "secret-value",
+				}))
+			})
+		})
+
 		context("WithoutInternetAccess", func() {
 			it("ensures the app does not have internet access", func() {
 				platform.Deploy.WithoutInternetAccess()
@@ -250,6 +634,333 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("WithObserver", func() {
+			var observer *recordingObserver
+
+			it.Before(func() {
+				observer = &recordingObserver{}
+
+				setup.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, name, path string) (string, error) {
+					setup.WithObserverCall.Receives.Observer.Started("image-pull")
+					setup.WithObserverCall.Receives.Observer.Finished("image-pull", nil)
+					return "some-container-id", nil
+				}
+			})
+
+			it("reports and records the time taken by each phase", func() {
+				deployment, _, err := platform.WithObserver(observer).Deploy.Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(observer.events).To(Equal([]string{
+					"started setup",
+					"started image-pull",
+					"finished image-pull",
+					"finished setup",
+					"started stage",
+					"finished stage",
+					"started start",
+					"finished start",
+				}))
+				Expect(observer.finished[0]).To(MatchFields(IgnoreExtras, Fields{
+					"Platform": Equal(switchblade.Docker),
+					"Name":     Equal("some-app"),
+					"Phase":    Equal(switchblade.PhaseImagePull),
+					"Err":      BeNil(),
+				}))
+
+				var phases []string
+				for _, timing := range deployment.Timings {
+					phases = append(phases, timing.Phase)
+					Expect(timing.Start).NotTo(BeZero())
+					Expect(timing.Duration).To(BeNumerically(">=", 0))
+				}
+				Expect(phases).To(Equal([]string{
+					switchblade.PhaseSetup,
+					switchblade.PhaseImagePull,
+					switchblade.PhaseStage,
+					switchblade.PhaseStart,
+				}))
+			})
+
+			context("when a phase fails", func() {
+				it.Before(func() {
+					stage.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, containerID, name string) (string, error) {
+						return "", errors.New("stage phase errored")
+					}
+				})
+
+				it("reports the error with the phase", func() {
+					_, _, err := platform.WithObserver(observer).Deploy.Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(HaveOccurred())
+
+					Expect(observer.events).To(HaveLen(6))
+					Expect(observer.finished[2].Phase).To(Equal(switchblade.PhaseStage))
+					Expect(observer.finished[2].Err).To(MatchError("stage phase errored"))
+				})
+			})
+		})
+
+		context("WithDroplet", func() {
+			it.Before(func() {
+				setup.WithoutStagingCall.Returns.SetupPhase = setup
+				start.WithDropletCall.Returns.StartPhase = start
+			})
+
+			it("starts that droplet without staging the app", func() {
+				deployment, logs, err := platform.Deploy.WithDroplet("/some/droplet.tgz").Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logs).To(ContainLines(
+					"Setting up...",
+					"Starting...",
+				))
+				Expect(deployment.ExternalURL).To(Equal("some-external-url"))
+
+				Expect(setup.WithoutStagingCall.CallCount).To(Equal(1))
+				Expect(stage.RunCall.CallCount).To(Equal(0))
+
+				Expect(start.WithDropletCall.Receives.Path).To(Equal("/some/droplet.tgz"))
+				Expect(start.RunCall.Receives.Name).To(Equal("some-app"))
+				Expect(start.RunCall.Receives.Command).To(BeEmpty())
+			})
+
+			it("exports that droplet as an image", func() {
+				deployment, _, err := platform.Deploy.WithDroplet("/some/droplet.tgz").Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = deployment.ExportImage("some-image:some-tag")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(export.RunCall.Receives.Droplet).To(Equal("/some/droplet.tgz"))
+			})
+
+			context("when the deployment is without starting", func() {
+				it("returns an error", func() {
+					_, _, err := platform.Deploy.WithDroplet("/some/droplet.tgz").WithoutStart().Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to deploy droplet: a droplet cannot be deployed without starting it"))
+					Expect(setup.RunCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("ExecuteImage", func() {
+			it.Before(func() {
+				setup.WithImageCall.Returns.SetupPhase = setup
+				start.WithImageCall.Returns.StartPhase = start
+			})
+
+			it("starts that image without staging an app", func() {
+				deployment, logs, err := platform.Deploy.ExecuteImage("some-app", "some-org/some-image:some-tag")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logs).To(ContainLines(
+					"Setting up...",
+					"Starting...",
+				))
+				Expect(deployment.Name).To(Equal("some-app"))
+				Expect(deployment.ExternalURL).To(Equal("some-external-url"))
+				Expect(deployment.InternalURL).To(Equal("some-internal-url"))
+
+				Expect(setup.WithImageCall.Receives.Image).To(Equal("some-org/some-image:some-tag"))
+				Expect(setup.RunCall.Receives.Name).To(Equal("some-app"))
+				Expect(stage.RunCall.CallCount).To(Equal(0))
+
+				Expect(start.WithImageCall.Receives.Image).To(Equal("some-org/some-image:some-tag"))
+				Expect(start.RunCall.Receives.Name).To(Equal("some-app"))
+				Expect(start.RunCall.Receives.Command).To(BeEmpty())
+			})
+
+			it("does not export the app as an image", func() {
+				deployment, _, err := platform.Deploy.ExecuteImage("some-app", "some-org/some-image:some-tag")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = deployment.ExportImage("some-image:some-tag")
+				Expect(err).To(MatchError("failed to export image: some-app was deployed from the image some-org/some-image:some-tag rather than a droplet, so that image can be run instead"))
+				Expect(export.RunCall.CallCount).To(Equal(0))
+			})
+
+			context("when the deployment is without starting", func() {
+				it("returns an error", func() {
+					_, _, err := platform.Deploy.WithoutStart().ExecuteImage("some-app", "some-org/some-image:some-tag")
+					Expect(err).To(MatchError("failed to deploy image: an image cannot be deployed without starting it"))
+					Expect(setup.RunCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("WithoutStart", func() {
+			it.Before(func() {
+				collect.DropletCall.Returns.Droplet = docker.Droplet{
+					Path:         "/some/workspace/droplets/some-app.tar.gz",
+					Buildpacks:   []docker.DropletBuildpack{{Name: "some-buildpack", Version: "1.2.3"}},
+					ProcessTypes: map[string]string{"web": "some-command"},
+					Metadata:     []byte(`{"process_types": {"web": "some-command"}}`),
+				}
+			})
+
+			it("stages the app without starting it", func() {
+				deployment, logs, err := platform.Deploy.WithoutStart().Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(logs).To(ContainLines(
+					"Setting up...",
+					"Staging...",
+				))
+				Expect(logs.String()).NotTo(ContainSubstring("Starting..."))
+				Expect(start.RunCall.CallCount).To(Equal(0))
+
+				Expect(deployment.Name).To(Equal("some-app"))
+				Expect(deployment.ExternalURL).To(BeEmpty())
+				Expect(deployment.Droplet).To(Equal(switchblade.Droplet{
+					Path:         "/some/workspace/droplets/some-app.tar.gz",
+					Buildpacks:   []switchblade.DropletBuildpack{{Name: "some-buildpack", Version: "1.2.3"}},
+					ProcessTypes: map[string]string{"web": "some-command"},
+					Metadata:     []byte(`{"process_types": {"web": "some-command"}}`),
+				}))
+
+				Expect(collect.DropletCall.Receives.Name).To(Equal("some-app"))
+			})
+
+			it("does not export the app as an image", func() {
+				deployment, _, err := platform.Deploy.WithoutStart().Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				err = deployment.ExportImage("some-image:some-tag")
+				Expect(err).To(MatchError(`failed to export image: some-app was deployed without starting it, so it has no container to export; deploy it again WithDroplet("/some/workspace/droplets/some-app.tar.gz") first`))
+				Expect(export.RunCall.CallCount).To(Equal(0))
+			})
+
+			context("when the droplet cannot be read", func() {
+				it.Before(func() {
+					collect.DropletCall.Returns.Error = errors.New("failed to read result.json: no such file")
+				})
+
+				it("returns a platform error", func() {
+					_, _, err := platform.Deploy.WithoutStart().Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to read droplet: failed to read result.json: no such file")))
+
+					var platformErr switchblade.PlatformError
+					Expect(errors.As(err, &platformErr)).To(BeTrue())
+					Expect(platformErr.Phase).To(Equal("stage"))
+				})
+			})
+		})
+
+		context("WithArtifactsDir", func() {
+			var artifactsDir string
+
+			it.Before(func() {
+				var err error
+				artifactsDir, err = os.MkdirTemp("", "artifacts")
+				Expect(err).NotTo(HaveOccurred())
+
+				setup.WithEnvCall.Returns.SetupPhase = setup
+				start.WithEnvCall.Returns.StartPhase = start
+
+				client.ContainerLogsCall.Stub = func(ctx gocontext.Context, container string, options container.LogsOptions) (io.ReadCloser, error) {
+					return io.NopCloser(strings.NewReader("Application is running\n")), nil
+				}
+
+				collect.RunCall.Returns.Artifacts = docker.Artifacts{
+					Container: []byte(`{"Name": "/some-app"}`),
+					Env:       []string{"SOME_KEY=some-value", "SECRET_KEY=secret-value"},
+					Result:    []byte(`{"processes": []}`),
+				}
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(artifactsDir)).To(Succeed())
+			})
+
+			it("writes the artifacts of the deployment to that directory", func() {
+				_, logs, err := platform.Deploy.
+					WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value"}).
+					WithArtifactsDir(artifactsDir).
+					Execute("some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(collect.RunCall.Receives.Name).To(Equal("some-app"))
+				Expect(collect.RunCall.Receives.Droplet).To(BeEmpty())
+
+				dir := filepath.Join(artifactsDir, "some-app")
+				files := map[string]string{
+					switchblade.StagingLogFile: logs.String(),
+					switchblade.RuntimeLogFile: "Application is running\n",
+					switchblade.EnvFile:        "SOME_KEY=some-value\nSECRET_KEY=[REDACTED]\n",
+					"container.json":           `{"Name": "/some-app"}`,
+					"result.json":              `{"processes": []}`,
+				}
+				for name, content := range files {
+					actual, err := os.ReadFile(filepath.Join(dir, name))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(actual)).To(Equal(content), name)
+				}
+
+				Expect(filepath.Join(dir, switchblade.ArtifactsErrorsFile)).NotTo(BeAnExistingFile())
+			})
+
+			context("WithDropletArtifact", func() {
+				it("collects the droplet into that directory", func() {
+					_, _, err := platform.Deploy.
+						WithArtifactsDir(artifactsDir).
+						WithDropletArtifact().
+						Execute("some-app", "/some/path/to/my/app")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(collect.RunCall.Receives.Droplet).To(Equal(filepath.Join(artifactsDir, "some-app", switchblade.DropletArtifactFile)))
+				})
+			})
+
+			context("when the deployment fails", func() {
+				it.Before(func() {
+					stage.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, containerID, name string) (string, error) {
+						fmt.Fprintln(logs, "Staging...")
+						return "", docker.StagingError{ExitCode: 223}
+					}
+
+					collect.RunCall.Returns.Artifacts = docker.Artifacts{}
+					collect.RunCall.Returns.Error = errors.New("failed to inspect container: no such container")
+					client.ContainerLogsCall.Stub = nil
+					client.ContainerLogsCall.Returns.Error = errors.New("no such container")
+				})
+
+				it("writes the artifacts that could be collected along with the problems", func() {
+					_, logs, err := platform.Deploy.
+						WithArtifactsDir(artifactsDir).
+						Execute("some-app", "/some/path/to/my/app")
+
+					var stagingErr switchblade.StagingError
+					Expect(errors.As(err, &stagingErr)).To(BeTrue())
+
+					dir := filepath.Join(artifactsDir, "some-app")
+					content, err := os.ReadFile(filepath.Join(dir, switchblade.StagingLogFile))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(Equal(logs.String()))
+
+					content, err = os.ReadFile(filepath.Join(dir, switchblade.ArtifactsErrorsFile))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(ContainSubstring("failed to inspect container: no such container"))
+					Expect(string(content)).To(ContainSubstring("failed to retrieve container logs: no such container"))
+
+					Expect(filepath.Join(dir, switchblade.RuntimeLogFile)).NotTo(BeAnExistingFile())
+					Expect(filepath.Join(dir, "container.json")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			context("when the artifacts cannot be written", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(artifactsDir, "some-app"), nil, 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, _, err := platform.Deploy.
+						WithArtifactsDir(artifactsDir).
+						Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to write artifacts: failed to create artifacts directory:")))
+				})
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the setup phase errors", func() {
 				it.Before(func() {
@@ -263,6 +974,11 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 					_, logs, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to run setup phase: setup phase errored")))
 					Expect(err).To(MatchError(ContainSubstring("Setting up...")))
+
+					var setupErr switchblade.SetupError
+					Expect(errors.As(err, &setupErr)).To(BeTrue())
+					Expect(setupErr.Platform).To(Equal(switchblade.Docker))
+					Expect(setupErr.Logs).To(Equal("Setting up...\n"))
 					Expect(logs).To(ContainLines(
 						"Setting up...",
 					))
@@ -285,6 +1001,10 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 					_, logs, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to run stage phase: stage phase errored")))
 					Expect(err).To(MatchError(ContainSubstring("Staging...")))
+
+					var platformErr switchblade.PlatformError
+					Expect(errors.As(err, &platformErr)).To(BeTrue())
+					Expect(platformErr.Phase).To(Equal("stage"))
 					Expect(logs).To(ContainLines(
 						"Setting up...",
 						"Staging...",
@@ -295,6 +1015,114 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 				})
 			})
 
+			context("when the logs are longer than the error log lines", func() {
+				it.Before(func() {
+					stage.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, containerID, name string) (string, error) {
+						for i := 1; i <= 5; i++ {
+							fmt.Fprintf(logs, "Staging line %d\n", i)
+						}
+						return "", errors.New("stage phase errored")
+					}
+				})
+
+				it("includes the end of the logs and writes them in full to a file", func() {
+					_, logs, err := platform.Deploy.WithErrorLogLines(2).Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(MatchRegexp(`^failed to run stage phase: stage phase errored\n\nOutput \(last 2 of 6 lines, full log at .+\):\nStaging line 4\nStaging line 5\n$`)))
+					Expect(err).NotTo(MatchError(ContainSubstring("Staging line 3")))
+
+					path := regexp.MustCompile(`full log at (.+)\)`).FindStringSubmatch(err.Error())[1]
+					defer os.Remove(path)
+
+					content, err := os.ReadFile(path)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(Equal(logs.String()))
+				})
+			})
+
+			context("when the app fails to stage", func() {
+				it.Before(func() {
+					setup.WithBuildpacksCall.Returns.SetupPhase = setup
+					stage.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, containerID, name string) (string, error) {
+						fmt.Fprintln(logs, "None of the buildpacks detected a compatible application")
+						return "", docker.StagingError{ExitCode: 222}
+					}
+				})
+
+				it("returns a staging error", func() {
+					_, _, err := platform.Deploy.WithBuildpacks("some-buildpack").Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to run stage phase: App staging failed: container exited with non-zero status code (222)")))
+
+					var stagingErr switchblade.StagingError
+					Expect(errors.As(err, &stagingErr)).To(BeTrue())
+					Expect(stagingErr.NoBuildpackDetected()).To(BeTrue())
+					Expect(stagingErr).To(MatchFields(IgnoreExtras, Fields{
+						"Platform":  Equal(switchblade.Docker),
+						"ExitCode":  Equal(switchblade.DetectFailCode),
+						"Buildpack": Equal("some-buildpack"),
+						"Logs":      ContainSubstring("None of the buildpacks detected a compatible application"),
+					}))
+				})
+			})
+
+			context("when the app fails to compile with several buildpacks", func() {
+				it.Before(func() {
+					setup.WithBuildpacksCall.Returns.SetupPhase = setup
+					stage.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, containerID, name string) (string, error) {
+						fmt.Fprintln(logs, "-----> Nodejs Buildpack version 1.8.0")
+						fmt.Fprintln(logs, "-----> Go Buildpack version 1.10.0")
+						fmt.Fprintln(logs, "**ERROR** Unable to install go")
+						return "", docker.StagingError{ExitCode: 223}
+					}
+				})
+
+				it("reports the buildpack that failed", func() {
+					_, _, err := platform.Deploy.WithBuildpacks("nodejs_buildpack", "go_buildpack").Execute("some-app", "/some/path/to/my/app")
+
+					var stagingErr switchblade.StagingError
+					Expect(errors.As(err, &stagingErr)).To(BeTrue())
+					Expect(stagingErr.ExitCode).To(Equal(switchblade.CompileFailCode))
+					Expect(stagingErr.Buildpack).To(Equal("Go"))
+				})
+			})
+
+			context("when the logs contain secrets", func() {
+				it.Before(func() {
+					setup.WithEnvCall.Returns.SetupPhase = setup
+					setup.WithServicesCall.Returns.SetupPhase = setup
+					start.WithEnvCall.Returns.StartPhase = start
+					start.WithServicesCall.Returns.StartPhase = start
+					stage.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, containerID, name string) (string, error) {
+						fmt.Fprintln(logs, "Using token secret-value")
+						fmt.Fprintln(logs, `VCAP_SERVICES={"some-service":[{"credentials":{"password":"pa\"ss"}}]}`)
+						return "", fmt.Errorf("failed with secret-value and %s", "service-password")
+					}
+				})
+
+				it("redacts them from the logs and the error", func() {
+					_, logs, err := platform.Deploy.
+						WithSecretEnv(map[string]string{"SECRET_KEY": "secret-value"}).
+						WithServices(map[string]switchblade.Service{
+							"some-service": {
+								"password": `pa"ss`,
+								"nested":   map[string]interface{}{"uri": "service-password"},
+							},
+						}).
+						Execute("some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to run stage phase: failed with [REDACTED] and [REDACTED]")))
+					Expect(err).To(MatchError(ContainSubstring("Using token [REDACTED]")))
+					Expect(err).NotTo(MatchError(ContainSubstring("secret-value")))
+
+					Expect(logs).To(ContainLines(
+						"Using token [REDACTED]",
+						`VCAP_SERVICES={"some-service":[{"credentials":{"password":"[REDACTED]"}}]}`,
+					))
+
+					var platformErr switchblade.PlatformError
+					Expect(errors.As(err, &platformErr)).To(BeTrue())
+					Expect(platformErr.Logs).To(Equal(logs.String()))
+				})
+			})
+
 			context("when the start phase errors", func() {
 				it.Before(func() {
 					start.RunCall.Stub = func(ctx gocontext.Context, logs io.Writer, name, command string) (string, string, error) {
@@ -307,6 +1135,10 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 					_, logs, err := platform.Deploy.Execute("some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to run start phase: start phase errored")))
 					Expect(err).To(MatchError(ContainSubstring("Starting...")))
+
+					var startErr switchblade.StartError
+					Expect(errors.As(err, &startErr)).To(BeTrue())
+					Expect(startErr.Platform).To(Equal(switchblade.Docker))
 					Expect(logs).To(ContainLines(
 						"Setting up...",
 						"Staging...",
@@ -317,7 +1149,54 @@ func testDocker(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("Attach", func() {
+		it("retrieves runtime logs from an existing deployment", func() {
+			client.ContainerLogsCall.Stub = func(ctx gocontext.Context, container string, options container.LogsOptions) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("Docker runtime log 1\n")), nil
+			}
+
+			deployment := platform.Attach("some-app")
+			Expect(deployment.Name).To(Equal("some-app"))
+
+			logs, err := deployment.RuntimeLogs()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logs).To(Equal("Docker runtime log 1\n"))
+
+			Expect(client.ContainerLogsCall.Receives.Container).To(Equal("some-app"))
+		})
+
+		it("restarts an existing deployment", func() {
+			restart.RunCall.Returns.ExternalURL = "some-external-url"
+
+			deployment, err := platform.Attach("some-app").Restart()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deployment.ExternalURL).To(Equal("some-external-url"))
+
+			Expect(restart.RunCall.Receives.Name).To(Equal("some-app"))
+		})
+
+		it("exports an existing deployment as an image", func() {
+			err := platform.Attach("some-app").ExportImage("some-image:some-tag")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(export.RunCall.Receives.Name).To(Equal("some-app"))
+			Expect(export.RunCall.Receives.Tag).To(Equal("some-image:some-tag"))
+		})
+	})
+
 	context("Delete", func() {
+		context("WithObserver", func() {
+			it("reports the time taken by the teardown", func() {
+				observer := &recordingObserver{}
+
+				err := platform.WithObserver(observer).Delete.Execute("some-app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(observer.events).To(Equal([]string{"started teardown", "finished teardown"}))
+				Expect(observer.finished[0].Name).To(Equal("some-app"))
+			})
+		})
+
 		it("deletes the app", func() {
 			err := platform.Delete.Execute("some-app")
 			Expect(err).NotTo(HaveOccurred())
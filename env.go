@@ -0,0 +1,135 @@
+package switchblade
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+const (
+	PlatformEnv    = "SWITCHBLADE_PLATFORM"
+	StackEnv       = "SWITCHBLADE_STACK"
+	GitHubTokenEnv = "SWITCHBLADE_GITHUB_TOKEN"
+	WorkspaceEnv   = "SWITCHBLADE_WORKSPACE"
+	CFHomeEnv      = "SWITCHBLADE_CF_HOME"
+	CFTempDirEnv   = "SWITCHBLADE_CF_TEMP_DIR"
+
+	DefaultStack = "cflinuxfs4"
+)
+
+// ConfigFromEnv builds a Config from the environment:
+//
+//   - SWITCHBLADE_PLATFORM selects the platform, either "cf" or "docker".
+//   - SWITCHBLADE_STACK selects the stack, defaulting to DefaultStack.
+//   - SWITCHBLADE_GITHUB_TOKEN provides the GitHub API token. When unset,
+//     GITHUB_TOKEN is used, followed on Docker by the output of
+//     `gh auth token`.
+//   - SWITCHBLADE_WORKSPACE, SWITCHBLADE_CF_HOME, and SWITCHBLADE_CF_TEMP_DIR
+//     override the Workspace, CFHome, and CFTempDir fields and must be
+//     absolute paths.
+func ConfigFromEnv() (Config, error) {
+	platformType := strings.ToLower(strings.TrimSpace(os.Getenv(PlatformEnv)))
+	switch platformType {
+	case CloudFoundry, Docker:
+	case "":
+		return Config{}, fmt.Errorf("%s must be set to %q or %q", PlatformEnv, CloudFoundry, Docker)
+	default:
+		return Config{}, fmt.Errorf("%s is set to unsupported platform %q: set it to %q or %q", PlatformEnv, platformType, CloudFoundry, Docker)
+	}
+
+	return configFromEnv(platformType)
+}
+
+// ConfigFromEnvWithType builds a Config from the environment in the same way
+// as ConfigFromEnv, but for the given platform type instead of the one that
+// SWITCHBLADE_PLATFORM selects.
+func ConfigFromEnvWithType(platformType string) (Config, error) {
+	platformType = strings.ToLower(strings.TrimSpace(platformType))
+	if platformType != CloudFoundry && platformType != Docker {
+		return Config{}, fmt.Errorf("unsupported platform %q: use %q or %q", platformType, CloudFoundry, Docker)
+	}
+
+	return configFromEnv(platformType)
+}
+
+func configFromEnv(platformType string) (Config, error) {
+	config := Config{Type: platformType}
+
+	config.Stack = strings.TrimSpace(os.Getenv(StackEnv))
+	if config.Stack == "" {
+		config.Stack = DefaultStack
+	}
+
+	for env, field := range map[string]*string{
+		WorkspaceEnv: &config.Workspace,
+		CFHomeEnv:    &config.CFHome,
+		CFTempDirEnv: &config.CFTempDir,
+	} {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+
+		if !filepath.IsAbs(value) {
+			return Config{}, fmt.Errorf("%s must be an absolute path, got %q", env, value)
+		}
+
+		*field = value
+	}
+
+	// Only the Docker platform resolves buildpacks with the GitHub API, so the
+	// GitHub CLI is not run for Cloud Foundry.
+	config.Token = githubToken(platformType == Docker)
+
+	return config, nil
+}
+
+// NewPlatformFromEnv creates a Platform from the Config returned by
+// ConfigFromEnv, with the given options applied on top.
+func NewPlatformFromEnv(options ...PlatformOption) (Platform, error) {
+	config, err := ConfigFromEnv()
+	if err != nil {
+		return Platform{}, err
+	}
+
+	for _, option := range options {
+		option(&config)
+	}
+
+	return NewPlatformWithConfig(config)
+}
+
+func githubToken(useCLI bool) string {
+	for _, env := range []string{GitHubTokenEnv, "GITHUB_TOKEN"} {
+		if token := strings.TrimSpace(os.Getenv(env)); token != "" {
+			return token
+		}
+	}
+
+	if !useCLI {
+		return ""
+	}
+
+	// The GitHub CLI is consulted last, and a missing or logged out CLI simply
+	// means there is no token.
+	if _, err := exec.LookPath("gh"); err != nil {
+		return ""
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err := pexec.NewExecutable("gh").Execute(pexec.Execution{
+		Args:   []string{"auth", "token"},
+		Stdout: buffer,
+		Stderr: bytes.NewBuffer(nil),
+	})
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(buffer.String())
+}
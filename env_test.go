@@ -0,0 +1,213 @@
+package switchblade_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testEnv(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		original map[string]*string
+		setenv   func(key, value string)
+	)
+
+	it.Before(func() {
+		original = map[string]*string{}
+		setenv = func(key, value string) {
+			if _, ok := original[key]; !ok {
+				if value, ok := os.LookupEnv(key); ok {
+					original[key] = &value
+				} else {
+					original[key] = nil
+				}
+			}
+
+			Expect(os.Setenv(key, value)).To(Succeed())
+		}
+
+		for _, key := range []string{
+			switchblade.PlatformEnv,
+			switchblade.StackEnv,
+			switchblade.GitHubTokenEnv,
+			switchblade.WorkspaceEnv,
+			switchblade.CFHomeEnv,
+			switchblade.CFTempDirEnv,
+			"GITHUB_TOKEN",
+		} {
+			setenv(key, "")
+		}
+
+		// Keep any GitHub CLI on the host from supplying a token.
+		setenv("PATH", "")
+	})
+
+	it.After(func() {
+		for key, value := range original {
+			if value == nil {
+				Expect(os.Unsetenv(key)).To(Succeed())
+			} else {
+				Expect(os.Setenv(key, *value)).To(Succeed())
+			}
+		}
+	})
+
+	context("ConfigFromEnv", func() {
+		it("reads the platform configuration", func() {
+			setenv(switchblade.PlatformEnv, "Docker")
+			setenv(switchblade.StackEnv, "some-stack")
+			setenv(switchblade.GitHubTokenEnv, "some-token")
+			setenv(switchblade.WorkspaceEnv, "/some/workspace")
+			setenv(switchblade.CFHomeEnv, "/some/cf-home")
+			setenv(switchblade.CFTempDirEnv, "/some/tmp")
+
+			config, err := switchblade.ConfigFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(switchblade.Config{
+				Type:      switchblade.Docker,
+				Stack:     "some-stack",
+				Token:     "some-token",
+				Workspace: "/some/workspace",
+				CFHome:    "/some/cf-home",
+				CFTempDir: "/some/tmp",
+			}))
+		})
+
+		it("defaults the stack", func() {
+			setenv(switchblade.PlatformEnv, "cf")
+
+			config, err := switchblade.ConfigFromEnv()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Stack).To(Equal(switchblade.DefaultStack))
+		})
+
+		context("when the token is only in GITHUB_TOKEN", func() {
+			it("uses that token", func() {
+				setenv(switchblade.PlatformEnv, "docker")
+				setenv("GITHUB_TOKEN", "other-token")
+
+				config, err := switchblade.ConfigFromEnv()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Token).To(Equal("other-token"))
+			})
+		})
+
+		context("when the token is only available from the GitHub CLI", func() {
+			var dir string
+
+			it.Before(func() {
+				var err error
+				dir, err = os.MkdirTemp("", "bin")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.WriteFile(filepath.Join(dir, "gh"), []byte("#!/bin/sh\necho gh-token\n"), 0755)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(dir)).To(Succeed())
+			})
+
+			it("uses that token", func() {
+				setenv(switchblade.PlatformEnv, "docker")
+				setenv("PATH", dir)
+
+				config, err := switchblade.ConfigFromEnv()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Token).To(Equal("gh-token"))
+			})
+
+			context("when the platform is Cloud Foundry", func() {
+				it("does not run the GitHub CLI", func() {
+					setenv(switchblade.PlatformEnv, "cf")
+					setenv("PATH", dir)
+
+					config, err := switchblade.ConfigFromEnv()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(config.Token).To(BeEmpty())
+				})
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the platform is not set", func() {
+				it("returns an error", func() {
+					_, err := switchblade.ConfigFromEnv()
+					Expect(err).To(MatchError(`SWITCHBLADE_PLATFORM must be set to "cf" or "docker"`))
+				})
+			})
+
+			context("when the platform is not supported", func() {
+				it("returns an error", func() {
+					setenv(switchblade.PlatformEnv, "kubernetes")
+
+					_, err := switchblade.ConfigFromEnv()
+					Expect(err).To(MatchError(`SWITCHBLADE_PLATFORM is set to unsupported platform "kubernetes": set it to "cf" or "docker"`))
+				})
+			})
+
+			context("when a workspace override is not absolute", func() {
+				it("returns an error", func() {
+					setenv(switchblade.PlatformEnv, "docker")
+					setenv(switchblade.WorkspaceEnv, "relative/workspace")
+
+					_, err := switchblade.ConfigFromEnv()
+					Expect(err).To(MatchError(`SWITCHBLADE_WORKSPACE must be an absolute path, got "relative/workspace"`))
+				})
+			})
+		})
+	})
+
+	context("ConfigFromEnvWithType", func() {
+		it("reads the platform configuration for the given platform", func() {
+			setenv(switchblade.PlatformEnv, "cf")
+			setenv(switchblade.WorkspaceEnv, "/some/workspace")
+
+			config, err := switchblade.ConfigFromEnvWithType("Docker")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Type).To(Equal(switchblade.Docker))
+			Expect(config.Stack).To(Equal(switchblade.DefaultStack))
+			Expect(config.Workspace).To(Equal("/some/workspace"))
+
+			Expect(os.Getenv(switchblade.PlatformEnv)).To(Equal("cf"))
+		})
+
+		context("failure cases", func() {
+			context("when the platform is not supported", func() {
+				it("returns an error", func() {
+					_, err := switchblade.ConfigFromEnvWithType("kubernetes")
+					Expect(err).To(MatchError(`unsupported platform "kubernetes": use "cf" or "docker"`))
+				})
+			})
+		})
+	})
+
+	context("NewPlatformFromEnv", func() {
+		it("creates the platform with the options applied", func() {
+			setenv(switchblade.PlatformEnv, "cf")
+			setenv(switchblade.CFHomeEnv, "/some/cf-home")
+
+			platform, err := switchblade.NewPlatformFromEnv(switchblade.WithLifecyclePath("/some/lifecycle"))
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := platform.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Type).To(Equal(switchblade.CloudFoundry))
+		})
+
+		context("failure cases", func() {
+			context("when the environment is invalid", func() {
+				it("returns an error", func() {
+					_, err := switchblade.NewPlatformFromEnv()
+					Expect(err).To(MatchError(ContainSubstring("SWITCHBLADE_PLATFORM must be set")))
+				})
+			})
+		})
+	})
+}
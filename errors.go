@@ -0,0 +1,133 @@
+package switchblade
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// DefaultErrorLogLines is the number of lines from the end of the deployment
+// logs that are included in the message of a deployment error.
+const DefaultErrorLogLines = 100
+
+// Exit codes of the buildpackapplifecycle builder that a StagingError reports
+// when staging fails in a known way. On Cloud Foundry, they are derived from
+// the staging error reported by the cloud controller.
+const (
+	DetectFailCode  = 222
+	CompileFailCode = 223
+	ReleaseFailCode = 224
+)
+
+// SetupError is returned by DeployProcess.Execute when the platform could not
+// be prepared for the application, such as when its source could not be
+// uploaded or its org could not be created.
+type SetupError struct {
+	Platform string
+	Logs     string
+	Err      error
+}
+
+func (e SetupError) Error() string { return e.Err.Error() }
+func (e SetupError) Unwrap() error { return e.Err }
+
+// StagingError is returned by DeployProcess.Execute when the application
+// failed to stage. The ExitCode is one of DetectFailCode, CompileFailCode, or
+// ReleaseFailCode when the failure is recognized. The Buildpack is the
+// buildpack that was given to WithBuildpacks when there was only one, or
+// otherwise the last buildpack that reported itself in the logs, such as
+// "Go" for "-----> Go Buildpack version 1.10.0".
+type StagingError struct {
+	Platform  string
+	ExitCode  int
+	Buildpack string
+	Logs      string
+	Err       error
+}
+
+func (e StagingError) Error() string { return e.Err.Error() }
+func (e StagingError) Unwrap() error { return e.Err }
+
+// NoBuildpackDetected reports whether staging failed because none of the
+// buildpacks detected the application.
+func (e StagingError) NoBuildpackDetected() bool {
+	return e.ExitCode == DetectFailCode
+}
+
+// StartError is returned by DeployProcess.Execute when the application was
+// staged but failed to start.
+type StartError struct {
+	Platform string
+	Logs     string
+	Err      error
+}
+
+func (e StartError) Error() string { return e.Err.Error() }
+func (e StartError) Unwrap() error { return e.Err }
+
+// PlatformError is returned by DeployProcess.Execute when the platform itself
+// failed during the given phase, for reasons that are unrelated to the
+// application.
+type PlatformError struct {
+	Platform string
+	Phase    string
+	Logs     string
+	Err      error
+}
+
+func (e PlatformError) Error() string { return e.Err.Error() }
+func (e PlatformError) Unwrap() error { return e.Err }
+
+// withLogs appends the end of the deployment logs to the message of err. When
+// the logs are longer than the given number of lines, only the last lines are
+// included and the full logs are written to a temporary file whose path is
+// given in the message instead.
+func withLogs(err error, name, logs string, lines int) error {
+	all := strings.Split(strings.TrimSuffix(logs, "\n"), "\n")
+	if len(all) <= lines {
+		return fmt.Errorf("%w\n\nOutput:\n%s", err, logs)
+	}
+
+	if lines < 0 {
+		lines = 0
+	}
+
+	tail := strings.Join(all[len(all)-lines:], "\n")
+	if lines > 0 {
+		tail += "\n"
+	}
+
+	location := "full log could not be saved"
+	file, fileErr := os.CreateTemp("", fmt.Sprintf("switchblade-%s-*.log", name))
+	if fileErr == nil {
+		_, fileErr = file.WriteString(logs)
+		if closeErr := file.Close(); fileErr == nil {
+			fileErr = closeErr
+		}
+	}
+
+	if fileErr != nil {
+		location = fmt.Sprintf("%s: %s", location, fileErr)
+	} else {
+		location = fmt.Sprintf("full log at %s", file.Name())
+	}
+
+	return fmt.Errorf("%w\n\nOutput (last %d of %d lines, %s):\n%s", err, lines, len(all), location, tail)
+}
+
+var buildpackPattern = regexp.MustCompile(`-----> (.+?) [Bb]uildpack version`)
+
+// failedBuildpack returns the buildpack that staging failed in.
+func failedBuildpack(buildpacks []string, logs string) string {
+	if len(buildpacks) == 1 {
+		return buildpacks[0]
+	}
+
+	matches := buildpackPattern.FindAllStringSubmatch(logs, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(matches[len(matches)-1][1])
+}
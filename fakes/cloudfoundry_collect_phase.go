@@ -0,0 +1,61 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
+)
+
+type CloudFoundryCollectPhase struct {
+	DropletCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Home string
+			Name string
+		}
+		Returns struct {
+			Droplet cloudfoundry.Droplet
+			Error   error
+		}
+		Stub func(string, string) (cloudfoundry.Droplet, error)
+	}
+	RunCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Home    string
+			Name    string
+			Droplet string
+		}
+		Returns struct {
+			Artifacts cloudfoundry.Artifacts
+			Error     error
+		}
+		Stub func(string, string, string) (cloudfoundry.Artifacts, error)
+	}
+}
+
+func (f *CloudFoundryCollectPhase) Droplet(param1 string, param2 string) (cloudfoundry.Droplet, error) {
+	f.DropletCall.mutex.Lock()
+	defer f.DropletCall.mutex.Unlock()
+	f.DropletCall.CallCount++
+	f.DropletCall.Receives.Home = param1
+	f.DropletCall.Receives.Name = param2
+	if f.DropletCall.Stub != nil {
+		return f.DropletCall.Stub(param1, param2)
+	}
+	return f.DropletCall.Returns.Droplet, f.DropletCall.Returns.Error
+}
+func (f *CloudFoundryCollectPhase) Run(param1 string, param2 string, param3 string) (cloudfoundry.Artifacts, error) {
+	f.RunCall.mutex.Lock()
+	defer f.RunCall.mutex.Unlock()
+	f.RunCall.CallCount++
+	f.RunCall.Receives.Home = param1
+	f.RunCall.Receives.Name = param2
+	f.RunCall.Receives.Droplet = param3
+	if f.RunCall.Stub != nil {
+		return f.RunCall.Stub(param1, param2, param3)
+	}
+	return f.RunCall.Returns.Artifacts, f.RunCall.Returns.Error
+}
@@ -34,6 +34,17 @@ type CloudFoundrySetupPhase struct {
 		}
 		Stub func(...string) cloudfoundry.SetupPhase
 	}
+	WithDropletCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			SetupPhase cloudfoundry.SetupPhase
+		}
+		Stub func(string) cloudfoundry.SetupPhase
+	}
 	WithEnvCall struct {
 		mutex     sync.Mutex
 		CallCount int
@@ -45,6 +56,39 @@ type CloudFoundrySetupPhase struct {
 		}
 		Stub func(map[string]string) cloudfoundry.SetupPhase
 	}
+	WithHealthCheckTypeCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			HealthCheckType string
+		}
+		Returns struct {
+			SetupPhase cloudfoundry.SetupPhase
+		}
+		Stub func(string) cloudfoundry.SetupPhase
+	}
+	WithImageCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Image string
+		}
+		Returns struct {
+			SetupPhase cloudfoundry.SetupPhase
+		}
+		Stub func(string) cloudfoundry.SetupPhase
+	}
+	WithObserverCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Observer cloudfoundry.PhaseObserver
+		}
+		Returns struct {
+			SetupPhase cloudfoundry.SetupPhase
+		}
+		Stub func(cloudfoundry.PhaseObserver) cloudfoundry.SetupPhase
+	}
 	WithServicesCall struct {
 		mutex     sync.Mutex
 		CallCount int
@@ -80,18 +124,15 @@ type CloudFoundrySetupPhase struct {
 		}
 		Stub func(string) cloudfoundry.SetupPhase
 	}
-	WithHealthCheckTypeCall struct {
+	WithoutInternetAccessCall struct {
 		mutex     sync.Mutex
 		CallCount int
-		Receives  struct {
-			HealthCheckType string
-		}
-		Returns struct {
+		Returns   struct {
 			SetupPhase cloudfoundry.SetupPhase
 		}
-		Stub func(string) cloudfoundry.SetupPhase
+		Stub func() cloudfoundry.SetupPhase
 	}
-	WithoutInternetAccessCall struct {
+	WithoutRoutesCall struct {
 		mutex     sync.Mutex
 		CallCount int
 		Returns   struct {
@@ -124,6 +165,16 @@ func (f *CloudFoundrySetupPhase) WithBuildpacks(param1 ...string) cloudfoundry.S
 	}
 	return f.WithBuildpacksCall.Returns.SetupPhase
 }
+func (f *CloudFoundrySetupPhase) WithDroplet(param1 string) cloudfoundry.SetupPhase {
+	f.WithDropletCall.mutex.Lock()
+	defer f.WithDropletCall.mutex.Unlock()
+	f.WithDropletCall.CallCount++
+	f.WithDropletCall.Receives.Path = param1
+	if f.WithDropletCall.Stub != nil {
+		return f.WithDropletCall.Stub(param1)
+	}
+	return f.WithDropletCall.Returns.SetupPhase
+}
 func (f *CloudFoundrySetupPhase) WithEnv(param1 map[string]string) cloudfoundry.SetupPhase {
 	f.WithEnvCall.mutex.Lock()
 	defer f.WithEnvCall.mutex.Unlock()
@@ -134,6 +185,36 @@ func (f *CloudFoundrySetupPhase) WithEnv(param1 map[string]string) cloudfoundry.
 	}
 	return f.WithEnvCall.Returns.SetupPhase
 }
+func (f *CloudFoundrySetupPhase) WithHealthCheckType(param1 string) cloudfoundry.SetupPhase {
+	f.WithHealthCheckTypeCall.mutex.Lock()
+	defer f.WithHealthCheckTypeCall.mutex.Unlock()
+	f.WithHealthCheckTypeCall.CallCount++
+	f.WithHealthCheckTypeCall.Receives.HealthCheckType = param1
+	if f.WithHealthCheckTypeCall.Stub != nil {
+		return f.WithHealthCheckTypeCall.Stub(param1)
+	}
+	return f.WithHealthCheckTypeCall.Returns.SetupPhase
+}
+func (f *CloudFoundrySetupPhase) WithImage(param1 string) cloudfoundry.SetupPhase {
+	f.WithImageCall.mutex.Lock()
+	defer f.WithImageCall.mutex.Unlock()
+	f.WithImageCall.CallCount++
+	f.WithImageCall.Receives.Image = param1
+	if f.WithImageCall.Stub != nil {
+		return f.WithImageCall.Stub(param1)
+	}
+	return f.WithImageCall.Returns.SetupPhase
+}
+func (f *CloudFoundrySetupPhase) WithObserver(param1 cloudfoundry.PhaseObserver) cloudfoundry.SetupPhase {
+	f.WithObserverCall.mutex.Lock()
+	defer f.WithObserverCall.mutex.Unlock()
+	f.WithObserverCall.CallCount++
+	f.WithObserverCall.Receives.Observer = param1
+	if f.WithObserverCall.Stub != nil {
+		return f.WithObserverCall.Stub(param1)
+	}
+	return f.WithObserverCall.Returns.SetupPhase
+}
 func (f *CloudFoundrySetupPhase) WithServices(param1 map[string]map[string]interface {
 }) cloudfoundry.SetupPhase {
 	f.WithServicesCall.mutex.Lock()
@@ -165,16 +246,6 @@ func (f *CloudFoundrySetupPhase) WithStartCommand(param1 string) cloudfoundry.Se
 	}
 	return f.WithStartCommandCall.Returns.SetupPhase
 }
-func (f *CloudFoundrySetupPhase) WithHealthCheckType(param1 string) cloudfoundry.SetupPhase {
-	f.WithHealthCheckTypeCall.mutex.Lock()
-	defer f.WithHealthCheckTypeCall.mutex.Unlock()
-	f.WithHealthCheckTypeCall.CallCount++
-	f.WithHealthCheckTypeCall.Receives.HealthCheckType = param1
-	if f.WithHealthCheckTypeCall.Stub != nil {
-		return f.WithHealthCheckTypeCall.Stub(param1)
-	}
-	return f.WithHealthCheckTypeCall.Returns.SetupPhase
-}
 func (f *CloudFoundrySetupPhase) WithoutInternetAccess() cloudfoundry.SetupPhase {
 	f.WithoutInternetAccessCall.mutex.Lock()
 	defer f.WithoutInternetAccessCall.mutex.Unlock()
@@ -184,3 +255,12 @@ func (f *CloudFoundrySetupPhase) WithoutInternetAccess() cloudfoundry.SetupPhase
 	}
 	return f.WithoutInternetAccessCall.Returns.SetupPhase
 }
+func (f *CloudFoundrySetupPhase) WithoutRoutes() cloudfoundry.SetupPhase {
+	f.WithoutRoutesCall.mutex.Lock()
+	defer f.WithoutRoutesCall.mutex.Unlock()
+	f.WithoutRoutesCall.CallCount++
+	if f.WithoutRoutesCall.Stub != nil {
+		return f.WithoutRoutesCall.Stub()
+	}
+	return f.WithoutRoutesCall.Returns.SetupPhase
+}
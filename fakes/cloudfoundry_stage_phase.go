@@ -3,6 +3,8 @@ package fakes
 import (
 	"io"
 	"sync"
+
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
 )
 
 type CloudFoundryStagePhase struct {
@@ -20,6 +22,14 @@ type CloudFoundryStagePhase struct {
 		}
 		Stub func(io.Writer, string, string) (string, error)
 	}
+	WithoutStartCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Returns   struct {
+			StagePhase cloudfoundry.StagePhase
+		}
+		Stub func() cloudfoundry.StagePhase
+	}
 }
 
 func (f *CloudFoundryStagePhase) Run(param1 io.Writer, param2 string, param3 string) (string, error) {
@@ -34,3 +44,12 @@ func (f *CloudFoundryStagePhase) Run(param1 io.Writer, param2 string, param3 str
 	}
 	return f.RunCall.Returns.Url, f.RunCall.Returns.Err
 }
+func (f *CloudFoundryStagePhase) WithoutStart() cloudfoundry.StagePhase {
+	f.WithoutStartCall.mutex.Lock()
+	defer f.WithoutStartCall.mutex.Unlock()
+	f.WithoutStartCall.CallCount++
+	if f.WithoutStartCall.Stub != nil {
+		return f.WithoutStartCall.Stub()
+	}
+	return f.WithoutStartCall.Returns.StagePhase
+}
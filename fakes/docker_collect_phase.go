@@ -0,0 +1,60 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+)
+
+type DockerCollectPhase struct {
+	DropletCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Name string
+		}
+		Returns struct {
+			Droplet docker.Droplet
+			Error   error
+		}
+		Stub func(string) (docker.Droplet, error)
+	}
+	RunCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx     context.Context
+			Name    string
+			Droplet string
+		}
+		Returns struct {
+			Artifacts docker.Artifacts
+			Error     error
+		}
+		Stub func(context.Context, string, string) (docker.Artifacts, error)
+	}
+}
+
+func (f *DockerCollectPhase) Droplet(param1 string) (docker.Droplet, error) {
+	f.DropletCall.mutex.Lock()
+	defer f.DropletCall.mutex.Unlock()
+	f.DropletCall.CallCount++
+	f.DropletCall.Receives.Name = param1
+	if f.DropletCall.Stub != nil {
+		return f.DropletCall.Stub(param1)
+	}
+	return f.DropletCall.Returns.Droplet, f.DropletCall.Returns.Error
+}
+func (f *DockerCollectPhase) Run(param1 context.Context, param2 string, param3 string) (docker.Artifacts, error) {
+	f.RunCall.mutex.Lock()
+	defer f.RunCall.mutex.Unlock()
+	f.RunCall.CallCount++
+	f.RunCall.Receives.Ctx = param1
+	f.RunCall.Receives.Name = param2
+	f.RunCall.Receives.Droplet = param3
+	if f.RunCall.Stub != nil {
+		return f.RunCall.Stub(param1, param2, param3)
+	}
+	return f.RunCall.Returns.Artifacts, f.RunCall.Returns.Error
+}
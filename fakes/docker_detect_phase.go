@@ -0,0 +1,40 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+)
+
+type DockerDetectPhase struct {
+	RunCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx        context.Context
+			Name       string
+			Path       string
+			Buildpacks []string
+		}
+		Returns struct {
+			DetectResultSlice []docker.DetectResult
+			Error             error
+		}
+		Stub func(context.Context, string, string, ...string) ([]docker.DetectResult, error)
+	}
+}
+
+func (f *DockerDetectPhase) Run(param1 context.Context, param2 string, param3 string, param4 ...string) ([]docker.DetectResult, error) {
+	f.RunCall.mutex.Lock()
+	defer f.RunCall.mutex.Unlock()
+	f.RunCall.CallCount++
+	f.RunCall.Receives.Ctx = param1
+	f.RunCall.Receives.Name = param2
+	f.RunCall.Receives.Path = param3
+	f.RunCall.Receives.Buildpacks = param4
+	if f.RunCall.Stub != nil {
+		return f.RunCall.Stub(param1, param2, param3, param4...)
+	}
+	return f.RunCall.Returns.DetectResultSlice, f.RunCall.Returns.Error
+}
@@ -0,0 +1,39 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+)
+
+type DockerExportPhase struct {
+	RunCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx     context.Context
+			Name    string
+			Droplet string
+			Tag     string
+			Omit    []string
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(context.Context, string, string, string, ...string) error
+	}
+}
+
+func (f *DockerExportPhase) Run(param1 context.Context, param2 string, param3 string, param4 string, param5 ...string) error {
+	f.RunCall.mutex.Lock()
+	defer f.RunCall.mutex.Unlock()
+	f.RunCall.CallCount++
+	f.RunCall.Receives.Ctx = param1
+	f.RunCall.Receives.Name = param2
+	f.RunCall.Receives.Droplet = param3
+	f.RunCall.Receives.Tag = param4
+	f.RunCall.Receives.Omit = param5
+	if f.RunCall.Stub != nil {
+		return f.RunCall.Stub(param1, param2, param3, param4, param5...)
+	}
+	return f.RunCall.Returns.Error
+}
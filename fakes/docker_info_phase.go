@@ -0,0 +1,29 @@
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+)
+
+type DockerInfoPhase struct {
+	RunCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Returns   struct {
+			PlatformInfo docker.PlatformInfo
+			Error        error
+		}
+		Stub func() (docker.PlatformInfo, error)
+	}
+}
+
+func (f *DockerInfoPhase) Run() (docker.PlatformInfo, error) {
+	f.RunCall.mutex.Lock()
+	defer f.RunCall.mutex.Unlock()
+	f.RunCall.CallCount++
+	if f.RunCall.Stub != nil {
+		return f.RunCall.Stub()
+	}
+	return f.RunCall.Returns.PlatformInfo, f.RunCall.Returns.Error
+}
@@ -0,0 +1,104 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+)
+
+type DockerRestartPhase struct {
+	BindServiceCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			Name        string
+			Droplet     string
+			Service     string
+			Credentials map[string]interface {
+			}
+		}
+		Returns struct {
+			ExternalURL string
+			InternalURL string
+			Err         error
+		}
+		Stub func(context.Context, string, string, string, map[string]interface {
+		}) (string, string, error)
+	}
+	RunCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx     context.Context
+			Name    string
+			Droplet string
+			Env     map[string]string
+			Unset   []string
+		}
+		Returns struct {
+			ExternalURL string
+			InternalURL string
+			Err         error
+		}
+		Stub func(context.Context, string, string, map[string]string, []string) (string, string, error)
+	}
+	UnbindServiceCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx     context.Context
+			Name    string
+			Droplet string
+			Service string
+		}
+		Returns struct {
+			ExternalURL string
+			InternalURL string
+			Err         error
+		}
+		Stub func(context.Context, string, string, string) (string, string, error)
+	}
+}
+
+func (f *DockerRestartPhase) BindService(param1 context.Context, param2 string, param3 string, param4 string, param5 map[string]interface {
+}) (string, string, error) {
+	f.BindServiceCall.mutex.Lock()
+	defer f.BindServiceCall.mutex.Unlock()
+	f.BindServiceCall.CallCount++
+	f.BindServiceCall.Receives.Ctx = param1
+	f.BindServiceCall.Receives.Name = param2
+	f.BindServiceCall.Receives.Droplet = param3
+	f.BindServiceCall.Receives.Service = param4
+	f.BindServiceCall.Receives.Credentials = param5
+	if f.BindServiceCall.Stub != nil {
+		return f.BindServiceCall.Stub(param1, param2, param3, param4, param5)
+	}
+	return f.BindServiceCall.Returns.ExternalURL, f.BindServiceCall.Returns.InternalURL, f.BindServiceCall.Returns.Err
+}
+func (f *DockerRestartPhase) Run(param1 context.Context, param2 string, param3 string, param4 map[string]string, param5 []string) (string, string, error) {
+	f.RunCall.mutex.Lock()
+	defer f.RunCall.mutex.Unlock()
+	f.RunCall.CallCount++
+	f.RunCall.Receives.Ctx = param1
+	f.RunCall.Receives.Name = param2
+	f.RunCall.Receives.Droplet = param3
+	f.RunCall.Receives.Env = param4
+	f.RunCall.Receives.Unset = param5
+	if f.RunCall.Stub != nil {
+		return f.RunCall.Stub(param1, param2, param3, param4, param5)
+	}
+	return f.RunCall.Returns.ExternalURL, f.RunCall.Returns.InternalURL, f.RunCall.Returns.Err
+}
+func (f *DockerRestartPhase) UnbindService(param1 context.Context, param2 string, param3 string, param4 string) (string, string, error) {
+	f.UnbindServiceCall.mutex.Lock()
+	defer f.UnbindServiceCall.mutex.Unlock()
+	f.UnbindServiceCall.CallCount++
+	f.UnbindServiceCall.Receives.Ctx = param1
+	f.UnbindServiceCall.Receives.Name = param2
+	f.UnbindServiceCall.Receives.Droplet = param3
+	f.UnbindServiceCall.Receives.Service = param4
+	if f.UnbindServiceCall.Stub != nil {
+		return f.UnbindServiceCall.Stub(param1, param2, param3, param4)
+	}
+	return f.UnbindServiceCall.Returns.ExternalURL, f.UnbindServiceCall.Returns.InternalURL, f.UnbindServiceCall.Returns.Err
+}
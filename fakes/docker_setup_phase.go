@@ -46,6 +46,28 @@ type DockerSetupPhase struct {
 		}
 		Stub func(map[string]string) docker.SetupPhase
 	}
+	WithImageCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Image string
+		}
+		Returns struct {
+			SetupPhase docker.SetupPhase
+		}
+		Stub func(string) docker.SetupPhase
+	}
+	WithObserverCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Observer docker.PhaseObserver
+		}
+		Returns struct {
+			SetupPhase docker.SetupPhase
+		}
+		Stub func(docker.PhaseObserver) docker.SetupPhase
+	}
 	WithServicesCall struct {
 		mutex     sync.Mutex
 		CallCount int
@@ -78,6 +100,14 @@ type DockerSetupPhase struct {
 		}
 		Stub func() docker.SetupPhase
 	}
+	WithoutStagingCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Returns   struct {
+			SetupPhase docker.SetupPhase
+		}
+		Stub func() docker.SetupPhase
+	}
 }
 
 func (f *DockerSetupPhase) Run(param1 context.Context, param2 io.Writer, param3 string, param4 string) (string, error) {
@@ -113,6 +143,26 @@ func (f *DockerSetupPhase) WithEnv(param1 map[string]string) docker.SetupPhase {
 	}
 	return f.WithEnvCall.Returns.SetupPhase
 }
+func (f *DockerSetupPhase) WithImage(param1 string) docker.SetupPhase {
+	f.WithImageCall.mutex.Lock()
+	defer f.WithImageCall.mutex.Unlock()
+	f.WithImageCall.CallCount++
+	f.WithImageCall.Receives.Image = param1
+	if f.WithImageCall.Stub != nil {
+		return f.WithImageCall.Stub(param1)
+	}
+	return f.WithImageCall.Returns.SetupPhase
+}
+func (f *DockerSetupPhase) WithObserver(param1 docker.PhaseObserver) docker.SetupPhase {
+	f.WithObserverCall.mutex.Lock()
+	defer f.WithObserverCall.mutex.Unlock()
+	f.WithObserverCall.CallCount++
+	f.WithObserverCall.Receives.Observer = param1
+	if f.WithObserverCall.Stub != nil {
+		return f.WithObserverCall.Stub(param1)
+	}
+	return f.WithObserverCall.Returns.SetupPhase
+}
 func (f *DockerSetupPhase) WithServices(param1 map[string]map[string]interface {
 }) docker.SetupPhase {
 	f.WithServicesCall.mutex.Lock()
@@ -143,3 +193,12 @@ func (f *DockerSetupPhase) WithoutInternetAccess() docker.SetupPhase {
 	}
 	return f.WithoutInternetAccessCall.Returns.SetupPhase
 }
+func (f *DockerSetupPhase) WithoutStaging() docker.SetupPhase {
+	f.WithoutStagingCall.mutex.Lock()
+	defer f.WithoutStagingCall.mutex.Unlock()
+	f.WithoutStagingCall.CallCount++
+	if f.WithoutStagingCall.Stub != nil {
+		return f.WithoutStagingCall.Stub()
+	}
+	return f.WithoutStagingCall.Returns.SetupPhase
+}
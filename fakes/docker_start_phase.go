@@ -25,6 +25,17 @@ type DockerStartPhase struct {
 		}
 		Stub func(context.Context, io.Writer, string, string) (string, string, error)
 	}
+	WithDropletCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path string
+		}
+		Returns struct {
+			StartPhase docker.StartPhase
+		}
+		Stub func(string) docker.StartPhase
+	}
 	WithEnvCall struct {
 		mutex     sync.Mutex
 		CallCount int
@@ -36,6 +47,17 @@ type DockerStartPhase struct {
 		}
 		Stub func(map[string]string) docker.StartPhase
 	}
+	WithImageCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Image string
+		}
+		Returns struct {
+			StartPhase docker.StartPhase
+		}
+		Stub func(string) docker.StartPhase
+	}
 	WithServicesCall struct {
 		mutex     sync.Mutex
 		CallCount int
@@ -86,6 +108,16 @@ func (f *DockerStartPhase) Run(param1 context.Context, param2 io.Writer, param3
 	}
 	return f.RunCall.Returns.ExternalURL, f.RunCall.Returns.InternalURL, f.RunCall.Returns.Err
 }
+func (f *DockerStartPhase) WithDroplet(param1 string) docker.StartPhase {
+	f.WithDropletCall.mutex.Lock()
+	defer f.WithDropletCall.mutex.Unlock()
+	f.WithDropletCall.CallCount++
+	f.WithDropletCall.Receives.Path = param1
+	if f.WithDropletCall.Stub != nil {
+		return f.WithDropletCall.Stub(param1)
+	}
+	return f.WithDropletCall.Returns.StartPhase
+}
 func (f *DockerStartPhase) WithEnv(param1 map[string]string) docker.StartPhase {
 	f.WithEnvCall.mutex.Lock()
 	defer f.WithEnvCall.mutex.Unlock()
@@ -96,6 +128,16 @@ func (f *DockerStartPhase) WithEnv(param1 map[string]string) docker.StartPhase {
 	}
 	return f.WithEnvCall.Returns.StartPhase
 }
+func (f *DockerStartPhase) WithImage(param1 string) docker.StartPhase {
+	f.WithImageCall.mutex.Lock()
+	defer f.WithImageCall.mutex.Unlock()
+	f.WithImageCall.CallCount++
+	f.WithImageCall.Receives.Image = param1
+	if f.WithImageCall.Stub != nil {
+		return f.WithImageCall.Stub(param1)
+	}
+	return f.WithImageCall.Returns.StartPhase
+}
 func (f *DockerStartPhase) WithServices(param1 map[string]map[string]interface {
 }) docker.StartPhase {
 	f.WithServicesCall.mutex.Lock()
@@ -3,6 +3,7 @@ package switchblade_test
 import (
 	"testing"
 
+	"github.com/cloudfoundry/switchblade"
 	"github.com/onsi/gomega/format"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -13,8 +14,26 @@ func TestSwitchblade(t *testing.T) {
 
 	suite := spec.New("switchblade", spec.Report(report.Terminal{}), spec.Parallel())
 	suite("CloudFoundry", testCloudFoundry)
+	suite("Config", testConfig)
 	suite("Docker", testDocker)
+	suite("Env", testEnv, spec.Sequential())
 	suite("RandomName", testRandomName)
 	suite("Source", testSource)
 	suite.Run(t)
 }
+
+// recordingObserver records the phases it observes as "started <phase>" and
+// "finished <phase>", along with the finished events themselves.
+type recordingObserver struct {
+	events   []string
+	finished []switchblade.PhaseEvent
+}
+
+func (o *recordingObserver) PhaseStarted(event switchblade.PhaseEvent) {
+	o.events = append(o.events, "started "+event.Phase)
+}
+
+func (o *recordingObserver) PhaseFinished(event switchblade.PhaseEvent) {
+	o.events = append(o.events, "finished "+event.Phase)
+	o.finished = append(o.finished, event)
+}
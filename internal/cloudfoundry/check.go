@@ -0,0 +1,144 @@
+package cloudfoundry
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+type CheckPhase interface {
+	Run() error
+}
+
+// Check verifies that the cf CLI is set up to deploy applications before
+// anything is deployed, so that problems are reported up front rather than
+// partway through a deployment.
+type Check struct {
+	cli  Executable
+	home string
+}
+
+func NewCheck(cli Executable, home string) Check {
+	return Check{
+		cli:  cli,
+		home: home,
+	}
+}
+
+// Run performs every check and returns an error that describes each problem
+// that was found along with how to resolve it.
+func (c Check) Run() error {
+	// The home is the $CF_HOME/.cf directory that is copied for each
+	// deployment, so the CLI is run against its parent.
+	env := append(os.Environ(), fmt.Sprintf("CF_HOME=%s", filepath.Dir(c.home)))
+
+	logs := bytes.NewBuffer(nil)
+	err := c.cli.Execute(pexec.Execution{
+		Args:   []string{"version"},
+		Stdout: logs,
+		Stderr: logs,
+		Env:    env,
+	})
+	if err != nil {
+		return fmt.Errorf("the cf CLI is not installed or cannot be run: %w; install it from https://github.com/cloudfoundry/cli", err)
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err = c.cli.Execute(pexec.Execution{
+		Args:   []string{"oauth-token"},
+		Stdout: buffer,
+		Stderr: logs,
+		Env:    env,
+	})
+	if err != nil {
+		return fmt.Errorf("the cf CLI is not logged in with the config in %s: %w; run `cf login`", c.home, err)
+	}
+
+	var problems []error
+
+	scopes, err := tokenScopes(buffer.String())
+	if err != nil {
+		problems = append(problems, err)
+	} else if !contains(scopes, "cloud_controller.admin") {
+		problems = append(problems, errors.New("the logged in user is not an admin; create-security-group and update-quota require the cloud_controller.admin scope, so log in as an admin user"))
+	}
+
+	buffer = bytes.NewBuffer(nil)
+	err = c.cli.Execute(pexec.Execution{
+		Args:   []string{"curl", "/routing/v1/router_groups"},
+		Stdout: buffer,
+		Stderr: logs,
+		Env:    env,
+	})
+	if err != nil {
+		problems = append(problems, fmt.Errorf("failed to curl /routing/v1/router_groups: %w\n\nOutput:\n%s", err, logs))
+	} else {
+		var routerGroups []struct {
+			Type string `json:"type"`
+		}
+		err = json.NewDecoder(buffer).Decode(&routerGroups)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("failed to parse router groups: %w", err))
+		} else {
+			var tcp bool
+			for _, group := range routerGroups {
+				if group.Type == "tcp" {
+					tcp = true
+					break
+				}
+			}
+
+			if !tcp {
+				problems = append(problems, errors.New("no TCP router group exists; enable TCP routing on the Cloud Foundry deployment"))
+			}
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// tokenScopes returns the scopes that are granted by the bearer token printed
+// by `cf oauth-token`.
+func tokenScopes(output string) ([]string, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return nil, errors.New("failed to parse oauth token: token is empty")
+	}
+
+	parts := strings.Split(fields[len(fields)-1], ".")
+	if len(parts) != 3 {
+		return nil, errors.New("failed to parse oauth token: token is not a JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse oauth token: %w", err)
+	}
+
+	var claims struct {
+		Scope []string `json:"scope"`
+	}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse oauth token: %w", err)
+	}
+
+	return claims.Scope, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,133 @@
+package cloudfoundry_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry/fakes"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCheck(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Run", func() {
+		var (
+			check cloudfoundry.Check
+
+			executable   *fakes.Executable
+			executions   []pexec.Execution
+			scopes       string
+			routerGroups string
+			failures     map[string]error
+		)
+
+		it.Before(func() {
+			scopes = `["openid", "cloud_controller.admin"]`
+			routerGroups = `[{"name": "default-http", "type": "http"}, {"name": "default-tcp", "type": "tcp"}]`
+			failures = map[string]error{}
+
+			executable = &fakes.Executable{}
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				executions = append(executions, execution)
+
+				command := strings.Join(execution.Args, " ")
+				if err, ok := failures[command]; ok {
+					return err
+				}
+
+				switch command {
+				case "oauth-token":
+					payload := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"scope": %s}`, scopes)))
+					fmt.Fprintf(execution.Stdout, "bearer some-header.%s.some-signature\n", payload)
+				case "curl /routing/v1/router_groups":
+					fmt.Fprintln(execution.Stdout, routerGroups)
+				}
+
+				return nil
+			}
+
+			check = cloudfoundry.NewCheck(executable, "/some/home/.cf")
+		})
+
+		it("checks the cf CLI, login, admin scope, and router groups", func() {
+			err := check.Run()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions).To(HaveLen(3))
+			Expect(executions[0].Args).To(Equal([]string{"version"}))
+			Expect(executions[1].Args).To(Equal([]string{"oauth-token"}))
+			Expect(executions[2].Args).To(Equal([]string{"curl", "/routing/v1/router_groups"}))
+
+			for _, execution := range executions {
+				Expect(execution.Env).To(ContainElement("CF_HOME=/some/home"))
+			}
+		})
+
+		context("failure cases", func() {
+			context("when the cf CLI cannot be run", func() {
+				it.Before(func() {
+					failures["version"] = errors.New("executable file not found in $PATH")
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("the cf CLI is not installed or cannot be run: executable file not found in $PATH; install it from https://github.com/cloudfoundry/cli"))
+				})
+			})
+
+			context("when the cf CLI is not logged in", func() {
+				it.Before(func() {
+					failures["oauth-token"] = errors.New("exit status 1")
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("the cf CLI is not logged in with the config in /some/home/.cf: exit status 1; run `cf login`"))
+				})
+			})
+
+			context("when the user is not an admin", func() {
+				it.Before(func() {
+					scopes = `["openid", "cloud_controller.read"]`
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError(ContainSubstring("the logged in user is not an admin")))
+				})
+			})
+
+			context("when there is no TCP router group", func() {
+				it.Before(func() {
+					routerGroups = `[{"name": "default-http", "type": "http"}]`
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("no TCP router group exists; enable TCP routing on the Cloud Foundry deployment"))
+				})
+			})
+
+			context("when there are several problems", func() {
+				it.Before(func() {
+					scopes = `[]`
+					routerGroups = `[]`
+				})
+
+				it("reports all of them", func() {
+					err := check.Run()
+					Expect(err).To(MatchError(ContainSubstring("the logged in user is not an admin")))
+					Expect(err).To(MatchError(ContainSubstring("no TCP router group exists")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,193 @@
+package cloudfoundry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+type CollectPhase interface {
+	Run(home, name, droplet string) (Artifacts, error)
+	Droplet(home, name string) (Droplet, error)
+}
+
+// Artifacts describe a deployment for debugging after the fact. Each is empty
+// when it could not be collected, such as when the deployment failed before
+// it was produced.
+type Artifacts struct {
+	// App is the output of `cf app`.
+	App []byte
+
+	// Env is the output of `cf env`.
+	Env []byte
+
+	// Droplet is the metadata of the droplet that the app was most recently
+	// staged into, which includes the buildpacks that staged it and its
+	// process types.
+	Droplet []byte
+}
+
+// Droplet describes the droplet that an app was most recently staged into.
+type Droplet struct {
+	GUID         string
+	Stack        string
+	Buildpacks   []DropletBuildpack
+	ProcessTypes map[string]string
+
+	// Metadata is the droplet resource returned by the v3 API.
+	Metadata []byte
+}
+
+// DropletBuildpack is a buildpack that staged a droplet. The Name is the name
+// of the buildpack that was given to stage the app, such as "go_buildpack".
+// The DetectOutput is what its bin/detect printed, which is only recorded
+// when the buildpack was detected rather than given.
+type DropletBuildpack struct {
+	Name         string
+	Version      string
+	DetectOutput string
+}
+
+// Collect gathers the artifacts of a deployment with the cf CLI.
+type Collect struct {
+	cli Executable
+}
+
+func NewCollect(cli Executable) Collect {
+	return Collect{
+		cli: cli,
+	}
+}
+
+// Run collects every artifact that is available, and downloads the droplet to
+// the given path unless it is empty. The returned error describes the
+// artifacts that could not be collected.
+func (c Collect) Run(home, name, droplet string) (Artifacts, error) {
+	env := append(os.Environ(), fmt.Sprintf("CF_HOME=%s", home))
+
+	var (
+		artifacts Artifacts
+		problems  []error
+		err       error
+	)
+
+	artifacts.App, err = c.execute(env, "app", name)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	artifacts.Env, err = c.execute(env, "env", name)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	// The droplet that the app was most recently staged into is collected
+	// rather than its current droplet, which is only set once the app is
+	// started.
+	staged, err := c.Droplet(home, name)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("failed to find droplet: %w", err))
+		return artifacts, errors.Join(problems...)
+	}
+
+	artifacts.Droplet, err = c.execute(env, "curl", fmt.Sprintf("/v3/droplets/%s", staged.GUID))
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	if droplet != "" {
+		_, err = c.execute(env, "download-droplet", name, "--droplet", staged.GUID, "--path", droplet)
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	return artifacts, errors.Join(problems...)
+}
+
+// Droplet returns the droplet that the app was most recently staged into,
+// which is not yet its current droplet when it was staged without starting.
+func (c Collect) Droplet(home, name string) (Droplet, error) {
+	env := append(os.Environ(), fmt.Sprintf("CF_HOME=%s", home))
+
+	guid, err := c.execute(env, "app", name, "--guid")
+	if err != nil {
+		return Droplet{}, err
+	}
+
+	content, err := c.execute(env, "curl", fmt.Sprintf("/v3/apps/%s/droplets?order_by=-created_at&per_page=1", strings.TrimSpace(string(guid))))
+	if err != nil {
+		return Droplet{}, err
+	}
+
+	var droplets struct {
+		Resources []json.RawMessage `json:"resources"`
+	}
+	err = json.Unmarshal(content, &droplets)
+	if err != nil {
+		return Droplet{}, fmt.Errorf("failed to parse droplets: %w", err)
+	}
+
+	if len(droplets.Resources) == 0 {
+		return Droplet{}, fmt.Errorf("app %s has no droplets", name)
+	}
+
+	var resource struct {
+		GUID       string `json:"guid"`
+		Stack      string `json:"stack"`
+		Buildpacks []struct {
+			Name          string `json:"name"`
+			BuildpackName string `json:"buildpack_name"`
+			Version       string `json:"version"`
+			DetectOutput  string `json:"detect_output"`
+		} `json:"buildpacks"`
+		ProcessTypes map[string]string `json:"process_types"`
+	}
+	err = json.Unmarshal(droplets.Resources[0], &resource)
+	if err != nil {
+		return Droplet{}, fmt.Errorf("failed to parse droplet: %w", err)
+	}
+
+	droplet := Droplet{
+		GUID:         resource.GUID,
+		Stack:        resource.Stack,
+		ProcessTypes: resource.ProcessTypes,
+		Metadata:     droplets.Resources[0],
+	}
+
+	for _, buildpack := range resource.Buildpacks {
+		bpName := buildpack.Name
+		if bpName == "" {
+			bpName = buildpack.BuildpackName
+		}
+
+		droplet.Buildpacks = append(droplet.Buildpacks, DropletBuildpack{
+			Name:         bpName,
+			Version:      buildpack.Version,
+			DetectOutput: buildpack.DetectOutput,
+		})
+	}
+
+	return droplet, nil
+}
+
+func (c Collect) execute(env []string, args ...string) ([]byte, error) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	err := c.cli.Execute(pexec.Execution{
+		Args:   args,
+		Stdout: stdout,
+		Stderr: stderr,
+		Env:    env,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run cf %s: %w\n\nOutput:\n%s%s", args[0], err, stdout, stderr)
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,216 @@
+package cloudfoundry_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry/fakes"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCollect(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Run", func() {
+		var (
+			collect cloudfoundry.Collect
+
+			executable *fakes.Executable
+			executions []pexec.Execution
+		)
+
+		it.Before(func() {
+			executions = nil
+
+			executable = &fakes.Executable{}
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				executions = append(executions, execution)
+
+				command := strings.Join(execution.Args, " ")
+				switch {
+				case command == "app some-app --guid":
+					fmt.Fprintln(execution.Stdout, "some-app-guid")
+				case command == "app some-app":
+					fmt.Fprintln(execution.Stdout, "requested state: started")
+				case command == "env some-app":
+					fmt.Fprintln(execution.Stdout, "SOME_KEY: some-value")
+				case command == "curl /v3/apps/some-app-guid/droplets?order_by=-created_at&per_page=1":
+					fmt.Fprintln(execution.Stdout, `{"resources": [{"guid": "some-droplet-guid"}]}`)
+				case command == "curl /v3/droplets/some-droplet-guid":
+					fmt.Fprintln(execution.Stdout, `{"state": "STAGED"}`)
+				}
+
+				return nil
+			}
+
+			collect = cloudfoundry.NewCollect(executable)
+		})
+
+		it("collects the artifacts of the deployment", func() {
+			artifacts, err := collect.Run("/some/home", "some-app", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(artifacts.App)).To(Equal("requested state: started\n"))
+			Expect(string(artifacts.Env)).To(Equal("SOME_KEY: some-value\n"))
+			Expect(string(artifacts.Droplet)).To(Equal("{\"state\": \"STAGED\"}\n"))
+
+			Expect(executions).To(HaveLen(5))
+			for _, execution := range executions {
+				Expect(execution.Env).To(ContainElement("CF_HOME=/some/home"))
+			}
+		})
+
+		context("when a droplet path is given", func() {
+			it("downloads the most recently staged droplet there", func() {
+				_, err := collect.Run("/some/home", "some-app", "/some/droplet.tgz")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(6))
+				Expect(executions[5].Args).To(Equal([]string{"download-droplet", "some-app", "--droplet", "some-droplet-guid", "--path", "/some/droplet.tgz"}))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the app does not exist", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						executions = append(executions, execution)
+
+						if execution.Args[0] == "env" {
+							fmt.Fprintln(execution.Stdout, "SOME_KEY: some-value")
+							return nil
+						}
+
+						fmt.Fprintln(execution.Stderr, "App 'some-app' not found.")
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("returns the artifacts that were collected and an error for the others", func() {
+					artifacts, err := collect.Run("/some/home", "some-app", "/some/droplet.tgz")
+					Expect(err).To(MatchError(ContainSubstring("failed to run cf app: exit status 1\n\nOutput:\nApp 'some-app' not found.")))
+					Expect(err).To(MatchError(ContainSubstring("failed to find droplet: failed to run cf app: exit status 1")))
+
+					Expect(artifacts.App).To(BeEmpty())
+					Expect(string(artifacts.Env)).To(Equal("SOME_KEY: some-value\n"))
+					Expect(artifacts.Droplet).To(BeEmpty())
+				})
+			})
+		})
+	})
+
+	context("Droplet", func() {
+		var (
+			collect cloudfoundry.Collect
+
+			executable *fakes.Executable
+			executions []pexec.Execution
+		)
+
+		it.Before(func() {
+			executions = nil
+
+			executable = &fakes.Executable{}
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				executions = append(executions, execution)
+
+				command := strings.Join(execution.Args, " ")
+				switch {
+				case command == "app some-app --guid":
+					fmt.Fprintln(execution.Stdout, "some-app-guid")
+				case command == "curl /v3/apps/some-app-guid/droplets?order_by=-created_at&per_page=1":
+					fmt.Fprintln(execution.Stdout, `{
+						"resources": [
+							{
+								"guid": "some-droplet-guid",
+								"stack": "some-stack",
+								"buildpacks": [
+									{ "name": "some_buildpack", "buildpack_name": "some-buildpack", "version": "1.2.3", "detect_output": "some-detect-output" },
+									{ "name": "", "buildpack_name": "other-buildpack", "version": "4.5.6" }
+								],
+								"process_types": { "web": "some-command" }
+							}
+						]
+					}`)
+				}
+
+				return nil
+			}
+
+			collect = cloudfoundry.NewCollect(executable)
+		})
+
+		it("returns the most recent droplet of the app", func() {
+			droplet, err := collect.Droplet("/some/home", "some-app")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(droplet.GUID).To(Equal("some-droplet-guid"))
+			Expect(droplet.Stack).To(Equal("some-stack"))
+			Expect(droplet.Buildpacks).To(Equal([]cloudfoundry.DropletBuildpack{
+				{Name: "some_buildpack", Version: "1.2.3", DetectOutput: "some-detect-output"},
+				{Name: "other-buildpack", Version: "4.5.6"},
+			}))
+			Expect(droplet.ProcessTypes).To(Equal(map[string]string{"web": "some-command"}))
+			Expect(string(droplet.Metadata)).To(ContainSubstring(`"guid": "some-droplet-guid"`))
+
+			Expect(executions).To(HaveLen(2))
+			for _, execution := range executions {
+				Expect(execution.Env).To(ContainElement("CF_HOME=/some/home"))
+			}
+		})
+
+		context("failure cases", func() {
+			context("when the guid cannot be fetched", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						fmt.Fprintln(execution.Stderr, "App 'some-app' not found.")
+						return errors.New("exit status 1")
+					}
+				})
+
+				it("returns an error", func() {
+					_, err := collect.Droplet("/some/home", "some-app")
+					Expect(err).To(MatchError(ContainSubstring("failed to run cf app: exit status 1")))
+				})
+			})
+
+			context("when the droplets are malformed", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						if execution.Args[0] == "curl" {
+							fmt.Fprintln(execution.Stdout, "%%%")
+						}
+						return nil
+					}
+				})
+
+				it("returns an error", func() {
+					_, err := collect.Droplet("/some/home", "some-app")
+					Expect(err).To(MatchError(ContainSubstring("failed to parse droplets:")))
+				})
+			})
+
+			context("when the app has no droplets", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						if execution.Args[0] == "curl" {
+							fmt.Fprintln(execution.Stdout, `{"resources": []}`)
+						}
+						return nil
+					}
+				})
+
+				it("returns an error", func() {
+					_, err := collect.Droplet("/some/home", "some-app")
+					Expect(err).To(MatchError("app some-app has no droplets"))
+				})
+			})
+		})
+	})
+}
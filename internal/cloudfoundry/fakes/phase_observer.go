@@ -0,0 +1,43 @@
+package fakes
+
+import "sync"
+
+type PhaseObserver struct {
+	FinishedCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Phase string
+			Err   error
+		}
+		Stub func(string, error)
+	}
+	StartedCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Phase string
+		}
+		Stub func(string)
+	}
+}
+
+func (f *PhaseObserver) Finished(param1 string, param2 error) {
+	f.FinishedCall.mutex.Lock()
+	defer f.FinishedCall.mutex.Unlock()
+	f.FinishedCall.CallCount++
+	f.FinishedCall.Receives.Phase = param1
+	f.FinishedCall.Receives.Err = param2
+	if f.FinishedCall.Stub != nil {
+		f.FinishedCall.Stub(param1, param2)
+	}
+}
+func (f *PhaseObserver) Started(param1 string) {
+	f.StartedCall.mutex.Lock()
+	defer f.StartedCall.mutex.Unlock()
+	f.StartedCall.CallCount++
+	f.StartedCall.Receives.Phase = param1
+	if f.StartedCall.Stub != nil {
+		f.StartedCall.Stub(param1)
+	}
+}
@@ -12,8 +12,11 @@ func TestCloudFoundry(t *testing.T) {
 	format.MaxLength = 0
 
 	suite := spec.New("switchblade/internal/cloudfoundry", spec.Report(report.Terminal{}), spec.Parallel())
+	suite("Check", testCheck)
+	suite("Collect", testCollect)
 	suite("Initialize", testInitialize)
 	suite("Logs", testLogs)
+	suite("Restart", testRestart)
 	suite("Setup", testSetup)
 	suite("Stage", testStage)
 	suite("Teardown", testTeardown)
@@ -0,0 +1,12 @@
+package cloudfoundry
+
+//go:generate faux --interface PhaseObserver --output fakes/phase_observer.go
+type PhaseObserver interface {
+	Started(phase string)
+	Finished(phase string, err error)
+}
+
+type noopObserver struct{}
+
+func (noopObserver) Started(phase string)             {}
+func (noopObserver) Finished(phase string, err error) {}
@@ -0,0 +1,131 @@
+package cloudfoundry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+// Restart sets and unsets the given environment variables of an app using
+// 'cf set-env' and 'cf unset-env', and then restarts it using 'cf restart' so
+// that they take effect.
+func Restart(cli Executable, home, appName string, env map[string]string, unset []string) error {
+	environ := append(os.Environ(), fmt.Sprintf("CF_HOME=%s", home))
+
+	var keys []string
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var commands [][]string
+	for _, key := range keys {
+		commands = append(commands, []string{"set-env", appName, key, env[key]})
+	}
+
+	for _, key := range unset {
+		commands = append(commands, []string{"unset-env", appName, key})
+	}
+
+	commands = append(commands, []string{"restart", appName})
+
+	for _, args := range commands {
+		output, err := execute(cli, environ, args...)
+		if err != nil {
+			return fmt.Errorf("failed to %s: %w\n\nOutput:\n%s", args[0], err, output)
+		}
+	}
+
+	return nil
+}
+
+// BindService binds a user-provided service with the given credentials to an
+// app using 'cf bind-service', and then restages it using 'cf restage' so that
+// its buildpacks see the service too. When restage is false, such as for an
+// app that was pushed as a droplet and so has no package to restage, the app
+// is only restarted using 'cf restart'. The service is named after the app, in
+// the same way as the services that the app was deployed with, so that it is
+// deleted along with them. When the service already exists, its credentials
+// are updated instead.
+func BindService(cli Executable, home, appName, name string, credentials map[string]interface{}, restage bool) error {
+	environ := append(os.Environ(), fmt.Sprintf("CF_HOME=%s", home))
+	service := fmt.Sprintf("%s-%s", appName, name)
+
+	content, err := json.Marshal(credentials)
+	if err != nil {
+		return fmt.Errorf("failed to marshal services json: %w", err)
+	}
+
+	// The cf CLI succeeds without creating a service that already exists.
+	output, err := execute(cli, environ, "create-user-provided-service", service, "-p", string(content))
+	if err != nil {
+		return fmt.Errorf("failed to create-user-provided-service: %w\n\nOutput:\n%s", err, output)
+	}
+
+	if strings.Contains(output, "already exists") {
+		output, err = execute(cli, environ, "update-user-provided-service", service, "-p", string(content))
+		if err != nil {
+			return fmt.Errorf("failed to update-user-provided-service: %w\n\nOutput:\n%s", err, output)
+		}
+	}
+
+	output, err = execute(cli, environ, "bind-service", appName, service)
+	if err != nil {
+		return fmt.Errorf("failed to bind-service: %w\n\nOutput:\n%s", err, output)
+	}
+
+	command := "restart"
+	if restage {
+		command = "restage"
+	}
+
+	output, err = execute(cli, environ, command, appName)
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w\n\nOutput:\n%s", command, err, output)
+	}
+
+	return nil
+}
+
+// UnbindService unbinds a service that was bound with BindService, or that the
+// app was deployed with, from an app using 'cf unbind-service' and deletes it.
+// The app is then restaged or restarted in the same way as BindService.
+func UnbindService(cli Executable, home, appName, name string, restage bool) error {
+	environ := append(os.Environ(), fmt.Sprintf("CF_HOME=%s", home))
+	service := fmt.Sprintf("%s-%s", appName, name)
+
+	command := "restart"
+	if restage {
+		command = "restage"
+	}
+
+	for _, args := range [][]string{
+		{"unbind-service", appName, service},
+		{"delete-service", service, "-f"},
+		{command, appName},
+	} {
+		output, err := execute(cli, environ, args...)
+		if err != nil {
+			return fmt.Errorf("failed to %s: %w\n\nOutput:\n%s", args[0], err, output)
+		}
+	}
+
+	return nil
+}
+
+func execute(cli Executable, env []string, args ...string) (string, error) {
+	buffer := bytes.NewBuffer(nil)
+	err := cli.Execute(pexec.Execution{
+		Args:   args,
+		Stdout: buffer,
+		Stderr: buffer,
+		Env:    env,
+	})
+
+	return buffer.String(), err
+}
@@ -0,0 +1,202 @@
+package cloudfoundry_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
+	"github.com/cloudfoundry/switchblade/internal/cloudfoundry/fakes"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRestart(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		cli        *fakes.Executable
+		executions []pexec.Execution
+	)
+
+	it.Before(func() {
+		executions = nil
+
+		cli = &fakes.Executable{}
+		cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+			executions = append(executions, execution)
+			return nil
+		}
+	})
+
+	context("Restart", func() {
+		it("sets and unsets the env and restarts the app", func() {
+			err := cloudfoundry.Restart(cli, "/tmp/some-home", "some-app", map[string]string{
+				"SOME_KEY":  "some-value",
+				"OTHER_KEY": "other-value",
+			}, []string{"UNSET_KEY"})
+			Expect(err).NotTo(HaveOccurred())
+
+			var args [][]string
+			for _, execution := range executions {
+				args = append(args, execution.Args)
+				Expect(execution.Env).To(ContainElement("CF_HOME=/tmp/some-home"))
+			}
+
+			Expect(args).To(Equal([][]string{
+				{"set-env", "some-app", "OTHER_KEY", "other-value"},
+				{"set-env", "some-app", "SOME_KEY", "some-value"},
+				{"unset-env", "some-app", "UNSET_KEY"},
+				{"restart", "some-app"},
+			}))
+		})
+
+		context("when a command fails", func() {
+			it.Before(func() {
+				cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					if execution.Args[0] == "restart" {
+						fmt.Fprintln(execution.Stdout, "Instances failed to start")
+						return fmt.Errorf("exit status 1")
+					}
+
+					return nil
+				}
+			})
+
+			it("returns an error with its output", func() {
+				err := cloudfoundry.Restart(cli, "/tmp/some-home", "some-app", nil, nil)
+				Expect(err).To(MatchError(ContainSubstring("failed to restart: exit status 1")))
+				Expect(err).To(MatchError(ContainSubstring("Instances failed to start")))
+			})
+		})
+	})
+
+	context("BindService", func() {
+		it("creates and binds the service and restages the app", func() {
+			err := cloudfoundry.BindService(cli, "/tmp/some-home", "some-app", "some-service", map[string]interface{}{
+				"some-key": "some-value",
+			}, true)
+			Expect(err).NotTo(HaveOccurred())
+
+			var args [][]string
+			for _, execution := range executions {
+				args = append(args, execution.Args)
+				Expect(execution.Env).To(ContainElement("CF_HOME=/tmp/some-home"))
+			}
+
+			Expect(args).To(Equal([][]string{
+				{"create-user-provided-service", "some-app-some-service", "-p", `{"some-key":"some-value"}`},
+				{"bind-service", "some-app", "some-app-some-service"},
+				{"restage", "some-app"},
+			}))
+		})
+
+		context("when the app is not restaged", func() {
+			it("restarts it instead", func() {
+				err := cloudfoundry.BindService(cli, "/tmp/some-home", "some-app", "some-service", map[string]interface{}{
+					"some-key": "some-value",
+				}, false)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(3))
+				Expect(executions[2].Args).To(Equal([]string{"restart", "some-app"}))
+			})
+		})
+
+		context("when the service already exists", func() {
+			it.Before(func() {
+				cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+					executions = append(executions, execution)
+					if execution.Args[0] == "create-user-provided-service" {
+						fmt.Fprintln(execution.Stdout, "Service instance some-app-some-service already exists")
+						fmt.Fprintln(execution.Stdout, "OK")
+					}
+
+					return nil
+				}
+			})
+
+			it("updates its credentials", func() {
+				err := cloudfoundry.BindService(cli, "/tmp/some-home", "some-app", "some-service", map[string]interface{}{
+					"some-key": "rotated-value",
+				}, true)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(4))
+				Expect(executions[1].Args).To(Equal([]string{"update-user-provided-service", "some-app-some-service", "-p", `{"some-key":"rotated-value"}`}))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the credentials cannot be marshalled to json", func() {
+				it("returns an error", func() {
+					err := cloudfoundry.BindService(cli, "/tmp/some-home", "some-app", "some-service", map[string]interface{}{
+						"some-key": func() {},
+					}, true)
+					Expect(err).To(MatchError(ContainSubstring("failed to marshal services json")))
+					Expect(executions).To(BeEmpty())
+				})
+			})
+
+			context("when the service cannot be bound", func() {
+				it.Before(func() {
+					cli.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						if execution.Args[0] == "bind-service" {
+							fmt.Fprintln(execution.Stdout, "App some-app not found")
+							return fmt.Errorf("exit status 1")
+						}
+
+						return nil
+					}
+				})
+
+				it("returns an error with its output", func() {
+					err := cloudfoundry.BindService(cli, "/tmp/some-home", "some-app", "some-service", nil, true)
+					Expect(err).To(MatchError(ContainSubstring("failed to bind-service: exit status 1")))
+					Expect(err).To(MatchError(ContainSubstring("App some-app not found")))
+				})
+			})
+		})
+	})
+
+	context("UnbindService", func() {
+		it("unbinds and deletes the service and restages the app", func() {
+			err := cloudfoundry.UnbindService(cli, "/tmp/some-home", "some-app", "some-service", true)
+			Expect(err).NotTo(HaveOccurred())
+
+			var args [][]string
+			for _, execution := range executions {
+				args = append(args, execution.Args)
+			}
+
+			Expect(args).To(Equal([][]string{
+				{"unbind-service", "some-app", "some-app-some-service"},
+				{"delete-service", "some-app-some-service", "-f"},
+				{"restage", "some-app"},
+			}))
+		})
+
+		context("when the app is not restaged", func() {
+			it("restarts it instead", func() {
+				err := cloudfoundry.UnbindService(cli, "/tmp/some-home", "some-app", "some-service", false)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(3))
+				Expect(executions[2].Args).To(Equal([]string{"restart", "some-app"}))
+			})
+		})
+
+		context("when the service cannot be unbound", func() {
+			it.Before(func() {
+				cli.ExecuteCall.Returns.Error = fmt.Errorf("exit status 1")
+				cli.ExecuteCall.Stub = nil
+			})
+
+			it("returns an error", func() {
+				err := cloudfoundry.UnbindService(cli, "/tmp/some-home", "some-app", "some-service", true)
+				Expect(err).To(MatchError(ContainSubstring("failed to unbind-service: exit status 1")))
+			})
+		})
+	})
+}
@@ -26,6 +26,10 @@ type SetupPhase interface {
 	WithServices(services map[string]map[string]interface{}) SetupPhase
 	WithStartCommand(command string) SetupPhase
 	WithHealthCheckType(healthCheckType string) SetupPhase
+	WithObserver(observer PhaseObserver) SetupPhase
+	WithoutRoutes() SetupPhase
+	WithDroplet(path string) SetupPhase
+	WithImage(image string) SetupPhase
 }
 
 type Setup struct {
@@ -40,6 +44,10 @@ type Setup struct {
 	lookupHost      func(string) ([]string, error)
 	startCommand    string
 	healthCheckType string
+	observer        PhaseObserver
+	withoutRoutes   bool
+	droplet         string
+	image           string
 }
 
 func NewSetup(cli Executable, home, stack string) Setup {
@@ -49,6 +57,7 @@ func NewSetup(cli Executable, home, stack string) Setup {
 		internetAccess: true,
 		lookupHost:     net.LookupHost,
 		stack:          stack,
+		observer:       noopObserver{},
 	}
 }
 
@@ -57,6 +66,36 @@ func (s Setup) WithBuildpacks(buildpacks ...string) SetupPhase {
 	return s
 }
 
+// WithObserver reports the time taken by `cf push` to the given observer.
+func (s Setup) WithObserver(observer PhaseObserver) SetupPhase {
+	s.observer = observer
+	return s
+}
+
+// WithoutRoutes pushes the app without any routes, for when it will only be
+// staged. The returned URL is then empty.
+func (s Setup) WithoutRoutes() SetupPhase {
+	s.withoutRoutes = true
+	return s
+}
+
+// WithDroplet pushes the droplet at the given path with `cf push --droplet`
+// instead of the source, so that the app is not staged again when started.
+// The source and buildpacks are then ignored.
+func (s Setup) WithDroplet(path string) SetupPhase {
+	s.droplet = path
+	return s
+}
+
+// WithImage pushes the Docker image with the given reference with `cf push
+// --docker-image` instead of the source, which requires the diego_docker
+// feature flag to be enabled. The source, buildpacks and stack are then
+// ignored.
+func (s Setup) WithImage(image string) SetupPhase {
+	s.image = image
+	return s
+}
+
 func (s Setup) WithStack(stack string) SetupPhase {
 	s.stack = stack
 	return s
@@ -109,6 +148,7 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 	}
 
 	env := append(os.Environ(), fmt.Sprintf("CF_HOME=%s", home))
+
 	buffer := bytes.NewBuffer(nil)
 	err = s.cli.Execute(pexec.Execution{
 		Args:   []string{"curl", "/v3/domains"},
@@ -117,7 +157,7 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 		Env:    env,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to curl /v3/domains: %w\n\nOutput:\n%s", err, log)
+		return "", fmt.Errorf("failed to curl /v3/domains: %w", err)
 	}
 
 	var domains struct {
@@ -156,7 +196,7 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 			Env:    env,
 		})
 		if err != nil {
-			return "", fmt.Errorf("failed to curl /routing/v1/router_groups: %w\n\nOutput:\n%s", err, log)
+			return "", fmt.Errorf("failed to curl /routing/v1/router_groups: %w", err)
 		}
 
 		var routerGroups []struct {
@@ -176,50 +216,29 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 			}
 		}
 
-		err = s.cli.Execute(pexec.Execution{
-			Args:   []string{"create-shared-domain", fmt.Sprintf("tcp.%s", domain), "--router-group", routerGroup},
-			Stdout: log,
-			Stderr: log,
-			Env:    env,
-		})
+		output, err := s.execute(log, env, "create-shared-domain", fmt.Sprintf("tcp.%s", domain), "--router-group", routerGroup)
 		if err != nil {
-			logStr := log.(*bytes.Buffer).String()
-			if strings.Contains(logStr, "already in use") {
+			if strings.Contains(output, "already in use") {
 				fmt.Fprintf(log, "TCP domain already exists, continuing...\n")
 			} else {
-				return "", fmt.Errorf("failed to create-shared-domain: %w\n\nOutput:\n%s", err, log)
+				return "", fmt.Errorf("failed to create-shared-domain: %w", err)
 			}
 		}
 	}
 
-	err = s.cli.Execute(pexec.Execution{
-		Args:   []string{"create-org", name},
-		Stdout: log,
-		Stderr: log,
-		Env:    env,
-	})
+	_, err = s.execute(log, env, "create-org", name)
 	if err != nil {
-		return "", fmt.Errorf("failed to create-org: %w\n\nOutput:\n%s", err, log)
+		return "", fmt.Errorf("failed to create-org: %w", err)
 	}
 
-	err = s.cli.Execute(pexec.Execution{
-		Args:   []string{"create-space", name, "-o", name},
-		Stdout: log,
-		Stderr: log,
-		Env:    env,
-	})
+	_, err = s.execute(log, env, "create-space", name, "-o", name)
 	if err != nil {
-		return "", fmt.Errorf("failed to create-space: %w\n\nOutput:\n%s", err, log)
+		return "", fmt.Errorf("failed to create-space: %w", err)
 	}
 
-	err = s.cli.Execute(pexec.Execution{
-		Args:   []string{"target", "-o", name, "-s", name},
-		Stdout: log,
-		Stderr: log,
-		Env:    env,
-	})
+	_, err = s.execute(log, env, "target", "-o", name, "-s", name)
 	if err != nil {
-		return "", fmt.Errorf("failed to target: %w\n\nOutput:\n%s", err, log)
+		return "", fmt.Errorf("failed to target: %w", err)
 	}
 
 	configFile, err := os.Open(filepath.Join(home, ".cf", "config.json"))
@@ -277,25 +296,15 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 		return "", err
 	}
 
-	err = s.cli.Execute(pexec.Execution{
-		Args:   []string{"create-security-group", name, filepath.Join(home, "security-group.json")},
-		Stdout: log,
-		Stderr: log,
-		Env:    env,
-	})
+	_, err = s.execute(log, env, "create-security-group", name, filepath.Join(home, "security-group.json"))
 	if err != nil {
-		return "", fmt.Errorf("failed to create-security-group: %w\n\nOutput:\n%s", err, log)
+		return "", fmt.Errorf("failed to create-security-group: %w", err)
 	}
 
 	for _, phase := range []string{"staging", "running"} {
-		err = s.cli.Execute(pexec.Execution{
-			Args:   []string{"bind-security-group", name, name, "--space", name, "--lifecycle", phase},
-			Stdout: log,
-			Stderr: log,
-			Env:    env,
-		})
+		_, err = s.execute(log, env, "bind-security-group", name, name, "--space", name, "--lifecycle", phase)
 		if err != nil {
-			return "", fmt.Errorf("failed to bind-security-group: %w\n\nOutput:\n%s", err, log)
+			return "", fmt.Errorf("failed to bind-security-group: %w", err)
 		}
 	}
 
@@ -307,7 +316,7 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 		Env:    env,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to curl /v3/security_groups: %w\n\nOutput:\n%s", err, log)
+		return "", fmt.Errorf("failed to curl /v3/security_groups: %w", err)
 	}
 
 	var securityGroups struct {
@@ -322,14 +331,9 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 
 	for _, securityGroup := range securityGroups.Resources {
 		if !strings.HasPrefix(securityGroup.Name, "switchblade") {
-			err = s.cli.Execute(pexec.Execution{
-				Args:   []string{"update-security-group", securityGroup.Name, filepath.Join(home, "empty-security-group.json")},
-				Stdout: log,
-				Stderr: log,
-				Env:    env,
-			})
+			_, err = s.execute(log, env, "update-security-group", securityGroup.Name, filepath.Join(home, "empty-security-group.json"))
 			if err != nil {
-				return "", fmt.Errorf("failed to update-security-group: %w\n\nOutput:\n%s", err, log)
+				return "", fmt.Errorf("failed to update-security-group: %w", err)
 			}
 		}
 	}
@@ -339,48 +343,120 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 		args = append(args, "-b", buildpack)
 	}
 
+	if s.droplet != "" {
+		args = []string{"push", name, "--droplet", s.droplet, "--no-start", "-s", s.stack}
+	}
+
+	if s.image != "" {
+		output, err := s.execute(log, env, "feature-flag", "diego_docker")
+		if err != nil {
+			return "", fmt.Errorf("failed to get feature-flag: %w", err)
+		}
+
+		if !featureFlagEnabled(output, "diego_docker") {
+			return "", fmt.Errorf("failed to push docker image: the diego_docker feature flag is disabled, enable it with `cf enable-feature-flag diego_docker`")
+		}
+
+		args = []string{"push", name, "--docker-image", s.image, "--no-start"}
+	}
+
 	if s.startCommand != "" {
 		args = append(args, "-c", s.startCommand)
 	}
 
-	_, err = os.Stat(filepath.Join(source, "manifest.yml"))
-	if err == nil {
-		args = append(args, "-f", filepath.Join(source, "manifest.yml"))
+	// A droplet or an image has no source, so a manifest.yml is never pushed
+	// alongside it.
+	if s.image == "" && s.droplet == "" {
+		_, err = os.Stat(filepath.Join(source, "manifest.yml"))
+		if err == nil {
+			args = append(args, "-f", filepath.Join(source, "manifest.yml"))
+		}
 	}
 
-	err = s.cli.Execute(pexec.Execution{
-		Args:   args,
-		Stdout: log,
-		Stderr: log,
-		Env:    env,
-	})
+	if s.withoutRoutes {
+		args = append(args, "--no-route")
+	}
+
+	s.observer.Started("push")
+	_, err = s.execute(log, env, args...)
+	s.observer.Finished("push", err)
 	if err != nil {
-		return "", fmt.Errorf("failed to push: %w\n\nOutput:\n%s", err, log)
+		return "", fmt.Errorf("failed to push: %w", err)
 	}
 
-	err = s.cli.Execute(pexec.Execution{
-		Args:   []string{"update-quota", "default", "--reserved-route-ports", "100"},
-		Stdout: log,
-		Stderr: log,
-		Env:    env,
-	})
+	var url string
+	if !s.withoutRoutes {
+		port, err := s.mapRoute(log, env, name, domain)
+		if err != nil {
+			return "", err
+		}
+
+		url = fmt.Sprintf("http://tcp.%s:%d", domain, port)
+	}
+
+	var envKeys []string
+	for key := range s.env {
+		envKeys = append(envKeys, key)
+	}
+	sort.Strings(envKeys)
+
+	for _, key := range envKeys {
+		_, err = s.execute(log, env, "set-env", name, key, s.env[key])
+		if err != nil {
+			return "", fmt.Errorf("failed to set-env: %w", err)
+		}
+	}
+
+	if s.healthCheckType != "" {
+		_, err = s.execute(log, env, "set-health-check", name, s.healthCheckType)
+		if err != nil {
+			return "", fmt.Errorf("failed to set-health-check: %w", err)
+		}
+	}
+
+	var serviceKeys []string
+	for key := range s.services {
+		serviceKeys = append(serviceKeys, key)
+	}
+	sort.Strings(serviceKeys)
+
+	for _, key := range serviceKeys {
+		content, err := json.Marshal(s.services[key])
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal services json: %w", err)
+		}
+
+		service := fmt.Sprintf("%s-%s", name, key)
+		_, err = s.execute(log, env, "create-user-provided-service", service, "-p", string(content))
+		if err != nil {
+			return "", fmt.Errorf("failed to create-user-provided-service: %w", err)
+		}
+
+		_, err = s.execute(log, env, "bind-service", name, service)
+		if err != nil {
+			return "", fmt.Errorf("failed to bind-service: %w", err)
+		}
+	}
+
+	return url, nil
+}
+
+// mapRoute maps a TCP route to the app, and returns the port of the TCP route
+// that the app is reachable on from within the platform.
+func (s Setup) mapRoute(log io.Writer, env []string, name, domain string) (int, error) {
+	_, err := s.execute(log, env, "update-quota", "default", "--reserved-route-ports", "100")
 	if err != nil {
 		fmt.Fprintf(log, "WARNING: failed to update-quota for TCP routes: %v\n", err)
 		fmt.Fprintf(log, "Continuing without TCP route - HTTP routes will still be available\n")
 	} else {
-		err = s.cli.Execute(pexec.Execution{
-			Args:   []string{"map-route", name, fmt.Sprintf("tcp.%s", domain)},
-			Stdout: log,
-			Stderr: log,
-			Env:    env,
-		})
+		_, err = s.execute(log, env, "map-route", name, fmt.Sprintf("tcp.%s", domain))
 		if err != nil {
 			fmt.Fprintf(log, "WARNING: failed to map TCP route: %v\n", err)
 			fmt.Fprintf(log, "Continuing without TCP route - HTTP routes will still be available\n")
 		}
 	}
 
-	buffer = bytes.NewBuffer(nil)
+	buffer := bytes.NewBuffer(nil)
 	err = s.cli.Execute(pexec.Execution{
 		Args:   []string{"curl", "/v3/spaces"},
 		Stdout: io.MultiWriter(log, buffer),
@@ -388,7 +464,7 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 		Env:    env,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to curl /v3/spaces: %w\n\nOutput:\n%s", err, log)
+		return 0, fmt.Errorf("failed to curl /v3/spaces: %w", err)
 	}
 
 	var spaces struct {
@@ -399,7 +475,7 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 	}
 	err = json.NewDecoder(buffer).Decode(&spaces)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse spaces: %w\n\nOutput:\n%s", err, log)
+		return 0, fmt.Errorf("failed to parse spaces: %w", err)
 	}
 
 	var spaceGUID string
@@ -418,7 +494,7 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 		Env:    env,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to curl /v3/routes: %w\n\nOutput:\n%s", err, log)
+		return 0, fmt.Errorf("failed to curl /v3/routes: %w", err)
 	}
 
 	var routes struct {
@@ -429,7 +505,7 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 	}
 	err = json.NewDecoder(buffer).Decode(&routes)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse routes: %w\n\nOutput:\n%s", err, log)
+		return 0, fmt.Errorf("failed to parse routes: %w", err)
 	}
 
 	var port int
@@ -440,71 +516,34 @@ func (s Setup) Run(log io.Writer, home, name, source string) (string, error) {
 		}
 	}
 
-	var envKeys []string
-	for key := range s.env {
-		envKeys = append(envKeys, key)
-	}
-	sort.Strings(envKeys)
-
-	for _, key := range envKeys {
-		err = s.cli.Execute(pexec.Execution{
-			Args:   []string{"set-env", name, key, s.env[key]},
-			Stdout: log,
-			Stderr: log,
-			Env:    env,
-		})
-		if err != nil {
-			return "", fmt.Errorf("failed to set-env: %w\n\nOutput:\n%s", err, log)
-		}
-	}
+	return port, nil
+}
 
-	if s.healthCheckType != "" {
-		err = s.cli.Execute(pexec.Execution{
-			Args:   []string{"set-health-check", name, s.healthCheckType},
-			Stdout: log,
-			Stderr: log,
-			Env:    env,
-		})
-		if err != nil {
-			return "", fmt.Errorf("failed to set-health-check: %w\n\nOutput:\n%s", err, log)
+// featureFlagEnabled reports whether the output of `cf feature-flag` shows the
+// given feature flag as enabled.
+func featureFlagEnabled(output, flag string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == flag {
+			return fields[1] == "enabled"
 		}
 	}
 
-	var serviceKeys []string
-	for key := range s.services {
-		serviceKeys = append(serviceKeys, key)
-	}
-	sort.Strings(serviceKeys)
-
-	for _, key := range serviceKeys {
-		content, err := json.Marshal(s.services[key])
-		if err != nil {
-			return "", fmt.Errorf("failed to marshal services json: %w", err)
-		}
-
-		service := fmt.Sprintf("%s-%s", name, key)
-		err = s.cli.Execute(pexec.Execution{
-			Args:   []string{"create-user-provided-service", service, "-p", string(content)},
-			Stdout: log,
-			Stderr: log,
-			Env:    env,
-		})
-		if err != nil {
-			return "", fmt.Errorf("failed to create-user-provided-service: %w\n\nOutput:\n%s", err, log)
-		}
+	return false
+}
 
-		err = s.cli.Execute(pexec.Execution{
-			Args:   []string{"bind-service", name, service},
-			Stdout: log,
-			Stderr: log,
-			Env:    env,
-		})
-		if err != nil {
-			return "", fmt.Errorf("failed to bind-service: %w\n\nOutput:\n%s", err, log)
-		}
-	}
+// execute runs the cf CLI, writing its output to the log, and returns the
+// output of the command alone.
+func (s Setup) execute(log io.Writer, env []string, args ...string) (string, error) {
+	output := bytes.NewBuffer(nil)
+	err := s.cli.Execute(pexec.Execution{
+		Args:   args,
+		Stdout: io.MultiWriter(log, output),
+		Stderr: io.MultiWriter(log, output),
+		Env:    env,
+	})
 
-	return fmt.Sprintf("http://tcp.%s:%d", domain, port), nil
+	return output.String(), err
 }
 
 type SecurityGroupRule struct {
@@ -29,10 +29,13 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 			executable      *fakes.Executable
 			workspace, home string
 
-			executions []pexec.Execution
+			executions         []pexec.Execution
+			diegoDockerEnabled bool
 		)
 
 		it.Before(func() {
+			diegoDockerEnabled = true
+
 			executable = &fakes.Executable{}
 			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
 				executions = append(executions, execution)
@@ -95,6 +98,12 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 					fmt.Fprintln(execution.Stdout, "Updating quota...")
 				case strings.HasPrefix(command, "map-route"):
 					fmt.Fprintln(execution.Stdout, "Mapping route...")
+				case strings.HasPrefix(command, "feature-flag diego_docker"):
+					state := "disabled"
+					if diegoDockerEnabled {
+						state = "enabled"
+					}
+					fmt.Fprintf(execution.Stdout, "Getting feature flag diego_docker as admin...\n\nFeatures       State\ndiego_docker   %s\n", state)
 				}
 
 				return nil
@@ -301,6 +310,91 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the setup is observed", func() {
+			it("reports the push to the observer", func() {
+				observer := &fakes.PhaseObserver{}
+
+				_, err := setup.
+					WithObserver(observer).
+					Run(bytes.NewBuffer(nil), filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(observer.StartedCall.Receives.Phase).To(Equal("push"))
+				Expect(observer.FinishedCall.Receives.Phase).To(Equal("push"))
+				Expect(observer.FinishedCall.Receives.Err).NotTo(HaveOccurred())
+			})
+		})
+
+		context("when the app is pushed from a droplet", func() {
+			it("pushes the droplet instead of the source", func() {
+				_, err := setup.
+					WithBuildpacks("some-buildpack").
+					WithDroplet("/some/droplet.tgz").
+					Run(bytes.NewBuffer(nil), filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(16))
+				Expect(executions[11]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"push", "some-app", "--droplet", "/some/droplet.tgz", "--no-start", "-s", "default-stack"}),
+					"Env":  ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-home"))),
+				}))
+			})
+		})
+
+		context("when the app is pushed from a docker image", func() {
+			it("pushes the image instead of the source", func() {
+				_, err := setup.
+					WithBuildpacks("some-buildpack").
+					WithStartCommand("some-command").
+					WithImage("some-org/some-image:some-tag").
+					Run(bytes.NewBuffer(nil), filepath.Join(workspace, "some-home"), "some-app", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(17))
+				Expect(executions[11]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"feature-flag", "diego_docker"}),
+					"Env":  ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-home"))),
+				}))
+				Expect(executions[12]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"push", "some-app", "--docker-image", "some-org/some-image:some-tag", "--no-start", "-c", "some-command"}),
+					"Env":  ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-home"))),
+				}))
+			})
+
+			context("when the diego_docker feature flag is disabled", func() {
+				it.Before(func() {
+					diegoDockerEnabled = false
+				})
+
+				it("returns an error without pushing", func() {
+					_, err := setup.
+						WithImage("some-org/some-image:some-tag").
+						Run(bytes.NewBuffer(nil), filepath.Join(workspace, "some-home"), "some-app", "")
+					Expect(err).To(MatchError("failed to push docker image: the diego_docker feature flag is disabled, enable it with `cf enable-feature-flag diego_docker`"))
+
+					for _, execution := range executions {
+						Expect(execution.Args[0]).NotTo(Equal("push"))
+					}
+				})
+			})
+		})
+
+		context("when the app is pushed without routes", func() {
+			it("pushes the app without mapping a route", func() {
+				url, err := setup.
+					WithoutRoutes().
+					Run(bytes.NewBuffer(nil), filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(url).To(BeEmpty())
+
+				Expect(executions).To(HaveLen(12))
+				Expect(executions[11]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"push", "some-app", "-p", "/some/path/to/my/app", "--no-start", "-s", "default-stack", "--no-route"}),
+					"Env":  ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-home"))),
+				}))
+			})
+		})
+
 		context("when the app has environment variables", func() {
 			it("pushes the app with those environment variables", func() {
 				logs := bytes.NewBuffer(nil)
@@ -590,6 +684,27 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 					"Env": ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-home"))),
 				}))
 			})
+
+			context("when a droplet is given", func() {
+				it("does not pass the manifest to the push cmd", func() {
+					logs := bytes.NewBuffer(nil)
+
+					_, err := setup.
+						WithDroplet("/some/droplet.tgz").
+						Run(logs, filepath.Join(workspace, "some-home"), "some-app", tmpappdir)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(executions).To(HaveLen(16))
+					Expect(executions[11]).To(MatchFields(IgnoreExtras, Fields{
+						"Args": Equal([]string{
+							"push", "some-app",
+							"--droplet", "/some/droplet.tgz",
+							"--no-start",
+							"-s", "default-stack",
+						}),
+					}))
+				})
+			})
 		})
 
 		context("failure cases", func() {
@@ -643,7 +758,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to curl /v3/domains: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring(`{"error": "could not list domains"}`)))
+					Expect(err).NotTo(MatchError(ContainSubstring(`{"error": "could not list domains"}`)))
 
 					Expect(logs).To(ContainSubstring(`{"error": "could not list domains"}`))
 				})
@@ -695,7 +810,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to curl /routing/v1/router_groups: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring(`{"error": "could not list router groups"}`)))
+					Expect(err).NotTo(MatchError(ContainSubstring(`{"error": "could not list router groups"}`)))
 
 					Expect(logs).To(ContainSubstring(`{"error": "could not list router groups"}`))
 				})
@@ -760,7 +875,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to create-shared-domain: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("Shared domain failed to create")))
+					Expect(err).NotTo(MatchError(ContainSubstring("Shared domain failed to create")))
 
 					Expect(logs).To(ContainSubstring("Shared domain failed to create"))
 				})
@@ -797,9 +912,11 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to create-org: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("Org failed to create")))
+					Expect(err).NotTo(MatchError(ContainSubstring("Org failed to create")))
+					Expect(err).NotTo(MatchError(ContainSubstring("some-router-group")))
 
 					Expect(logs).To(ContainSubstring("Org failed to create"))
+					Expect(logs).To(ContainSubstring("some-router-group"))
 				})
 			})
 
@@ -834,7 +951,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to create-space: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("Space failed to create")))
+					Expect(err).NotTo(MatchError(ContainSubstring("Space failed to create")))
 
 					Expect(logs).To(ContainSubstring("Space failed to create"))
 				})
@@ -871,7 +988,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to target: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("Target failed")))
+					Expect(err).NotTo(MatchError(ContainSubstring("Target failed")))
 
 					Expect(logs).To(ContainSubstring("Target failed"))
 				})
@@ -953,7 +1070,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to create-security-group: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("Security group failed to create")))
+					Expect(err).NotTo(MatchError(ContainSubstring("Security group failed to create")))
 
 					Expect(logs).To(ContainSubstring("Security group failed to create"))
 				})
@@ -990,7 +1107,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to bind-security-group: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("Security group failed to bind")))
+					Expect(err).NotTo(MatchError(ContainSubstring("Security group failed to bind")))
 
 					Expect(logs).To(ContainSubstring("Security group failed to bind"))
 				})
@@ -1027,7 +1144,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to curl /v3/security_groups: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring(`{"error": "could not list security groups"}`)))
+					Expect(err).NotTo(MatchError(ContainSubstring(`{"error": "could not list security groups"}`)))
 
 					Expect(logs).To(ContainSubstring(`{"error": "could not list security groups"}`))
 				})
@@ -1103,7 +1220,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to update-security-group: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("Security group failed to update")))
+					Expect(err).NotTo(MatchError(ContainSubstring("Security group failed to update")))
 
 					Expect(logs).To(ContainSubstring("Security group failed to update"))
 				})
@@ -1145,7 +1262,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to push: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("App failed to create")))
+					Expect(err).NotTo(MatchError(ContainSubstring("App failed to create")))
 
 					Expect(logs).To(ContainSubstring("App failed to create"))
 				})
@@ -1187,7 +1304,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to curl /v3/spaces: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring(`{"error": "could not list spaces"}`)))
+					Expect(err).NotTo(MatchError(ContainSubstring(`{"error": "could not list spaces"}`)))
 
 					Expect(logs).To(ContainSubstring(`{"error": "could not list spaces"}`))
 				})
@@ -1275,7 +1392,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 					_, err := setup.Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to curl /v3/routes: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring(`{"error": "could not list routes"}`)))
+					Expect(err).NotTo(MatchError(ContainSubstring(`{"error": "could not list routes"}`)))
 
 					Expect(logs).To(ContainSubstring(`{"error": "could not list routes"}`))
 				})
@@ -1377,7 +1494,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 						WithEnv(map[string]string{"SOME_VARIABLE": "some-value"}).
 						Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to set-env: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("App failed to set environment")))
+					Expect(err).NotTo(MatchError(ContainSubstring("App failed to set environment")))
 
 					Expect(logs).To(ContainSubstring("App failed to set environment"))
 				})
@@ -1453,7 +1570,9 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 						}).
 						Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to create-user-provided-service: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("could not create user-provided service")))
+					Expect(err).NotTo(MatchError(ContainSubstring("could not create user-provided service")))
+
+					Expect(logs).To(ContainSubstring("could not create user-provided service"))
 				})
 			})
 
@@ -1511,7 +1630,9 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 						}).
 						Run(logs, filepath.Join(workspace, "some-home"), "some-app", "/some/path/to/my/app")
 					Expect(err).To(MatchError(ContainSubstring("failed to bind-service: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("could not bind service")))
+					Expect(err).NotTo(MatchError(ContainSubstring("could not bind service")))
+
+					Expect(logs).To(ContainSubstring("could not bind service"))
 				})
 			})
 		})
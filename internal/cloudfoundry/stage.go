@@ -13,10 +13,28 @@ import (
 
 type StagePhase interface {
 	Run(logs io.Writer, home, name string) (url string, err error)
+	WithoutStart() StagePhase
+}
+
+// StartError is returned when `cf start` fails, which happens both when the
+// application fails to stage and when it fails to start, or when `cf stage`
+// fails without starting. The output of the command is written to the logs
+// rather than included in the error.
+type StartError struct {
+	Err error
+}
+
+func (e StartError) Error() string {
+	return fmt.Sprintf("failed to start: %s", e.Err)
+}
+
+func (e StartError) Unwrap() error {
+	return e.Err
 }
 
 type Stage struct {
-	cli Executable
+	cli          Executable
+	withoutStart bool
 }
 
 func NewStage(cli Executable) Stage {
@@ -25,11 +43,23 @@ func NewStage(cli Executable) Stage {
 	}
 }
 
+// WithoutStart stages the app into a droplet with `cf stage` without starting
+// it. The returned URL is then empty.
+func (s Stage) WithoutStart() StagePhase {
+	s.withoutStart = true
+	return s
+}
+
 func (s Stage) Run(logs io.Writer, home, name string) (string, error) {
 	env := append(os.Environ(), fmt.Sprintf("CF_HOME=%s", home))
 
+	command := "start"
+	if s.withoutStart {
+		command = "stage"
+	}
+
 	err := s.cli.Execute(pexec.Execution{
-		Args:   []string{"start", name},
+		Args:   []string{command, name},
 		Stdout: logs,
 		Stderr: logs,
 		Env:    env,
@@ -44,7 +74,11 @@ func (s Stage) Run(logs io.Writer, home, name string) (string, error) {
 			_, _ = logs.Write([]byte(recentLogs))
 		}
 
-		return "", fmt.Errorf("failed to start: %w\n\nOutput:\n%s", err, logs)
+		return "", StartError{Err: err}
+	}
+
+	if s.withoutStart {
+		return "", nil
 	}
 
 	buffer := bytes.NewBuffer(nil)
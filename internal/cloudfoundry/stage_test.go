@@ -92,6 +92,24 @@ func testStage(t *testing.T, context spec.G, it spec.S) {
 			Expect(logs).To(ContainLines("Starting app..."))
 		})
 
+		context("when the app is staged without starting", func() {
+			it("stages the app into a droplet", func() {
+				logs := bytes.NewBuffer(nil)
+
+				url, err := stage.
+					WithoutStart().
+					Run(logs, filepath.Join(workspace, "some-home"), "some-app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(url).To(BeEmpty())
+
+				Expect(executions).To(HaveLen(1))
+				Expect(executions[0]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"stage", "some-app"}),
+					"Env":  ContainElement(fmt.Sprintf("CF_HOME=%s", filepath.Join(workspace, "some-home"))),
+				}))
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the app cannot be started", func() {
 				it.Before(func() {
@@ -108,8 +126,8 @@ func testStage(t *testing.T, context spec.G, it spec.S) {
 					logs := bytes.NewBuffer(nil)
 
 					_, err := stage.Run(logs, filepath.Join(workspace, "some-home"), "some-app")
-					Expect(err).To(MatchError(ContainSubstring("failed to start: exit status 1")))
-					Expect(err).To(MatchError(ContainSubstring("App failed to start")))
+					Expect(err).To(MatchError("failed to start: exit status 1"))
+					Expect(err).To(BeAssignableToTypeOf(cloudfoundry.StartError{}))
 
 					Expect(logs).To(ContainSubstring("App failed to start"))
 				})
@@ -0,0 +1,82 @@
+package docker
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const DefaultArchitecture = "amd64"
+
+//go:generate faux --interface ArchitectureClient --output fakes/architecture_client.go
+type ArchitectureClient interface {
+	ServerVersion(ctx context.Context) (types.Version, error)
+}
+
+// ArchitectureResolver resolves the CPU architecture of the Docker daemon the
+// first time that it is needed rather than when a platform is created, so
+// that creating one does not require a reachable daemon. Copies of a resolver
+// share the architecture that it resolved.
+type ArchitectureResolver struct {
+	client ArchitectureClient
+	once   *sync.Once
+	arch   *string
+}
+
+func NewArchitectureResolver(client ArchitectureClient) ArchitectureResolver {
+	return ArchitectureResolver{
+		client: client,
+		once:   &sync.Once{},
+		arch:   new(string),
+	}
+}
+
+// Resolve returns the given architecture when it is set, and otherwise the
+// architecture of the Docker daemon, or that of the host when the daemon
+// cannot be reached. A resolver without a client resolves to
+// DefaultArchitecture.
+func (r ArchitectureResolver) Resolve(ctx context.Context, arch string) string {
+	if arch != "" {
+		return arch
+	}
+
+	if r.client == nil {
+		return DefaultArchitecture
+	}
+
+	r.once.Do(func() {
+		arch := runtime.GOARCH
+		version, err := r.client.ServerVersion(ctx)
+		if err == nil && version.Arch != "" {
+			arch = version.Arch
+		}
+
+		*r.arch = NormalizeArchitecture(arch)
+	})
+
+	return *r.arch
+}
+
+// NormalizeArchitecture maps common aliases of CPU architectures, such as
+// those reported by uname, onto the names used by Go and OCI image indexes.
+func NormalizeArchitecture(arch string) string {
+	switch strings.ToLower(arch) {
+	case "x86_64", "x86-64", "amd64":
+		return "amd64"
+	case "aarch64", "arm64":
+		return "arm64"
+	default:
+		return strings.ToLower(arch)
+	}
+}
+
+func platformFor(arch string) *specs.Platform {
+	return &specs.Platform{
+		OS:           "linux",
+		Architecture: arch,
+	}
+}
@@ -0,0 +1,82 @@
+package docker_test
+
+import (
+	gocontext "context"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/docker/docker/api/types"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testArchitecture(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("NormalizeArchitecture", func() {
+		it("maps common aliases onto Go architecture names", func() {
+			Expect(docker.NormalizeArchitecture("x86_64")).To(Equal("amd64"))
+			Expect(docker.NormalizeArchitecture("amd64")).To(Equal("amd64"))
+			Expect(docker.NormalizeArchitecture("aarch64")).To(Equal("arm64"))
+			Expect(docker.NormalizeArchitecture("ARM64")).To(Equal("arm64"))
+			Expect(docker.NormalizeArchitecture("s390x")).To(Equal("s390x"))
+		})
+	})
+
+	context("ArchitectureResolver", func() {
+		var (
+			client   *fakes.ArchitectureClient
+			resolver docker.ArchitectureResolver
+		)
+
+		it.Before(func() {
+			client = &fakes.ArchitectureClient{}
+			client.ServerVersionCall.Returns.Version = types.Version{Arch: "aarch64"}
+
+			resolver = docker.NewArchitectureResolver(client)
+		})
+
+		it("asks the daemon for its architecture once", func() {
+			Expect(client.ServerVersionCall.CallCount).To(Equal(0))
+
+			Expect(resolver.Resolve(gocontext.Background(), "")).To(Equal("arm64"))
+			Expect(resolver.Resolve(gocontext.Background(), "")).To(Equal("arm64"))
+			Expect(client.ServerVersionCall.CallCount).To(Equal(1))
+		})
+
+		it("shares the resolved architecture between copies", func() {
+			other := resolver
+
+			Expect(resolver.Resolve(gocontext.Background(), "")).To(Equal("arm64"))
+			Expect(other.Resolve(gocontext.Background(), "")).To(Equal("arm64"))
+			Expect(client.ServerVersionCall.CallCount).To(Equal(1))
+		})
+
+		context("when an architecture is given", func() {
+			it("uses it without asking the daemon", func() {
+				Expect(resolver.Resolve(gocontext.Background(), "s390x")).To(Equal("s390x"))
+				Expect(client.ServerVersionCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("when the daemon cannot be reached", func() {
+			it.Before(func() {
+				client.ServerVersionCall.Returns.Error = errors.New("cannot connect to the Docker daemon")
+			})
+
+			it("uses the architecture of the host", func() {
+				Expect(resolver.Resolve(gocontext.Background(), "")).To(Equal(runtime.GOARCH))
+			})
+		})
+
+		context("when the resolver has no client", func() {
+			it("uses the default architecture", func() {
+				Expect(docker.ArchitectureResolver{}.Resolve(gocontext.Background(), "")).To(Equal(docker.DefaultArchitecture))
+			})
+		})
+	})
+}
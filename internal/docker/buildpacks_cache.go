@@ -2,6 +2,7 @@ package docker
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,16 +15,26 @@ import (
 type BuildpacksCache struct {
 	workspace string
 	index     *sync.Map
+	client    *http.Client
 }
 
 func NewBuildpacksCache(workspace string) BuildpacksCache {
 	return BuildpacksCache{
 		workspace: workspace,
 		index:     &sync.Map{},
+		client:    http.DefaultClient,
 	}
 }
 
-func (c BuildpacksCache) Fetch(uri string) (io.ReadCloser, error) {
+func (c BuildpacksCache) WithHTTPClient(client *http.Client) BuildpacksCache {
+	c.client = client
+	return c
+}
+
+// Fetch returns the buildpack at the given uri, downloading it into the cache
+// if it is not already there. When a checksum is given, it must match the
+// SHA256 of the buildpack, whether it is downloaded or a local file.
+func (c BuildpacksCache) Fetch(uri, checksum string) (io.ReadCloser, error) {
 	err := os.MkdirAll(c.workspace, os.ModePerm)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create workspace: %w", err)
@@ -40,10 +51,25 @@ func (c BuildpacksCache) Fetch(uri string) (io.ReadCloser, error) {
 			return nil, fmt.Errorf("failed to open buildpack: %w", err)
 		}
 
+		if checksum == "" {
+			return file, nil
+		}
+
+		sum, err := checksumFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		if sum != checksum {
+			file.Close()
+			return nil, fmt.Errorf("failed to verify buildpack: %s has SHA256 %s, expected %s", uri, sum, checksum)
+		}
+
 		return file, nil
 	}
 
-	path := filepath.Join(c.workspace, fmt.Sprintf("%x", sha256.Sum256([]byte(uri))))
+	path := cachePath(c.workspace, uri)
 
 	value, _ := c.index.LoadOrStore(path, &sync.Mutex{})
 	mutex := value.(*sync.Mutex)
@@ -51,6 +77,14 @@ func (c BuildpacksCache) Fetch(uri string) (io.ReadCloser, error) {
 	mutex.Lock()
 	defer mutex.Unlock()
 
+	// Other processes may be sharing the cache, so downloads of the same
+	// buildpack are also serialized through a lock file that sits next to it.
+	unlock, err := lockPath(fmt.Sprintf("%s.lock", path))
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
 	_, err = os.Stat(path)
 	if err == nil {
 		file, err := os.Open(path)
@@ -58,29 +92,99 @@ func (c BuildpacksCache) Fetch(uri string) (io.ReadCloser, error) {
 			return nil, fmt.Errorf("failed to open buildpack: %w", err)
 		}
 
-		return file, nil
+		if checksum == "" {
+			return file, nil
+		}
+
+		sum, err := checksumFile(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		if sum == checksum {
+			return file, nil
+		}
+
+		// The cached buildpack does not match the expected checksum, so it is
+		// downloaded again.
+		file.Close()
 	}
 
-	resp, err := http.Get(uri)
+	resp, err := c.client.Get(uri)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download buildpack: %w", err)
 	}
 	defer resp.Body.Close()
 
-	file, err := os.Create(path)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("failed to download buildpack: received unexpected response status %q from %s", resp.Status, uri)
+	}
+
+	// The buildpack is downloaded next to its final location and only moved
+	// into place once it is complete, so that an interrupted download is never
+	// mistaken for a cached buildpack.
+	file, err := os.CreateTemp(c.workspace, fmt.Sprintf("%s.*.download", filepath.Base(path)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create buildpack file: %w", err)
 	}
+	defer os.Remove(file.Name())
 
-	_, err = io.Copy(file, resp.Body)
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hash), resp.Body)
 	if err != nil {
+		file.Close()
 		return nil, fmt.Errorf("failed to copy buildpack file: %w", err)
 	}
 
+	err = file.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to close buildpack file: %w", err)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if checksum != "" && sum != checksum {
+		return nil, fmt.Errorf("failed to verify buildpack: %s has SHA256 %s, expected %s", uri, sum, checksum)
+	}
+
+	err = os.Rename(file.Name(), path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move buildpack into cache: %w", err)
+	}
+
+	cached, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open buildpack: %w", err)
+	}
+
+	return cached, nil
+}
+
+// checksumFile returns the SHA256 of the given file and rewinds it so that it
+// can be read again.
+func checksumFile(file *os.File) (string, error) {
+	hash := sha256.New()
+	_, err := io.Copy(hash, file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read buildpack: %w", err)
+	}
+
 	_, err = file.Seek(0, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to rewind buildpack file: %w", err)
+		return "", fmt.Errorf("failed to rewind buildpack file: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// cachePath returns the location in the cache workspace that the buildpack at
+// the given uri is downloaded to. Local buildpacks are never cached and are
+// reported at their own path.
+func cachePath(workspace, uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || !u.IsAbs() {
+		return uri
 	}
 
-	return file, nil
+	return filepath.Join(workspace, fmt.Sprintf("%x", sha256.Sum256([]byte(uri))))
 }
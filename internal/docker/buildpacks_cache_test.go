@@ -46,7 +46,7 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		it("downloads the buildpack into the cache", func() {
-			buildpack, err := cache.Fetch(server.URL)
+			buildpack, err := cache.Fetch(server.URL, "")
 			Expect(err).NotTo(HaveOccurred())
 
 			content, err := io.ReadAll(buildpack)
@@ -72,7 +72,7 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("reuses the cached buildpack", func() {
-				buildpack, err := cache.Fetch(server.URL)
+				buildpack, err := cache.Fetch(server.URL, "")
 				Expect(err).NotTo(HaveOccurred())
 
 				content, err := io.ReadAll(buildpack)
@@ -89,7 +89,7 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 					})
 
 					it("returns an error", func() {
-						_, err := cache.Fetch(server.URL)
+						_, err := cache.Fetch(server.URL, "")
 						Expect(err).To(MatchError(ContainSubstring("failed to open buildpack:")))
 						Expect(err).To(MatchError(ContainSubstring("permission denied")))
 					})
@@ -97,6 +97,52 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when a checksum is given", func() {
+			var checksum string
+
+			it.Before(func() {
+				checksum = fmt.Sprintf("%x", sha256.Sum256([]byte("some-content")))
+			})
+
+			it("verifies the downloaded buildpack", func() {
+				buildpack, err := cache.Fetch(server.URL, checksum)
+				Expect(err).NotTo(HaveOccurred())
+
+				content, err := io.ReadAll(buildpack)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("some-content"))
+
+				Expect(buildpack.Close()).To(Succeed())
+			})
+
+			context("when the cached buildpack does not match", func() {
+				it.Before(func() {
+					Expect(os.Mkdir(filepath.Join(workspace, "some-cache"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workspace, "some-cache", sum), []byte("corrupt-content"), 0600)).To(Succeed())
+				})
+
+				it("downloads the buildpack again", func() {
+					buildpack, err := cache.Fetch(server.URL, checksum)
+					Expect(err).NotTo(HaveOccurred())
+
+					content, err := io.ReadAll(buildpack)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(Equal("some-content"))
+
+					Expect(buildpack.Close()).To(Succeed())
+				})
+			})
+
+			context("when the downloaded buildpack does not match", func() {
+				it("returns an error and does not cache the buildpack", func() {
+					_, err := cache.Fetch(server.URL, "some-other-checksum")
+					Expect(err).To(MatchError(fmt.Sprintf("failed to verify buildpack: %s has SHA256 %s, expected some-other-checksum", server.URL, checksum)))
+
+					Expect(filepath.Join(workspace, "some-cache", sum)).NotTo(BeAnExistingFile())
+				})
+			})
+		})
+
 		context("when the url is a filepath", func() {
 			it.Before(func() {
 				err := os.WriteFile(filepath.Join(workspace, "some-buildpack"), []byte("file-content"), 0600)
@@ -104,7 +150,7 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("returns the filepath", func() {
-				buildpack, err := cache.Fetch(filepath.Join(workspace, "some-buildpack"))
+				buildpack, err := cache.Fetch(filepath.Join(workspace, "some-buildpack"), "")
 				Expect(err).NotTo(HaveOccurred())
 
 				content, err := io.ReadAll(buildpack)
@@ -114,14 +160,38 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 				Expect(buildpack.Close()).To(Succeed())
 			})
 
+			context("when a checksum is given", func() {
+				it("verifies the file", func() {
+					checksum := fmt.Sprintf("%x", sha256.Sum256([]byte("file-content")))
+
+					buildpack, err := cache.Fetch(filepath.Join(workspace, "some-buildpack"), checksum)
+					Expect(err).NotTo(HaveOccurred())
+
+					content, err := io.ReadAll(buildpack)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(content)).To(Equal("file-content"))
+
+					Expect(buildpack.Close()).To(Succeed())
+				})
+			})
+
 			context("failure cases", func() {
+				context("when the file does not match the checksum", func() {
+					it("returns an error", func() {
+						checksum := fmt.Sprintf("%x", sha256.Sum256([]byte("file-content")))
+
+						_, err := cache.Fetch(filepath.Join(workspace, "some-buildpack"), "some-other-checksum")
+						Expect(err).To(MatchError(fmt.Sprintf("failed to verify buildpack: %s has SHA256 %s, expected some-other-checksum", filepath.Join(workspace, "some-buildpack"), checksum)))
+					})
+				})
+
 				context("when the file cannot be opened", func() {
 					it.Before(func() {
 						Expect(os.Chmod(filepath.Join(workspace, "some-buildpack"), 0000)).To(Succeed())
 					})
 
 					it("returns an error", func() {
-						_, err := cache.Fetch(filepath.Join(workspace, "some-buildpack"))
+						_, err := cache.Fetch(filepath.Join(workspace, "some-buildpack"), "")
 						Expect(err).To(MatchError(ContainSubstring("failed to open buildpack:")))
 						Expect(err).To(MatchError(ContainSubstring("permission denied")))
 					})
@@ -136,7 +206,7 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("returns an error", func() {
-					_, err := cache.Fetch(server.URL)
+					_, err := cache.Fetch(server.URL, "")
 					Expect(err).To(MatchError(ContainSubstring("failed to create workspace:")))
 					Expect(err).To(MatchError(ContainSubstring("permission denied")))
 				})
@@ -144,7 +214,7 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 
 			context("when the uri is malformed", func() {
 				it("returns an error", func() {
-					_, err := cache.Fetch("%%%")
+					_, err := cache.Fetch("%%%", "")
 					Expect(err).To(MatchError(ContainSubstring("failed to parse uri:")))
 					Expect(err).To(MatchError(ContainSubstring("invalid URL escape")))
 				})
@@ -152,19 +222,42 @@ func testBuildpacksCache(t *testing.T, context spec.G, it spec.S) {
 
 			context("when the request fails", func() {
 				it("returns an error", func() {
-					_, err := cache.Fetch("http://localhost:0")
+					_, err := cache.Fetch("http://localhost:0", "")
 					Expect(err).To(MatchError(ContainSubstring("failed to download buildpack:")))
 					Expect(err).To(MatchError(ContainSubstring("dial tcp")))
 				})
 			})
 
+			context("when the response status is not successful", func() {
+				it.Before(func() {
+					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						w.WriteHeader(http.StatusNotFound)
+						fmt.Fprint(w, "not found")
+					}))
+				})
+
+				it("returns an error and does not cache the response", func() {
+					_, err := cache.Fetch(server.URL, "")
+					Expect(err).To(MatchError(fmt.Sprintf("failed to download buildpack: received unexpected response status \"404 Not Found\" from %s", server.URL)))
+
+					path := filepath.Join(workspace, "some-cache", fmt.Sprintf("%x", sha256.Sum256([]byte(server.URL))))
+					Expect(path).NotTo(BeAnExistingFile())
+				})
+			})
+
 			context("when the buildpack file cannot be created", func() {
 				it.Before(func() {
-					Expect(os.MkdirAll(filepath.Join(workspace, "some-cache"), 0000)).To(Succeed())
+					Expect(os.MkdirAll(filepath.Join(workspace, "some-cache"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workspace, "some-cache", fmt.Sprintf("%s.lock", sum)), nil, 0600)).To(Succeed())
+					Expect(os.Chmod(filepath.Join(workspace, "some-cache"), 0500)).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.Chmod(filepath.Join(workspace, "some-cache"), os.ModePerm)).To(Succeed())
 				})
 
 				it("returns an error", func() {
-					_, err := cache.Fetch(server.URL)
+					_, err := cache.Fetch(server.URL, "")
 					Expect(err).To(MatchError(ContainSubstring("failed to create buildpack file:")))
 					Expect(err).To(MatchError(ContainSubstring("permission denied")))
 				})
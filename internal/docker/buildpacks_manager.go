@@ -14,18 +14,19 @@ import (
 
 //go:generate faux --interface BPCache --output fakes/bp_cache.go
 type BPCache interface {
-	Fetch(url string) (io.ReadCloser, error)
+	Fetch(url, checksum string) (io.ReadCloser, error)
 }
 
 //go:generate faux --interface BPRegistry --output fakes/bp_registry.go
 type BPRegistry interface {
-	List() ([]Buildpack, error)
+	List(stack string, names ...string) ([]Buildpack, error)
 	Override(...Buildpack)
 }
 
 type Buildpack struct {
-	Name string
-	URI  string
+	Name   string
+	URI    string
+	SHA256 string
 }
 
 type BuildpacksManager struct {
@@ -34,6 +35,7 @@ type BuildpacksManager struct {
 	registry BPRegistry
 
 	filter []string
+	stack  string
 }
 
 func NewBuildpacksManager(archiver Archiver, cache BPCache, registry BPRegistry) BuildpacksManager {
@@ -50,25 +52,13 @@ func (m BuildpacksManager) Build(workspace, name string) (string, error) {
 		return "", fmt.Errorf("failed to remove existing buildpack directory: %w", err)
 	}
 
-	buildpacks, err := m.registry.List()
+	buildpacks, err := m.registry.List(m.stack, m.filter...)
 	if err != nil {
 		return "", fmt.Errorf("failed to list buildpacks: %w", err)
 	}
 
 	for _, buildpack := range buildpacks {
-		contains := len(m.filter) == 0
-		for _, name := range m.filter {
-			if buildpack.Name == name {
-				contains = true
-				break
-			}
-		}
-
-		if !contains {
-			continue
-		}
-
-		bp, err := m.cache.Fetch(buildpack.URI)
+		bp, err := m.cache.Fetch(buildpack.URI, buildpack.SHA256)
 		if err != nil {
 			return "", fmt.Errorf("failed to fetch buildpack: %w", err)
 		}
@@ -113,21 +103,28 @@ func (m BuildpacksManager) Build(workspace, name string) (string, error) {
 }
 
 func (m BuildpacksManager) Order() (string, bool, error) {
-	var names []string
-	buildpacks, err := m.registry.List()
+	// An explicit filter already names the order, so the registry does not
+	// need to be consulted.
+	if len(m.filter) > 0 {
+		return strings.Join(m.filter, ","), true, nil
+	}
+
+	buildpacks, err := m.registry.List(m.stack)
 	if err != nil {
 		return "", false, fmt.Errorf("failed to list buildpacks: %w", err)
 	}
 
-	if len(m.filter) > 0 {
-		names = m.filter
-	} else {
-		for _, buildpack := range buildpacks {
-			names = append(names, buildpack.Name)
-		}
+	var names []string
+	for _, buildpack := range buildpacks {
+		names = append(names, buildpack.Name)
 	}
 
-	return strings.Join(names, ","), len(m.filter) > 0, nil
+	return strings.Join(names, ","), false, nil
+}
+
+func (m BuildpacksManager) WithStack(stack string) BuildpacksBuilder {
+	m.stack = stack
+	return m
 }
 
 func (m BuildpacksManager) WithBuildpacks(buildpacks ...string) BuildpacksBuilder {
@@ -46,8 +46,8 @@ func testBuildpacksManager(t *testing.T, context spec.G, it spec.S) {
 		archiver.WithPrefixCall.Returns.Archiver = archiver
 
 		cache = &fakes.BPCache{}
-		cache.FetchCall.Stub = func(url string) (io.ReadCloser, error) {
-			cacheFetchInvocations = append(cacheFetchInvocations, cacheFetchInvocation{URL: url})
+		cache.FetchCall.Stub = func(url, checksum string) (io.ReadCloser, error) {
+			cacheFetchInvocations = append(cacheFetchInvocations, cacheFetchInvocation{URL: url, Checksum: checksum})
 
 			if url == filepath.Join(workspace, "some-buildpack") {
 				fd, err := os.Open(url)
@@ -83,8 +83,9 @@ func testBuildpacksManager(t *testing.T, context spec.G, it spec.S) {
 				URI:  "some-ruby-uri",
 			},
 			{
-				Name: "go-buildpack",
-				URI:  "some-go-uri",
+				Name:   "go-buildpack",
+				URI:    "some-go-uri",
+				SHA256: "some-go-checksum",
 			},
 			{
 				Name: "directory-buildpack",
@@ -114,7 +115,8 @@ func testBuildpacksManager(t *testing.T, context spec.G, it spec.S) {
 				URL: "some-ruby-uri",
 			}))
 			Expect(cacheFetchInvocations[1]).To(Equal(cacheFetchInvocation{
-				URL: "some-go-uri",
+				URL:      "some-go-uri",
+				Checksum: "some-go-checksum",
 			}))
 			Expect(cacheFetchInvocations[2]).To(Equal(cacheFetchInvocation{
 				URL: filepath.Join(workspace, "some-buildpack"),
@@ -154,10 +156,25 @@ func testBuildpacksManager(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		context("WithBuildpacks", func() {
-			it("only builds the named buildpacks", func() {
+			it.Before(func() {
+				registry.ListCall.Returns.BuildpackSlice = []docker.Buildpack{
+					{
+						Name: "ruby-buildpack",
+						URI:  "some-ruby-uri",
+					},
+					{
+						Name: "nodejs-buildpack",
+						URI:  "some-nodejs-uri",
+					},
+				}
+			})
+
+			it("only resolves and builds the named buildpacks", func() {
 				_, err := manager.WithBuildpacks("ruby-buildpack", "nodejs-buildpack").Build(workspace, "some-app")
 				Expect(err).NotTo(HaveOccurred())
 
+				Expect(registry.ListCall.Receives.Names).To(Equal([]string{"ruby-buildpack", "nodejs-buildpack"}))
+
 				directories, err := filepath.Glob(filepath.Join(workspace, "some-app", "*"))
 				Expect(err).NotTo(HaveOccurred())
 				Expect(directories).To(ConsistOf([]string{
@@ -175,6 +192,15 @@ func testBuildpacksManager(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("WithStack", func() {
+			it("lists the buildpacks for that stack", func() {
+				_, err := manager.WithStack("some-stack").Build(workspace, "some-app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(registry.ListCall.Receives.Stack).To(Equal("some-stack"))
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the registry cannot list the buildpacks", func() {
 				it.Before(func() {
@@ -213,7 +239,7 @@ func testBuildpacksManager(t *testing.T, context spec.G, it spec.S) {
 
 			context("when the buildpack cannot be decompressed", func() {
 				it.Before(func() {
-					cache.FetchCall.Stub = func(url string) (io.ReadCloser, error) {
+					cache.FetchCall.Stub = func(url, checksum string) (io.ReadCloser, error) {
 						return io.NopCloser(bytes.NewBuffer([]byte("this is not a zip file"))), nil
 					}
 				})
@@ -247,11 +273,22 @@ func testBuildpacksManager(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		context("WithBuildpacks", func() {
-			it("only returns those named buildpacks", func() {
+			it("only returns those named buildpacks without consulting the registry", func() {
 				order, skipDetect, err := manager.WithBuildpacks("nodejs-buildpack", "go-buildpack").Order()
 				Expect(err).NotTo(HaveOccurred())
 				Expect(order).To(Equal("nodejs-buildpack,go-buildpack"))
 				Expect(skipDetect).To(BeTrue())
+
+				Expect(registry.ListCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("WithStack", func() {
+			it("lists the buildpacks for that stack", func() {
+				_, _, err := manager.WithStack("some-stack").Order()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(registry.ListCall.Receives.Stack).To(Equal("some-stack"))
 			})
 		})
 
@@ -2,11 +2,16 @@ package docker
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 )
 
 var DefaultBuildpacks = []string{
@@ -23,83 +28,259 @@ var DefaultBuildpacks = []string{
 	"binary",
 }
 
+// CatalogEntry describes a buildpack that the registry resolves from the
+// GitHub releases of its repository. The Repository is given as "org/repo"
+// and defaults to "cloudfoundry/<name>-buildpack". When Tag is empty, the
+// latest release is used.
+type CatalogEntry struct {
+	Name       string `json:"name"`
+	Repository string `json:"repository,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+}
+
+// DefaultCatalog returns the catalog of buildpacks that are available on a
+// default Cloud Foundry installation, in their default order.
+func DefaultCatalog() []CatalogEntry {
+	var catalog []CatalogEntry
+	for _, name := range DefaultBuildpacks {
+		catalog = append(catalog, CatalogEntry{Name: name})
+	}
+
+	return catalog
+}
+
+// LoadCatalog reads a catalog from a JSON file of the form:
+//
+//	{
+//	  "buildpacks": [
+//	    { "name": "go", "repository": "some-org/go-buildpack", "tag": "v1.10.0" }
+//	  ]
+//	}
+func LoadCatalog(path string) ([]CatalogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open buildpack catalog: %w", err)
+	}
+	defer file.Close()
+
+	var content struct {
+		Buildpacks []CatalogEntry `json:"buildpacks"`
+	}
+	err = json.NewDecoder(file).Decode(&content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse buildpack catalog: %w", err)
+	}
+
+	for _, entry := range content.Buildpacks {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("failed to parse buildpack catalog: entry is missing a name")
+		}
+	}
+
+	return content.Buildpacks, nil
+}
+
+func (e CatalogEntry) buildpackName() string {
+	return strings.ReplaceAll(e.repositoryName(), "-", "_")
+}
+
+func (e CatalogEntry) repositoryName() string {
+	name := strings.ReplaceAll(e.Name, "_", "-")
+	if !strings.HasSuffix(name, "-buildpack") {
+		name = fmt.Sprintf("%s-buildpack", name)
+	}
+
+	return name
+}
+
+func (e CatalogEntry) releasePath() string {
+	repository := e.Repository
+	if repository == "" {
+		repository = fmt.Sprintf("cloudfoundry/%s", e.repositoryName())
+	}
+
+	if e.Tag == "" {
+		return fmt.Sprintf("/repos/%s/releases/latest", repository)
+	}
+
+	return fmt.Sprintf("/repos/%s/releases/tags/%s", repository, e.Tag)
+}
+
+// stackPattern matches the stack names that buildpack release assets are
+// qualified with, such as "cflinuxfs4" or "windows2016".
+var stackPattern = regexp.MustCompile(`(^|[-_.])(cflinuxfs[0-9]+|windows[0-9]*)([-_.]|$)`)
+
 type BuildpacksRegistry struct {
-	api   string
-	token string
-	index *sync.Map
+	api       string
+	token     string
+	catalog   []CatalogEntry
+	index     *sync.Map
+	overrides *sync.Map
+
+	client *http.Client
+
+	indexPath string
+	ttl       time.Duration
+	offline   bool
+	cacheDir  string
 }
 
 func NewBuildpacksRegistry(api, token string) BuildpacksRegistry {
 	return BuildpacksRegistry{
-		api:   api,
-		token: token,
-		index: &sync.Map{},
+		api:       api,
+		token:     token,
+		catalog:   DefaultCatalog(),
+		index:     &sync.Map{},
+		overrides: &sync.Map{},
+		client:    http.DefaultClient,
 	}
 }
 
-func (r BuildpacksRegistry) List() ([]Buildpack, error) {
-	var list []Buildpack
-	for _, name := range DefaultBuildpacks {
-		name = fmt.Sprintf("%s-buildpack", name)
-		buildpack := Buildpack{Name: strings.ReplaceAll(name, "-", "_")}
+func (r BuildpacksRegistry) WithHTTPClient(client *http.Client) BuildpacksRegistry {
+	r.client = client
+	return r
+}
+
+// WithCatalog replaces the buildpacks that the registry resolves. The order
+// of the catalog is the order in which buildpacks are listed.
+func (r BuildpacksRegistry) WithCatalog(catalog []CatalogEntry) BuildpacksRegistry {
+	r.catalog = catalog
+	return r
+}
+
+// WithPersistentIndex records resolved buildpacks in the file at the given
+// path so that other processes can reuse them until the ttl expires.
+func (r BuildpacksRegistry) WithPersistentIndex(path string, ttl time.Duration) BuildpacksRegistry {
+	r.indexPath = path
+	r.ttl = ttl
+	return r
+}
+
+// WithOffline resolves buildpacks only from the persistent index, regardless
+// of its age, and requires that each buildpack has already been downloaded
+// into the given cache directory.
+func (r BuildpacksRegistry) WithOffline(cacheDir string) BuildpacksRegistry {
+	r.offline = true
+	r.cacheDir = cacheDir
+	return r
+}
+
+// List resolves the buildpacks in the catalog, selecting the release asset
+// that is compatible with the given stack. When names are given, only those
+// buildpacks are resolved.
+func (r BuildpacksRegistry) List(stack string, names ...string) ([]Buildpack, error) {
+	wanted := func(name string) bool {
+		if len(names) == 0 {
+			return true
+		}
+
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	var (
+		list      []Buildpack
+		missing   []string
+		persisted map[string]indexRecord
+		updated   bool
+	)
+
+	for _, entry := range r.catalog {
+		buildpack := Buildpack{Name: entry.buildpackName()}
+		if !wanted(buildpack.Name) {
+			continue
+		}
+
+		if value, ok := r.overrides.Load(buildpack.Name); ok {
+			list = append(list, value.(Buildpack))
+			continue
+		}
 
-		value, ok := r.index.Load(buildpack.Name)
+		key := fmt.Sprintf("%s:%s", stack, buildpack.Name)
+		value, ok := r.index.Load(key)
 		if ok {
 			buildpack.URI = value.(string)
 		} else {
-			req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/cloudfoundry/%s/releases/latest", r.api, name), nil)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create request: %w", err)
+			if persisted == nil {
+				// The index stays locked until it has been saved, so that
+				// processes sharing it do not drop each other's records.
+				unlock, err := r.lockIndex()
+				if err != nil {
+					return nil, err
+				}
+				defer unlock()
+
+				persisted, err = r.loadIndex()
+				if err != nil {
+					return nil, err
+				}
 			}
 
-			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.token))
+			record, ok := persisted[key]
+			switch {
+			case ok && record.Release == entry.releasePath() && (r.offline || time.Since(record.ResolvedAt) < r.ttl):
+				buildpack.URI = record.URI
+			case r.offline:
+				missing = append(missing, fmt.Sprintf("%s (not in index)", buildpack.Name))
+				continue
+			default:
+				var err error
+				buildpack.URI, err = r.resolve(entry, stack)
+				if err != nil {
+					return nil, err
+				}
 
-			resp, err := http.DefaultClient.Do(req)
-			if err != nil {
-				return nil, fmt.Errorf("failed to complete request: %w", err)
+				persisted[key] = indexRecord{
+					Release:    entry.releasePath(),
+					URI:        buildpack.URI,
+					ResolvedAt: time.Now().UTC(),
+				}
+				updated = true
 			}
 
-			if resp.StatusCode != http.StatusOK {
-				dump, _ := httputil.DumpResponse(resp, true)
-				return nil, fmt.Errorf("received unexpected response status: %s", dump)
-			}
+			r.index.Store(key, buildpack.URI)
+		}
 
-			var release struct {
-				Assets []struct {
-					Name               string `json:"name"`
-					BrowserDownloadURL string `json:"browser_download_url"`
-				} `json:"assets"`
-			}
-			err = json.NewDecoder(resp.Body).Decode(&release)
+		if r.offline {
+			_, err := os.Stat(cachePath(r.cacheDir, buildpack.URI))
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse response json: %w", err)
+				missing = append(missing, fmt.Sprintf("%s (not cached)", buildpack.Name))
+				continue
 			}
-
-			for _, asset := range release.Assets {
-				if strings.HasSuffix(asset.Name, ".zip") {
-					buildpack.URI = asset.BrowserDownloadURL
-					break
-				}
-			}
-
-			r.index.Store(buildpack.Name, buildpack.URI)
 		}
 
 		list = append(list, buildpack)
 	}
 
-	r.index.Range(func(key, value interface{}) bool {
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("failed to resolve buildpacks offline: missing %s", strings.Join(missing, ", "))
+	}
+
+	if updated {
+		err := r.saveIndex(persisted)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.overrides.Range(func(key, value interface{}) bool {
 		name := key.(string)
-		for _, buildpack := range DefaultBuildpacks {
-			if name == strings.ReplaceAll(fmt.Sprintf("%s-buildpack", buildpack), "-", "_") {
+		if !wanted(name) {
+			return true
+		}
+
+		for _, entry := range r.catalog {
+			if name == entry.buildpackName() {
 				return true
 			}
 		}
 
-		list = append(list, Buildpack{
-			Name: name,
-			URI:  value.(string),
-		})
+		list = append(list, value.(Buildpack))
 
 		return true
 	})
@@ -107,8 +288,212 @@ func (r BuildpacksRegistry) List() ([]Buildpack, error) {
 	return list, nil
 }
 
+// Unresolved returns the names of the buildpacks in the catalog that List
+// would have to look up with the GitHub API for the given stack, because they
+// were neither given to Override nor resolved within the ttl of the index.
+// Nothing is looked up when offline.
+func (r BuildpacksRegistry) Unresolved(stack string) ([]string, error) {
+	if r.offline {
+		return nil, nil
+	}
+
+	unlock, err := r.lockIndex()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	persisted, err := r.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var unresolved []string
+	for _, entry := range r.catalog {
+		name := entry.buildpackName()
+		if _, ok := r.overrides.Load(name); ok {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s", stack, name)
+		if _, ok := r.index.Load(key); ok {
+			continue
+		}
+
+		record, ok := persisted[key]
+		if ok && record.Release == entry.releasePath() && time.Since(record.ResolvedAt) < r.ttl {
+			continue
+		}
+
+		unresolved = append(unresolved, name)
+	}
+
+	return unresolved, nil
+}
+
 func (r BuildpacksRegistry) Override(buildpacks ...Buildpack) {
 	for _, buildpack := range buildpacks {
-		r.index.Store(buildpack.Name, buildpack.URI)
+		r.overrides.Store(buildpack.Name, buildpack)
+	}
+}
+
+func (r BuildpacksRegistry) resolve(entry CatalogEntry, stack string) (string, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s%s", r.api, entry.releasePath()), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if r.token == "" {
+		return "", fmt.Errorf("failed to resolve %s: a GitHub API token is required to look up its release; pass one to switchblade.NewPlatform, or provide the buildpack with Platform.Initialize", entry.buildpackName())
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.token))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to complete request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		dump, _ := httputil.DumpResponse(resp, true)
+		return "", fmt.Errorf("received unexpected response status: %s", dump)
+	}
+
+	var release struct {
+		Assets []releaseAsset `json:"assets"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&release)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse response json: %w", err)
+	}
+
+	uri, err := selectAsset(release.Assets, stack)
+	if err != nil {
+		return "", fmt.Errorf("failed to select %s release asset: %w", entry.buildpackName(), err)
+	}
+
+	return uri, nil
+}
+
+type indexRecord struct {
+	Release    string    `json:"release"`
+	URI        string    `json:"uri"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// lockIndex acquires the lock file that sits next to the persistent index. It
+// does nothing when the index is not persisted.
+func (r BuildpacksRegistry) lockIndex() (func(), error) {
+	if r.indexPath == "" {
+		return func() {}, nil
+	}
+
+	return lockPath(fmt.Sprintf("%s.lock", r.indexPath))
+}
+
+func (r BuildpacksRegistry) loadIndex() (map[string]indexRecord, error) {
+	records := map[string]indexRecord{}
+	if r.indexPath == "" {
+		return records, nil
 	}
+
+	content, err := os.ReadFile(r.indexPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return records, nil
+		}
+
+		return nil, fmt.Errorf("failed to read buildpacks index: %w", err)
+	}
+
+	// A corrupt index is treated as empty so that it is rebuilt rather than
+	// failing every deployment.
+	_ = json.Unmarshal(content, &records)
+
+	return records, nil
+}
+
+func (r BuildpacksRegistry) saveIndex(records map[string]indexRecord) error {
+	if r.indexPath == "" {
+		return nil
+	}
+
+	err := os.MkdirAll(filepath.Dir(r.indexPath), os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create buildpacks index directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode buildpacks index: %w", err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(r.indexPath), filepath.Base(r.indexPath))
+	if err != nil {
+		return fmt.Errorf("failed to write buildpacks index: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	_, err = file.Write(content)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write buildpacks index: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write buildpacks index: %w", err)
+	}
+
+	err = os.Rename(file.Name(), r.indexPath)
+	if err != nil {
+		return fmt.Errorf("failed to write buildpacks index: %w", err)
+	}
+
+	return nil
+}
+
+type releaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// selectAsset picks the zip asset that is built for the given stack. When
+// the release has no asset for that stack, an asset that is not qualified
+// with any stack is used instead.
+func selectAsset(assets []releaseAsset, stack string) (string, error) {
+	var (
+		names    []string
+		agnostic string
+	)
+
+	for _, asset := range assets {
+		if !strings.HasSuffix(asset.Name, ".zip") {
+			continue
+		}
+		names = append(names, asset.Name)
+
+		match := stackPattern.FindStringSubmatch(asset.Name)
+		if match == nil {
+			if agnostic == "" {
+				agnostic = asset.BrowserDownloadURL
+			}
+			continue
+		}
+
+		if stack == "" || match[2] == stack {
+			return asset.BrowserDownloadURL, nil
+		}
+	}
+
+	if agnostic != "" {
+		return agnostic, nil
+	}
+
+	if len(names) == 0 {
+		return "", errors.New("release has no zip assets")
+	}
+
+	return "", fmt.Errorf("no asset is compatible with stack %q (found %s)", stack, strings.Join(names, ", "))
 }
@@ -1,13 +1,17 @@
 package docker_test
 
 import (
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cloudfoundry/switchblade/internal/docker"
 	"github.com/sclevine/spec"
@@ -68,7 +72,7 @@ func testBuildpacksRegistry(t *testing.T, context spec.G, it spec.S) {
 
 	context("List", func() {
 		it("manages the canonical list of buildpacks", func() {
-			list, err := registry.List()
+			list, err := registry.List("some-stack")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(list).To(Equal([]docker.Buildpack{
 				{
@@ -118,6 +122,302 @@ func testBuildpacksRegistry(t *testing.T, context spec.G, it spec.S) {
 			}))
 		})
 
+		context("when names are given", func() {
+			var paths []string
+
+			it.Before(func() {
+				paths = nil
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					paths = append(paths, req.URL.Path)
+					fmt.Fprint(w, `{"assets": [{"name": "buildpack.zip", "browser_download_url": "some-uri"}]}`)
+				}))
+
+				registry = docker.NewBuildpacksRegistry(server.URL, "some-token")
+				registry.Override(docker.Buildpack{Name: "extra_buildpack", URI: "some-extra-uri"})
+			})
+
+			it("only resolves those buildpacks", func() {
+				list, err := registry.List("some-stack", "go_buildpack", "extra_buildpack")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list).To(Equal([]docker.Buildpack{
+					{
+						Name: "go_buildpack",
+						URI:  "some-uri",
+					},
+					{
+						Name: "extra_buildpack",
+						URI:  "some-extra-uri",
+					},
+				}))
+
+				Expect(paths).To(Equal([]string{"/repos/cloudfoundry/go-buildpack/releases/latest"}))
+			})
+
+			it("resolves each buildpack only once per process", func() {
+				_, err := registry.List("some-stack", "go_buildpack")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = registry.List("some-stack")
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = registry.List("some-stack")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(paths).To(HaveLen(len(docker.DefaultBuildpacks)))
+			})
+		})
+
+		context("when a release has assets for several stacks", func() {
+			it.Before(func() {
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					switch req.URL.Path {
+					case "/repos/cloudfoundry/go-buildpack/releases/latest":
+						fmt.Fprint(w, `{"assets": [
+							{"name": "go-buildpack-cflinuxfs3-v1.2.3.zip", "browser_download_url": "some-cflinuxfs3-uri"},
+							{"name": "go-buildpack-cflinuxfs4-v1.2.3.zip", "browser_download_url": "some-cflinuxfs4-uri"}
+						]}`)
+					case "/repos/cloudfoundry/binary-buildpack/releases/latest":
+						fmt.Fprint(w, `{"assets": [
+							{"name": "binary-buildpack-windows-v1.2.3.zip", "browser_download_url": "some-windows-uri"},
+							{"name": "binary-buildpack-v1.2.3.zip", "browser_download_url": "some-agnostic-uri"}
+						]}`)
+					default:
+						w.WriteHeader(http.StatusNotFound)
+					}
+				}))
+
+				registry = docker.NewBuildpacksRegistry(server.URL, "some-token").WithCatalog([]docker.CatalogEntry{
+					{Name: "go"},
+					{Name: "binary"},
+				})
+			})
+
+			it("selects the asset for the stack, falling back to a stack-agnostic asset", func() {
+				list, err := registry.List("cflinuxfs4")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list).To(Equal([]docker.Buildpack{
+					{
+						Name: "go_buildpack",
+						URI:  "some-cflinuxfs4-uri",
+					},
+					{
+						Name: "binary_buildpack",
+						URI:  "some-agnostic-uri",
+					},
+				}))
+
+				list, err = registry.List("cflinuxfs3")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list[0].URI).To(Equal("some-cflinuxfs3-uri"))
+			})
+
+			context("when no asset is compatible with the stack", func() {
+				it("returns an error", func() {
+					_, err := registry.List("cflinuxfs5")
+					Expect(err).To(MatchError(`failed to select go_buildpack release asset: no asset is compatible with stack "cflinuxfs5" (found go-buildpack-cflinuxfs3-v1.2.3.zip, go-buildpack-cflinuxfs4-v1.2.3.zip)`))
+				})
+			})
+		})
+
+		context("WithPersistentIndex", func() {
+			var (
+				workspace string
+				index     string
+				requests  int
+			)
+
+			it.Before(func() {
+				var err error
+				workspace, err = os.MkdirTemp("", "workspace")
+				Expect(err).NotTo(HaveOccurred())
+
+				index = filepath.Join(workspace, "index", "buildpacks-index.json")
+
+				requests = 0
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					requests++
+					fmt.Fprint(w, `{"assets": [{"name": "go-buildpack.zip", "browser_download_url": "some-go-uri"}]}`)
+				}))
+
+				registry = docker.NewBuildpacksRegistry(server.URL, "some-token").
+					WithCatalog([]docker.CatalogEntry{{Name: "go"}}).
+					WithPersistentIndex(index, time.Hour)
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(workspace)).To(Succeed())
+			})
+
+			it("reuses the resolved buildpacks across registries", func() {
+				list, err := registry.List("some-stack")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list).To(Equal([]docker.Buildpack{{Name: "go_buildpack", URI: "some-go-uri"}}))
+				Expect(requests).To(Equal(1))
+				Expect(index).To(BeARegularFile())
+
+				list, err = docker.NewBuildpacksRegistry(server.URL, "some-token").
+					WithCatalog([]docker.CatalogEntry{{Name: "go"}}).
+					WithPersistentIndex(index, time.Hour).
+					List("some-stack")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list).To(Equal([]docker.Buildpack{{Name: "go_buildpack", URI: "some-go-uri"}}))
+				Expect(requests).To(Equal(1))
+			})
+
+			it("locks the index while it is updated", func() {
+				_, err := registry.List("some-stack")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fmt.Sprintf("%s.lock", index)).To(BeARegularFile())
+			})
+
+			context("when the index cannot be locked", func() {
+				it.Before(func() {
+					Expect(os.MkdirAll(fmt.Sprintf("%s.lock", index), os.ModePerm)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := registry.List("some-stack")
+					Expect(err).To(MatchError(ContainSubstring("failed to open lock file:")))
+					Expect(requests).To(Equal(0))
+				})
+			})
+
+			context("when the index entry has expired", func() {
+				it.Before(func() {
+					Expect(os.MkdirAll(filepath.Dir(index), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(index, []byte(`{
+						"some-stack:go_buildpack": {
+							"release": "/repos/cloudfoundry/go-buildpack/releases/latest",
+							"uri": "stale-go-uri",
+							"resolved_at": "2020-01-01T00:00:00Z"
+						}
+					}`), 0600)).To(Succeed())
+				})
+
+				it("resolves the buildpack again", func() {
+					list, err := registry.List("some-stack")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(list).To(Equal([]docker.Buildpack{{Name: "go_buildpack", URI: "some-go-uri"}}))
+					Expect(requests).To(Equal(1))
+				})
+
+				context("when offline", func() {
+					it.Before(func() {
+						Expect(os.MkdirAll(filepath.Join(workspace, "cache"), os.ModePerm)).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(workspace, "cache", fmt.Sprintf("%x", sha256.Sum256([]byte("https://example.com/go.zip")))), nil, 0600)).To(Succeed())
+						Expect(os.WriteFile(index, []byte(`{
+							"some-stack:go_buildpack": {
+								"release": "/repos/cloudfoundry/go-buildpack/releases/latest",
+								"uri": "https://example.com/go.zip",
+								"resolved_at": "2020-01-01T00:00:00Z"
+							}
+						}`), 0600)).To(Succeed())
+
+						registry = registry.WithOffline(filepath.Join(workspace, "cache"))
+					})
+
+					it("uses the index entry without making requests", func() {
+						list, err := registry.List("some-stack")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(list).To(Equal([]docker.Buildpack{{Name: "go_buildpack", URI: "https://example.com/go.zip"}}))
+						Expect(requests).To(Equal(0))
+					})
+				})
+			})
+
+			context("when the catalog entry has changed", func() {
+				it.Before(func() {
+					Expect(os.MkdirAll(filepath.Dir(index), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(index, []byte(fmt.Sprintf(`{
+						"some-stack:go_buildpack": {
+							"release": "/repos/cloudfoundry/go-buildpack/releases/tags/v1.0.0",
+							"uri": "old-go-uri",
+							"resolved_at": %q
+						}
+					}`, time.Now().UTC().Format(time.RFC3339))), 0600)).To(Succeed())
+				})
+
+				it("resolves the buildpack again", func() {
+					list, err := registry.List("some-stack")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(list).To(Equal([]docker.Buildpack{{Name: "go_buildpack", URI: "some-go-uri"}}))
+					Expect(requests).To(Equal(1))
+				})
+			})
+
+			context("when offline and buildpacks are missing from disk", func() {
+				it.Before(func() {
+					Expect(os.MkdirAll(filepath.Dir(index), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(index, []byte(fmt.Sprintf(`{
+						"some-stack:go_buildpack": {
+							"release": "/repos/cloudfoundry/go-buildpack/releases/latest",
+							"uri": "https://example.com/go.zip",
+							"resolved_at": %q
+						}
+					}`, time.Now().UTC().Format(time.RFC3339))), 0600)).To(Succeed())
+
+					registry = registry.
+						WithCatalog([]docker.CatalogEntry{{Name: "go"}, {Name: "binary"}}).
+						WithOffline(filepath.Join(workspace, "cache"))
+				})
+
+				it("reports which buildpacks are missing", func() {
+					_, err := registry.List("some-stack")
+					Expect(err).To(MatchError("failed to resolve buildpacks offline: missing go_buildpack (not cached), binary_buildpack (not in index)"))
+					Expect(requests).To(Equal(0))
+				})
+			})
+		})
+
+		context("WithCatalog", func() {
+			var paths []string
+
+			it.Before(func() {
+				paths = nil
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					paths = append(paths, req.URL.Path)
+
+					var uri string
+					switch req.URL.Path {
+					case "/repos/some-org/go-buildpack-fork/releases/tags/v1.2.3":
+						uri = "some-forked-go-uri"
+					case "/repos/cloudfoundry/binary-buildpack/releases/latest":
+						uri = "some-binary-uri"
+					default:
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+
+					fmt.Fprintf(w, `{"assets": [{"name": "buildpack.zip", "browser_download_url": %q}]}`, uri)
+				}))
+
+				registry = docker.NewBuildpacksRegistry(server.URL, "some-token").WithCatalog([]docker.CatalogEntry{
+					{Name: "go", Repository: "some-org/go-buildpack-fork", Tag: "v1.2.3"},
+					{Name: "binary"},
+				})
+			})
+
+			it("resolves the catalog in order from the given repositories and tags", func() {
+				list, err := registry.List("some-stack")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(list).To(Equal([]docker.Buildpack{
+					{
+						Name: "go_buildpack",
+						URI:  "some-forked-go-uri",
+					},
+					{
+						Name: "binary_buildpack",
+						URI:  "some-binary-uri",
+					},
+				}))
+
+				Expect(paths).To(Equal([]string{
+					"/repos/some-org/go-buildpack-fork/releases/tags/v1.2.3",
+					"/repos/cloudfoundry/binary-buildpack/releases/latest",
+				}))
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the api is malformed", func() {
 				it.Before(func() {
@@ -125,19 +425,40 @@ func testBuildpacksRegistry(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("returns an error", func() {
-					_, err := registry.List()
+					_, err := registry.List("some-stack")
 					Expect(err).To(MatchError(ContainSubstring("failed to create request:")))
 					Expect(err).To(MatchError(ContainSubstring("invalid URL escape")))
 				})
 			})
 
+			context("when a lookup is needed and there is no token", func() {
+				it.Before(func() {
+					registry = docker.NewBuildpacksRegistry(server.URL, "")
+				})
+
+				it("returns an actionable error", func() {
+					_, err := registry.List("some-stack", "go_buildpack")
+					Expect(err).To(MatchError(ContainSubstring("failed to resolve go_buildpack: a GitHub API token is required")))
+				})
+
+				context("when the buildpacks are overridden", func() {
+					it("does not require a token", func() {
+						registry.Override(docker.Buildpack{Name: "go_buildpack", URI: "some-go-uri"})
+
+						list, err := registry.List("some-stack", "go_buildpack")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(list).To(Equal([]docker.Buildpack{{Name: "go_buildpack", URI: "some-go-uri"}}))
+					})
+				})
+			})
+
 			context("when the request fails", func() {
 				it.Before(func() {
 					registry = docker.NewBuildpacksRegistry("http://localhost:0", "some-token")
 				})
 
 				it("returns an error", func() {
-					_, err := registry.List()
+					_, err := registry.List("some-stack")
 					Expect(err).To(MatchError(ContainSubstring("failed to complete request:")))
 					Expect(err).To(MatchError(ContainSubstring("dial tcp")))
 				})
@@ -154,7 +475,7 @@ func testBuildpacksRegistry(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("returns an error", func() {
-					_, err := registry.List()
+					_, err := registry.List("some-stack")
 					Expect(err).To(MatchError(ContainSubstring("received unexpected response status:")))
 					Expect(err).To(MatchError(ContainSubstring("server encountered an error")))
 				})
@@ -170,7 +491,7 @@ func testBuildpacksRegistry(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("returns an error", func() {
-					_, err := registry.List()
+					_, err := registry.List("some-stack")
 					Expect(err).To(MatchError(ContainSubstring("failed to parse response json:")))
 					Expect(err).To(MatchError(ContainSubstring("invalid character '%'")))
 				})
@@ -181,7 +502,7 @@ func testBuildpacksRegistry(t *testing.T, context spec.G, it spec.S) {
 	context("Override", func() {
 		it("overrides the given buildpack", func() {
 			registry.Override(docker.Buildpack{Name: "python_buildpack", URI: "override-python-uri"})
-			list, err := registry.List()
+			list, err := registry.List("some-stack")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(list).To(Equal([]docker.Buildpack{
 				{
@@ -234,7 +555,7 @@ func testBuildpacksRegistry(t *testing.T, context spec.G, it spec.S) {
 		context("when the buildpack is not in the default list", func() {
 			it("adds the given buildpack", func() {
 				registry.Override(docker.Buildpack{Name: "extra_buildpack", URI: "some-extra-uri"})
-				list, err := registry.List()
+				list, err := registry.List("some-stack")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(list).To(Equal([]docker.Buildpack{
 					{
@@ -289,4 +610,114 @@ func testBuildpacksRegistry(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 	})
+
+	context("Unresolved", func() {
+		var workspace string
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.WriteFile(filepath.Join(workspace, "buildpacks-index.json"), []byte(fmt.Sprintf(`{
+				"some-stack:go_buildpack": {
+					"release": "/repos/cloudfoundry/go-buildpack/releases/latest",
+					"uri": "some-go-uri",
+					"resolved_at": %q
+				},
+				"some-stack:ruby_buildpack": {
+					"release": "/repos/cloudfoundry/ruby-buildpack/releases/latest",
+					"uri": "stale-ruby-uri",
+					"resolved_at": "2020-01-01T00:00:00Z"
+				}
+			}`, time.Now().UTC().Format(time.RFC3339))), 0600)).To(Succeed())
+
+			registry = docker.NewBuildpacksRegistry(server.URL, "").
+				WithCatalog([]docker.CatalogEntry{{Name: "go"}, {Name: "ruby"}, {Name: "nodejs"}, {Name: "php"}}).
+				WithPersistentIndex(filepath.Join(workspace, "buildpacks-index.json"), time.Hour)
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("returns the buildpacks that are neither overridden nor in the index", func() {
+			registry.Override(docker.Buildpack{Name: "php_buildpack", URI: "override-php-uri"})
+
+			unresolved, err := registry.Unresolved("some-stack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(unresolved).To(Equal([]string{"ruby_buildpack", "nodejs_buildpack"}))
+		})
+
+		context("when offline", func() {
+			it("returns nothing", func() {
+				unresolved, err := registry.WithOffline(workspace).Unresolved("some-stack")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(unresolved).To(BeEmpty())
+			})
+		})
+	})
+
+	context("LoadCatalog", func() {
+		var (
+			workspace string
+			path      string
+		)
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			path = filepath.Join(workspace, "catalog.json")
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("reads the catalog entries in order", func() {
+			err := os.WriteFile(path, []byte(`{
+				"buildpacks": [
+					{ "name": "go", "repository": "some-org/go-buildpack", "tag": "v1.2.3" },
+					{ "name": "binary" }
+				]
+			}`), 0600)
+			Expect(err).NotTo(HaveOccurred())
+
+			catalog, err := docker.LoadCatalog(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(catalog).To(Equal([]docker.CatalogEntry{
+				{Name: "go", Repository: "some-org/go-buildpack", Tag: "v1.2.3"},
+				{Name: "binary"},
+			}))
+		})
+
+		context("failure cases", func() {
+			context("when the file does not exist", func() {
+				it("returns an error", func() {
+					_, err := docker.LoadCatalog(path)
+					Expect(err).To(MatchError(ContainSubstring("failed to open buildpack catalog:")))
+				})
+			})
+
+			context("when the file is malformed", func() {
+				it("returns an error", func() {
+					Expect(os.WriteFile(path, []byte("%%%"), 0600)).To(Succeed())
+
+					_, err := docker.LoadCatalog(path)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse buildpack catalog:")))
+				})
+			})
+
+			context("when an entry is missing a name", func() {
+				it("returns an error", func() {
+					Expect(os.WriteFile(path, []byte(`{"buildpacks": [{"tag": "v1.2.3"}]}`), 0600)).To(Succeed())
+
+					_, err := docker.LoadCatalog(path)
+					Expect(err).To(MatchError("failed to parse buildpack catalog: entry is missing a name"))
+				})
+			})
+		})
+	})
 }
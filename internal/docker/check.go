@@ -0,0 +1,201 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/registry"
+	"github.com/docker/docker/client"
+)
+
+type CheckPhase interface {
+	Run() error
+}
+
+//go:generate faux --interface CheckClient --output fakes/check_client.go
+type CheckClient interface {
+	Ping(ctx context.Context) (types.Ping, error)
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+	DistributionInspect(ctx context.Context, image, encodedRegistryAuth string) (registry.DistributionInspect, error)
+}
+
+//go:generate faux --interface CheckRegistry --output fakes/check_registry.go
+type CheckRegistry interface {
+	Unresolved(stack string) ([]string, error)
+}
+
+// Check verifies that the prerequisites of the Docker platform are in place
+// before anything is deployed, so that problems are reported up front rather
+// than partway through a deployment.
+type Check struct {
+	client CheckClient
+	stack  string
+
+	compilerImage string
+
+	api        string
+	token      string
+	httpClient *http.Client
+	offline    bool
+	registry   CheckRegistry
+}
+
+func NewCheck(client CheckClient, stack, api, token string) Check {
+	return Check{
+		client:     client,
+		stack:      stack,
+		api:        api,
+		token:      token,
+		httpClient: http.DefaultClient,
+	}
+}
+
+func (c Check) WithHTTPClient(client *http.Client) Check {
+	c.httpClient = client
+	return c
+}
+
+// WithCompilerImage checks that the given image is available to compile the
+// lifecycle in, for when the Go toolchain is not installed.
+func (c Check) WithCompilerImage(image string) Check {
+	c.compilerImage = image
+	return c
+}
+
+// WithOffline skips checking the GitHub API, which is not used to resolve
+// buildpacks when offline.
+func (c Check) WithOffline() Check {
+	c.offline = true
+	return c
+}
+
+// WithRegistry only checks the GitHub API when the given registry still has
+// buildpacks to look up with it, rather than having them all from
+// Platform.Initialize or its index.
+func (c Check) WithRegistry(registry CheckRegistry) Check {
+	c.registry = registry
+	return c
+}
+
+// Run performs every check and returns an error that describes each problem
+// that was found along with how to resolve it.
+func (c Check) Run() error {
+	ctx := context.Background()
+
+	var problems []error
+
+	_, err := c.client.Ping(ctx)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("the Docker daemon is not reachable: %w; start Docker, or set DOCKER_HOST to the address of a running daemon", err))
+	} else {
+		stackImage := fmt.Sprintf("cloudfoundry/%s:latest", c.stack)
+		err = c.checkImage(ctx, stackImage)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("the %s stack image %s is not available: %w; check the stack name, or pull the image with `docker pull %s`", c.stack, stackImage, err, stackImage))
+		}
+
+		if c.compilerImage != "" {
+			err = c.checkImage(ctx, c.compilerImage)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("go is not installed and the %s image that the lifecycle is compiled in instead is not available: %w; install Go, or pull the image with `docker pull %s`", c.compilerImage, err, c.compilerImage))
+			}
+		}
+	}
+
+	if !c.offline {
+		err = c.checkGitHub()
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	return errors.Join(problems...)
+}
+
+// checkImage succeeds if the image has already been pulled, or if it can be
+// found in its registry.
+func (c Check) checkImage(ctx context.Context, image string) error {
+	_, _, err := c.client.ImageInspectWithRaw(ctx, image)
+	if err == nil {
+		return nil
+	}
+
+	if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	_, err = c.client.DistributionInspect(ctx, image, "")
+	if err != nil {
+		return fmt.Errorf("it has not been pulled and cannot be found in its registry: %w", err)
+	}
+
+	return nil
+}
+
+func (c Check) checkGitHub() error {
+	required := "buildpack releases"
+	if c.registry != nil {
+		unresolved, err := c.registry.Unresolved(c.stack)
+		if err != nil {
+			return fmt.Errorf("failed to check which buildpacks need the GitHub API: %w", err)
+		}
+
+		if len(unresolved) == 0 {
+			return nil
+		}
+
+		required = fmt.Sprintf("the releases of %s", strings.Join(unresolved, ", "))
+	}
+
+	if c.token == "" {
+		return fmt.Errorf("no GitHub API token was provided; it is required to resolve %s, so provide one, provide the buildpacks with Platform.Initialize, or use the platform offline", required)
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/rate_limit", c.api), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("the GitHub API at %s is not reachable: %w; check the network connection, or use the platform offline", c.api, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return errors.New("the GitHub API token is invalid or has expired; provide a new token")
+	default:
+		return fmt.Errorf("the GitHub API at %s returned unexpected response status %q", c.api, resp.Status)
+	}
+
+	var rateLimit struct {
+		Resources struct {
+			Core struct {
+				Limit     int   `json:"limit"`
+				Remaining int   `json:"remaining"`
+				Reset     int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&rateLimit)
+	if err != nil {
+		return fmt.Errorf("failed to parse GitHub rate limit: %w", err)
+	}
+
+	core := rateLimit.Resources.Core
+	if core.Remaining == 0 {
+		return fmt.Errorf("the GitHub API rate limit of %d requests is exhausted until %s; wait for it to reset, or use the platform offline", core.Limit, time.Unix(core.Reset, 0).UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package docker_test
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCheck(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Run", func() {
+		var (
+			check docker.Check
+
+			client    *fakes.CheckClient
+			server    *httptest.Server
+			remaining int
+		)
+
+		it.Before(func() {
+			client = &fakes.CheckClient{}
+			remaining = 4999
+
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/rate_limit" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				if req.Header.Get("Authorization") != "Bearer some-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+
+				fmt.Fprintf(w, `{"resources": {"core": {"limit": 5000, "remaining": %d, "reset": 1767225600}}}`, remaining)
+			}))
+
+			check = docker.NewCheck(client, "some-stack", server.URL, "some-token")
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		it("checks the daemon, stack image, and GitHub token", func() {
+			err := check.Run()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.PingCall.CallCount).To(Equal(1))
+			Expect(client.ImageInspectWithRawCall.Receives.ImageID).To(Equal("cloudfoundry/some-stack:latest"))
+			Expect(client.DistributionInspectCall.CallCount).To(Equal(0))
+		})
+
+		context("when the stack image has not been pulled", func() {
+			it.Before(func() {
+				client.ImageInspectWithRawCall.Returns.Error = errdefs.NotFound(errors.New("no such image"))
+			})
+
+			it("checks that the image is in its registry", func() {
+				err := check.Run()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.DistributionInspectCall.Receives.Image).To(Equal("cloudfoundry/some-stack:latest"))
+			})
+		})
+
+		context("WithCompilerImage", func() {
+			it.Before(func() {
+				check = check.WithCompilerImage("some-golang-image")
+			})
+
+			it("checks that the compiler image is available", func() {
+				err := check.Run()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ImageInspectWithRawCall.CallCount).To(Equal(2))
+				Expect(client.ImageInspectWithRawCall.Receives.ImageID).To(Equal("some-golang-image"))
+			})
+		})
+
+		context("WithOffline", func() {
+			it.Before(func() {
+				check = docker.NewCheck(client, "some-stack", server.URL, "").WithOffline()
+			})
+
+			it("does not check the GitHub token", func() {
+				err := check.Run()
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
+		context("WithRegistry", func() {
+			var registry *fakes.CheckRegistry
+
+			it.Before(func() {
+				registry = &fakes.CheckRegistry{}
+				check = docker.NewCheck(client, "some-stack", server.URL, "").WithRegistry(registry)
+			})
+
+			context("when every buildpack is already resolved", func() {
+				it("does not check the GitHub token", func() {
+					err := check.Run()
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(registry.UnresolvedCall.Receives.Stack).To(Equal("some-stack"))
+				})
+			})
+
+			context("when buildpacks still need to be looked up", func() {
+				it.Before(func() {
+					registry.UnresolvedCall.Returns.StringSlice = []string{"go_buildpack", "ruby_buildpack"}
+				})
+
+				it("requires a GitHub token for them", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("no GitHub API token was provided; it is required to resolve the releases of go_buildpack, ruby_buildpack, so provide one, provide the buildpacks with Platform.Initialize, or use the platform offline"))
+				})
+			})
+
+			context("when the registry cannot be read", func() {
+				it.Before(func() {
+					registry.UnresolvedCall.Returns.Error = errors.New("permission denied")
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("failed to check which buildpacks need the GitHub API: permission denied"))
+				})
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the daemon is not reachable", func() {
+				it.Before(func() {
+					client.PingCall.Returns.Error = errors.New("connection refused")
+				})
+
+				it("reports the problem without checking images", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("the Docker daemon is not reachable: connection refused; start Docker, or set DOCKER_HOST to the address of a running daemon"))
+
+					Expect(client.ImageInspectWithRawCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the stack image is not available", func() {
+				it.Before(func() {
+					client.ImageInspectWithRawCall.Returns.Error = errdefs.NotFound(errors.New("no such image"))
+					client.DistributionInspectCall.Returns.Error = errors.New("manifest unknown")
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("the some-stack stack image cloudfoundry/some-stack:latest is not available: it has not been pulled and cannot be found in its registry: manifest unknown; check the stack name, or pull the image with `docker pull cloudfoundry/some-stack:latest`"))
+				})
+			})
+
+			context("when the compiler image is not available", func() {
+				it.Before(func() {
+					check = check.WithCompilerImage("some-golang-image")
+					client.ImageInspectWithRawCall.Stub = func(_ gocontext.Context, image string) (types.ImageInspect, []byte, error) {
+						if image == "some-golang-image" {
+							return types.ImageInspect{}, nil, errors.New("inspect failed")
+						}
+
+						return types.ImageInspect{}, nil, nil
+					}
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("go is not installed and the some-golang-image image that the lifecycle is compiled in instead is not available: inspect failed; install Go, or pull the image with `docker pull some-golang-image`"))
+				})
+			})
+
+			context("when there is no GitHub token", func() {
+				it.Before(func() {
+					check = docker.NewCheck(client, "some-stack", server.URL, "")
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError(ContainSubstring("no GitHub API token was provided")))
+				})
+			})
+
+			context("when the GitHub token is invalid", func() {
+				it.Before(func() {
+					check = docker.NewCheck(client, "some-stack", server.URL, "other-token")
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("the GitHub API token is invalid or has expired; provide a new token"))
+				})
+			})
+
+			context("when the GitHub rate limit is exhausted", func() {
+				it.Before(func() {
+					remaining = 0
+				})
+
+				it("reports the problem", func() {
+					err := check.Run()
+					Expect(err).To(MatchError("the GitHub API rate limit of 5000 requests is exhausted until 2026-01-01T00:00:00Z; wait for it to reset, or use the platform offline"))
+				})
+			})
+
+			context("when there are several problems", func() {
+				it.Before(func() {
+					client.PingCall.Returns.Error = errors.New("connection refused")
+					remaining = 0
+				})
+
+				it("reports all of them", func() {
+					err := check.Run()
+					Expect(err).To(MatchError(ContainSubstring("the Docker daemon is not reachable")))
+					Expect(err).To(MatchError(ContainSubstring("the GitHub API rate limit of 5000 requests is exhausted")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,172 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types"
+)
+
+type CollectPhase interface {
+	Run(ctx context.Context, name, droplet string) (Artifacts, error)
+	Droplet(name string) (Droplet, error)
+}
+
+//go:generate faux --interface CollectClient --output fakes/collect_client.go
+type CollectClient interface {
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+}
+
+// Artifacts describe a deployment for debugging after the fact. Each is empty
+// when it could not be collected, such as when the deployment failed before
+// it was produced.
+type Artifacts struct {
+	// Container is the output of `docker inspect` for the app container.
+	Container []byte
+
+	// Env is the environment of the app container.
+	Env []string
+
+	// Result is the result.json written by the builder during staging.
+	Result []byte
+}
+
+// Droplet describes the droplet that an app was staged into.
+type Droplet struct {
+	// Path is the droplet tarball in the workspace, which Teardown removes.
+	Path         string
+	Buildpacks   []DropletBuildpack
+	ProcessTypes map[string]string
+
+	// Metadata is the result.json written by the builder.
+	Metadata []byte
+}
+
+// DropletBuildpack is a buildpack that staged a droplet. The Name is the name
+// of the buildpack that was given to stage the app, such as "go_buildpack".
+type DropletBuildpack struct {
+	Name    string
+	Version string
+}
+
+// Collect gathers the artifacts of a deployment from its container and from
+// the files that were kept in the workspace when it was staged.
+type Collect struct {
+	client    CollectClient
+	workspace string
+}
+
+func NewCollect(client CollectClient, workspace string) Collect {
+	return Collect{
+		client:    client,
+		workspace: workspace,
+	}
+}
+
+// Run collects every artifact that is available, and copies the droplet to
+// the given path unless it is empty. The returned error describes the
+// artifacts that could not be collected.
+func (c Collect) Run(ctx context.Context, name, droplet string) (Artifacts, error) {
+	var (
+		artifacts Artifacts
+		problems  []error
+	)
+
+	inspect, err := c.client.ContainerInspect(ctx, name)
+	if err != nil {
+		problems = append(problems, fmt.Errorf("failed to inspect container: %w", err))
+	} else {
+		artifacts.Container, err = json.MarshalIndent(inspect, "", "  ")
+		if err != nil {
+			problems = append(problems, fmt.Errorf("failed to marshal container: %w", err))
+		}
+
+		if inspect.Config != nil {
+			artifacts.Env = inspect.Config.Env
+		}
+	}
+
+	artifacts.Result, err = os.ReadFile(filepath.Join(c.workspace, "droplets", fmt.Sprintf("%s.json", name)))
+	if err != nil {
+		problems = append(problems, fmt.Errorf("failed to read result.json: %w", err))
+	}
+
+	if droplet != "" {
+		err = copyFile(filepath.Join(c.workspace, "droplets", fmt.Sprintf("%s.tar.gz", name)), droplet)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("failed to copy droplet: %w", err))
+		}
+	}
+
+	return artifacts, errors.Join(problems...)
+}
+
+// Droplet returns the droplet that the app was staged into, as described by
+// the result.json that was kept when it was staged.
+func (c Collect) Droplet(name string) (Droplet, error) {
+	content, err := os.ReadFile(filepath.Join(c.workspace, "droplets", fmt.Sprintf("%s.json", name)))
+	if err != nil {
+		return Droplet{}, fmt.Errorf("failed to read result.json: %w", err)
+	}
+
+	var result struct {
+		LifecycleMetadata struct {
+			Buildpacks []struct {
+				Key     string `json:"key"`
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"buildpacks"`
+		} `json:"lifecycle_metadata"`
+		ProcessTypes map[string]string `json:"process_types"`
+	}
+	err = json.Unmarshal(content, &result)
+	if err != nil {
+		return Droplet{}, fmt.Errorf("failed to parse result.json: %w", err)
+	}
+
+	droplet := Droplet{
+		Path:         filepath.Join(c.workspace, "droplets", fmt.Sprintf("%s.tar.gz", name)),
+		ProcessTypes: result.ProcessTypes,
+		Metadata:     content,
+	}
+
+	for _, buildpack := range result.LifecycleMetadata.Buildpacks {
+		bpName := buildpack.Key
+		if bpName == "" {
+			bpName = buildpack.Name
+		}
+
+		droplet.Buildpacks = append(droplet.Buildpacks, DropletBuildpack{
+			Name:    bpName,
+			Version: buildpack.Version,
+		})
+	}
+
+	return droplet, nil
+}
+
+func copyFile(source, destination string) error {
+	src, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		return err
+	}
+
+	return dst.Close()
+}
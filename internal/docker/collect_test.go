@@ -0,0 +1,178 @@
+package docker_test
+
+import (
+	gocontext "context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testCollect(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Run", func() {
+		var (
+			collect docker.Collect
+
+			client    *fakes.CollectClient
+			workspace string
+			output    string
+		)
+
+		it.Before(func() {
+			client = &fakes.CollectClient{}
+			client.ContainerInspectCall.Returns.ContainerJSON = types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					Name: "/some-app",
+				},
+				Config: &container.Config{
+					Env: []string{"SOME_KEY=some-value"},
+				},
+			}
+
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			output, err = os.MkdirTemp("", "output")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Mkdir(filepath.Join(workspace, "droplets"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+			err = os.WriteFile(filepath.Join(workspace, "droplets", "some-app.tar.gz"), []byte("some-droplet-contents"), 0600)
+			Expect(err).NotTo(HaveOccurred())
+			err = os.WriteFile(filepath.Join(workspace, "droplets", "some-app.json"), []byte(`{"processes":[]}`), 0600)
+			Expect(err).NotTo(HaveOccurred())
+
+			collect = docker.NewCollect(client, workspace)
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+			Expect(os.RemoveAll(output)).To(Succeed())
+		})
+
+		it("collects the artifacts of the deployment", func() {
+			ctx := gocontext.Background()
+
+			artifacts, err := collect.Run(ctx, "some-app", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.ContainerInspectCall.Receives.Ctx).To(Equal(ctx))
+			Expect(client.ContainerInspectCall.Receives.ContainerID).To(Equal("some-app"))
+
+			Expect(string(artifacts.Container)).To(ContainSubstring(`"Name": "/some-app"`))
+			Expect(artifacts.Env).To(Equal([]string{"SOME_KEY=some-value"}))
+			Expect(string(artifacts.Result)).To(Equal(`{"processes":[]}`))
+		})
+
+		context("when a droplet path is given", func() {
+			it("copies the droplet there", func() {
+				_, err := collect.Run(gocontext.Background(), "some-app", filepath.Join(output, "droplet.tgz"))
+				Expect(err).NotTo(HaveOccurred())
+
+				content, err := os.ReadFile(filepath.Join(output, "droplet.tgz"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("some-droplet-contents"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the deployment did not get far enough to produce the artifacts", func() {
+				it.Before(func() {
+					client.ContainerInspectCall.Returns.Error = errors.New("no such container")
+					Expect(os.RemoveAll(filepath.Join(workspace, "droplets"))).To(Succeed())
+				})
+
+				it("returns the artifacts that were collected and an error for the others", func() {
+					artifacts, err := collect.Run(gocontext.Background(), "some-app", filepath.Join(output, "droplet.tgz"))
+					Expect(err).To(MatchError(ContainSubstring("failed to inspect container: no such container")))
+					Expect(err).To(MatchError(ContainSubstring("failed to read result.json:")))
+					Expect(err).To(MatchError(ContainSubstring("failed to copy droplet:")))
+
+					Expect(artifacts).To(Equal(docker.Artifacts{}))
+				})
+			})
+		})
+	})
+
+	context("Droplet", func() {
+		var (
+			collect docker.Collect
+
+			workspace string
+		)
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			err = os.Mkdir(filepath.Join(workspace, "droplets"), os.ModePerm)
+			Expect(err).NotTo(HaveOccurred())
+			err = os.WriteFile(filepath.Join(workspace, "droplets", "some-app.json"), []byte(`{
+				"lifecycle_metadata": {
+					"buildpacks": [
+						{ "key": "some_buildpack", "name": "some-buildpack", "version": "1.2.3" },
+						{ "name": "other-buildpack", "version": "4.5.6" }
+					]
+				},
+				"process_types": { "web": "some-command" }
+			}`), 0600)
+			Expect(err).NotTo(HaveOccurred())
+
+			collect = docker.NewCollect(&fakes.CollectClient{}, workspace)
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("returns the droplet that the app was staged into", func() {
+			droplet, err := collect.Droplet("some-app")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(droplet.Path).To(Equal(filepath.Join(workspace, "droplets", "some-app.tar.gz")))
+			Expect(droplet.Buildpacks).To(Equal([]docker.DropletBuildpack{
+				{Name: "some_buildpack", Version: "1.2.3"},
+				{Name: "other-buildpack", Version: "4.5.6"},
+			}))
+			Expect(droplet.ProcessTypes).To(Equal(map[string]string{"web": "some-command"}))
+			Expect(string(droplet.Metadata)).To(ContainSubstring(`"lifecycle_metadata"`))
+		})
+
+		context("failure cases", func() {
+			context("when the result.json does not exist", func() {
+				it.Before(func() {
+					Expect(os.RemoveAll(filepath.Join(workspace, "droplets"))).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := collect.Droplet("some-app")
+					Expect(err).To(MatchError(ContainSubstring("failed to read result.json:")))
+				})
+			})
+
+			context("when the result.json is malformed", func() {
+				it.Before(func() {
+					err := os.WriteFile(filepath.Join(workspace, "droplets", "some-app.json"), []byte("%%%"), 0600)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				it("returns an error", func() {
+					_, err := collect.Droplet("some-app")
+					Expect(err).To(MatchError(ContainSubstring("failed to parse result.json:")))
+				})
+			})
+		})
+	})
+}
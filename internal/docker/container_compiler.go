@@ -0,0 +1,207 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const DefaultGolangImage = "golang:latest"
+
+// This script mirrors the steps taken by the GoCompiler, which builds with the
+// same env, so that both produce the same binaries.
+const containerCompileScript = `set -e
+if [ ! -f go.mod ]; then
+  go mod init code.cloudfoundry.org/buildpackapplifecycle
+fi
+go mod tidy -compat "$(go env GOVERSION | sed -E 's/^go([0-9]+\.[0-9]+).*/\1/')"
+mkdir -p /workspace/output
+go build -o /workspace/output/builder ./builder
+go build -o /workspace/output/launcher ./launcher
+`
+
+//go:generate faux --interface ContainerCompilerClient --output fakes/container_compiler_client.go
+type ContainerCompilerClient interface {
+	ImageInspectWithRaw(ctx context.Context, imageID string) (types.ImageInspect, []byte, error)
+	ImagePull(ctx context.Context, ref string, options image.PullOptions) (io.ReadCloser, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.CreateResponse, error)
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
+	ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error)
+	CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+}
+
+// ContainerCompiler compiles the lifecycle inside of a golang container on
+// the Docker daemon so that the host does not need a Go toolchain.
+type ContainerCompiler struct {
+	client   ContainerCompilerClient
+	archiver Archiver
+	image    string
+}
+
+func NewContainerCompiler(client ContainerCompilerClient, archiver Archiver, image string) ContainerCompiler {
+	return ContainerCompiler{
+		client:   client,
+		archiver: archiver,
+		image:    image,
+	}
+}
+
+func (c ContainerCompiler) Compile(repo, output, arch string) error {
+	ctx := context.Background()
+
+	err := c.pull(ctx)
+	if err != nil {
+		return err
+	}
+
+	source := filepath.Join(filepath.Dir(repo), "repo.tar.gz")
+	err = c.archiver.WithPrefix("/workspace/repo").Compress(repo, source)
+	if err != nil {
+		return fmt.Errorf("failed to archive lifecycle repo: %w", err)
+	}
+	defer os.Remove(source)
+
+	resp, err := c.client.ContainerCreate(ctx, &container.Config{
+		Image:      c.image,
+		Cmd:        []string{"/bin/sh", "-c", containerCompileScript},
+		Env:        []string{"GOOS=linux", fmt.Sprintf("GOARCH=%s", arch), "CGO_ENABLED=0", "GOFLAGS=-buildvcs=false"},
+		WorkingDir: "/workspace/repo",
+	}, &container.HostConfig{}, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create golang container: %w", err)
+	}
+	defer c.client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+
+	tarball, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open lifecycle repo tarball: %w", err)
+	}
+	defer tarball.Close()
+
+	err = c.client.CopyToContainer(ctx, resp.ID, "/", tarball, container.CopyToContainerOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to copy lifecycle repo to container: %w", err)
+	}
+
+	err = c.client.ContainerStart(ctx, resp.ID, container.StartOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to start golang container: %w", err)
+	}
+
+	var status container.WaitResponse
+	onExit, onErr := c.client.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-onErr:
+		if err != nil {
+			return fmt.Errorf("failed to wait on golang container: %w", err)
+		}
+	case status = <-onExit:
+	}
+
+	if status.StatusCode != 0 {
+		buffer := bytes.NewBuffer(nil)
+		logs, err := c.client.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+		if err == nil {
+			_, _ = stdcopy.StdCopy(buffer, buffer, logs)
+			logs.Close()
+		}
+
+		return fmt.Errorf("failed to build lifecycle: golang container exited with non-zero status code (%d)\n\n%s", status.StatusCode, buffer)
+	}
+
+	binaries, _, err := c.client.CopyFromContainer(ctx, resp.ID, "/workspace/output")
+	if err != nil {
+		return fmt.Errorf("failed to copy lifecycle from container: %w", err)
+	}
+	defer binaries.Close()
+
+	err = os.MkdirAll(output, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	found := map[string]bool{}
+	tr := tar.NewReader(binaries)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to retrieve lifecycle from tarball: %w", err)
+		}
+
+		name := strings.TrimPrefix(hdr.Name, "output/")
+		if hdr.Typeflag != tar.TypeReg || (name != "builder" && name != "launcher") {
+			continue
+		}
+
+		file, err := os.OpenFile(filepath.Join(output, name), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+		if err != nil {
+			return fmt.Errorf("failed to create lifecycle %s: %w", name, err)
+		}
+
+		_, err = io.CopyN(file, tr, hdr.Size)
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("failed to copy lifecycle %s: %w", name, err)
+		}
+
+		err = file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to close lifecycle %s: %w", name, err)
+		}
+
+		found[name] = true
+	}
+
+	for _, name := range []string{"builder", "launcher"} {
+		if !found[name] {
+			return fmt.Errorf("failed to copy lifecycle from container: %s binary is missing", name)
+		}
+	}
+
+	return nil
+}
+
+// pull pulls the golang image unless it is already present, so that the
+// lifecycle can be compiled without network access once it has been pulled.
+func (c ContainerCompiler) pull(ctx context.Context) error {
+	_, _, err := c.client.ImageInspectWithRaw(ctx, c.image)
+	if err == nil {
+		return nil
+	}
+
+	if !client.IsErrNotFound(err) {
+		return fmt.Errorf("failed to inspect golang image: %w", err)
+	}
+
+	pullLogs, err := c.client.ImagePull(ctx, c.image, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull golang image: %w", err)
+	}
+	defer pullLogs.Close()
+
+	_, err = io.Copy(io.Discard, pullLogs)
+	if err != nil {
+		return fmt.Errorf("failed to copy image pull logs: %w", err)
+	}
+
+	return nil
+}
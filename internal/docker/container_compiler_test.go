@@ -0,0 +1,236 @@
+package docker_test
+
+import (
+	"archive/tar"
+	"bytes"
+	gocontext "context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testContainerCompiler(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Compile", func() {
+		var (
+			workspace string
+			client    *fakes.ContainerCompilerClient
+			archiver  *fakes.Archiver
+
+			copyToContainerInvocations []copyToContainerInvocation
+
+			compiler docker.ContainerCompiler
+		)
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.MkdirAll(filepath.Join(workspace, "repo"), os.ModePerm)).To(Succeed())
+
+			archiver = &fakes.Archiver{}
+			archiver.WithPrefixCall.Returns.Archiver = archiver
+			archiver.CompressCall.Stub = func(input, output string) error {
+				return os.WriteFile(output, []byte("repo-content"), 0600)
+			}
+
+			client = &fakes.ContainerCompilerClient{}
+			client.ImageInspectWithRawCall.Returns.Error = errdefs.NotFound(errors.New("no such image"))
+			client.ImagePullCall.Returns.ReadCloser = io.NopCloser(bytes.NewBuffer([]byte("Pulling image...\n")))
+			client.ContainerCreateCall.Returns.CreateResponse = container.CreateResponse{ID: "some-container-id"}
+			client.CopyToContainerCall.Stub = func(ctx gocontext.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+				b, err := io.ReadAll(content)
+				if err != nil {
+					return err
+				}
+
+				copyToContainerInvocations = append(copyToContainerInvocations, copyToContainerInvocation{
+					ContainerID: containerID,
+					DstPath:     dstPath,
+					Content:     string(b),
+				})
+
+				return nil
+			}
+
+			exit := make(chan container.WaitResponse, 1)
+			exit <- container.WaitResponse{StatusCode: 0}
+			client.ContainerWaitCall.Returns.WaitResponseChannel = exit
+
+			client.CopyFromContainerCall.Stub = func(ctx gocontext.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+				buffer := bytes.NewBuffer(nil)
+				tw := tar.NewWriter(buffer)
+				for _, file := range []string{"builder", "launcher"} {
+					content := []byte(file + "-binary")
+					err := tw.WriteHeader(&tar.Header{Name: "output/" + file, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+					if err != nil {
+						return nil, container.PathStat{}, err
+					}
+
+					_, err = tw.Write(content)
+					if err != nil {
+						return nil, container.PathStat{}, err
+					}
+				}
+
+				return io.NopCloser(buffer), container.PathStat{}, tw.Close()
+			}
+
+			compiler = docker.NewContainerCompiler(client, archiver, "some-golang-image")
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("builds the lifecycle in a golang container", func() {
+			err := compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), "arm64")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.ImageInspectWithRawCall.Receives.ImageID).To(Equal("some-golang-image"))
+			Expect(client.ImagePullCall.Receives.Ref).To(Equal("some-golang-image"))
+
+			Expect(archiver.WithPrefixCall.Receives.Prefix).To(Equal("/workspace/repo"))
+			Expect(archiver.CompressCall.Receives.Input).To(Equal(filepath.Join(workspace, "repo")))
+
+			Expect(client.ContainerCreateCall.Receives.Config.Image).To(Equal("some-golang-image"))
+			Expect(client.ContainerCreateCall.Receives.Config.WorkingDir).To(Equal("/workspace/repo"))
+			Expect(client.ContainerCreateCall.Receives.Config.Env).To(ContainElements("GOOS=linux", "GOARCH=arm64", "CGO_ENABLED=0", "GOFLAGS=-buildvcs=false"))
+			Expect(client.ContainerCreateCall.Receives.Config.Cmd[2]).To(ContainSubstring("go build -o /workspace/output/builder ./builder"))
+			Expect(client.ContainerCreateCall.Receives.Config.Cmd[2]).To(ContainSubstring("go build -o /workspace/output/launcher ./launcher"))
+
+			Expect(copyToContainerInvocations).To(Equal([]copyToContainerInvocation{
+				{
+					ContainerID: "some-container-id",
+					DstPath:     "/",
+					Content:     "repo-content",
+				},
+			}))
+
+			Expect(client.ContainerStartCall.Receives.ContainerID).To(Equal("some-container-id"))
+			Expect(client.CopyFromContainerCall.Receives.SrcPath).To(Equal("/workspace/output"))
+
+			content, err := os.ReadFile(filepath.Join(workspace, "output", "builder"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("builder-binary"))
+
+			content, err = os.ReadFile(filepath.Join(workspace, "output", "launcher"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("launcher-binary"))
+
+			Expect(client.ContainerRemoveCall.Receives.ContainerID).To(Equal("some-container-id"))
+			Expect(client.ContainerRemoveCall.Receives.Options).To(Equal(container.RemoveOptions{Force: true}))
+
+			Expect(filepath.Join(workspace, "repo.tar.gz")).NotTo(BeAnExistingFile())
+		})
+
+		context("when the image is already present", func() {
+			it.Before(func() {
+				client.ImageInspectWithRawCall.Returns.Error = nil
+			})
+
+			it("does not pull it again", func() {
+				err := compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), "amd64")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ImagePullCall.CallCount).To(Equal(0))
+				Expect(client.ContainerCreateCall.Receives.Config.Image).To(Equal("some-golang-image"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the image cannot be inspected", func() {
+				it.Before(func() {
+					client.ImageInspectWithRawCall.Returns.Error = errors.New("could not inspect image")
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), "amd64")
+					Expect(err).To(MatchError("failed to inspect golang image: could not inspect image"))
+					Expect(client.ImagePullCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the image cannot be pulled", func() {
+				it.Before(func() {
+					client.ImagePullCall.Returns.Error = errors.New("could not pull image")
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), "amd64")
+					Expect(err).To(MatchError("failed to pull golang image: could not pull image"))
+				})
+			})
+
+			context("when the repo cannot be archived", func() {
+				it.Before(func() {
+					archiver.CompressCall.Stub = nil
+					archiver.CompressCall.Returns.Error = errors.New("could not compress")
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), "amd64")
+					Expect(err).To(MatchError("failed to archive lifecycle repo: could not compress"))
+				})
+			})
+
+			context("when the container cannot be created", func() {
+				it.Before(func() {
+					client.ContainerCreateCall.Returns.Error = errors.New("could not create container")
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), "amd64")
+					Expect(err).To(MatchError("failed to create golang container: could not create container"))
+				})
+			})
+
+			context("when the build exits with a non-zero status", func() {
+				it.Before(func() {
+					exit := make(chan container.WaitResponse, 1)
+					exit <- container.WaitResponse{StatusCode: 1}
+					client.ContainerWaitCall.Returns.WaitResponseChannel = exit
+
+					logs := bytes.NewBuffer(nil)
+					_, err := stdcopy.NewStdWriter(logs, stdcopy.Stderr).Write([]byte("go: build failed\n"))
+					Expect(err).NotTo(HaveOccurred())
+					client.ContainerLogsCall.Returns.ReadCloser = io.NopCloser(logs)
+				})
+
+				it("returns an error with the build output", func() {
+					err := compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to build lifecycle: golang container exited with non-zero status code (1)")))
+					Expect(err).To(MatchError(ContainSubstring("go: build failed")))
+					Expect(client.ContainerRemoveCall.CallCount).To(Equal(1))
+				})
+			})
+
+			context("when the binaries are missing from the container", func() {
+				it.Before(func() {
+					client.CopyFromContainerCall.Stub = func(ctx gocontext.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+						buffer := bytes.NewBuffer(nil)
+						return io.NopCloser(buffer), container.PathStat{}, tar.NewWriter(buffer).Close()
+					}
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), "amd64")
+					Expect(err).To(MatchError("failed to copy lifecycle from container: builder binary is missing"))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,280 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/pkg/stdcopy"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	detectBeginMarker = "switchblade-detect-begin"
+	detectEndMarker   = "switchblade-detect-end"
+)
+
+type DetectPhase interface {
+	Run(ctx context.Context, name, path string, buildpacks ...string) ([]DetectResult, error)
+}
+
+//go:generate faux --interface DetectClient --output fakes/detect_client.go
+type DetectClient interface {
+	ImagePull(ctx context.Context, ref string, options image.PullOptions) (io.ReadCloser, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.CreateResponse, error)
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
+	ContainerLogs(ctx context.Context, container string, options container.LogsOptions) (io.ReadCloser, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+}
+
+// DetectResult is the outcome of running the bin/detect script of a
+// buildpack against an app, along with everything that the script printed.
+type DetectResult struct {
+	Buildpack string
+	Detected  bool
+	Output    string
+}
+
+// Detect runs the bin/detect script of each buildpack against an app in a
+// container of the stack image, without staging the app. Unlike staging, every
+// buildpack is run, even after one of them has detected.
+type Detect struct {
+	client       DetectClient
+	buildpacks   BuildpacksBuilder
+	archiver     Archiver
+	workspace    string
+	stack        string
+	arch         string
+	archResolver ArchitectureResolver
+}
+
+func NewDetect(client DetectClient, buildpacks BuildpacksBuilder, archiver Archiver, workspace, stack string) Detect {
+	return Detect{
+		client:     client,
+		buildpacks: buildpacks,
+		archiver:   archiver,
+		workspace:  workspace,
+		stack:      stack,
+	}
+}
+
+// WithArchitecture sets the CPU architecture that the stack image is pulled
+// and run as.
+func (d Detect) WithArchitecture(arch string) Detect {
+	d.arch = arch
+	return d
+}
+
+// WithArchitectureResolver resolves the architecture with the given resolver
+// when it is not set with WithArchitecture.
+func (d Detect) WithArchitectureResolver(resolver ArchitectureResolver) Detect {
+	d.archResolver = resolver
+	return d
+}
+
+// Run detects the app at the given path with the given buildpacks, or with
+// every buildpack for the stack when none are given, in the order in which
+// staging would try them.
+func (d Detect) Run(ctx context.Context, name, path string, buildpacks ...string) ([]DetectResult, error) {
+	d.arch = d.archResolver.Resolve(ctx, d.arch)
+
+	builder := d.buildpacks.WithStack(d.stack)
+	if len(buildpacks) > 0 {
+		builder = builder.WithBuildpacks(buildpacks...)
+	}
+
+	order, _, err := builder.Order()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine buildpack ordering: %w", err)
+	}
+
+	if order == "" {
+		return nil, nil
+	}
+	names := strings.Split(order, ",")
+
+	source := filepath.Join(d.workspace, "source", fmt.Sprintf("%s.tar.gz", name))
+
+	// The buildpacks and source are only needed for as long as the container
+	// runs, so they are not left behind for a teardown to remove.
+	defer func() {
+		_ = os.RemoveAll(filepath.Join(d.workspace, "buildpacks", name))
+		_ = os.Remove(filepath.Join(d.workspace, "buildpacks", fmt.Sprintf("%s.tar.gz", name)))
+		_ = os.Remove(source)
+	}()
+
+	tarball, err := builder.Build(filepath.Join(d.workspace, "buildpacks"), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build buildpacks: %w", err)
+	}
+
+	// Buildpacks that the registry does not know are left out of the build,
+	// so they are reported here rather than as not detecting the app.
+	var unknown []string
+	for _, bpName := range names {
+		_, err = os.Stat(filepath.Join(d.workspace, "buildpacks", name, fmt.Sprintf("%x", md5.Sum([]byte(bpName)))))
+		if err != nil {
+			unknown = append(unknown, bpName)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("failed to detect: unknown buildpacks %s; provide them with Platform.Initialize", strings.Join(unknown, ", "))
+	}
+
+	err = d.archiver.WithPrefix("/tmp/app").Compress(path, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive source code: %w", err)
+	}
+
+	ref := fmt.Sprintf("cloudfoundry/%s:latest", d.stack)
+	pullLogs, err := d.client.ImagePull(ctx, ref, image.PullOptions{
+		Platform: fmt.Sprintf("linux/%s", d.arch),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull base image: %w", err)
+	}
+	defer pullLogs.Close()
+
+	_, err = io.Copy(io.Discard, pullLogs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy image pull logs: %w", err)
+	}
+
+	// Each buildpack is extracted into a directory named after the MD5 of its
+	// name, which is where the lifecycle builder expects to find it too.
+	script := bytes.NewBuffer(nil)
+	for i, bpName := range names {
+		fmt.Fprintf(script, "echo '%s %d'\n", detectBeginMarker, i)
+		fmt.Fprintf(script, "/tmp/buildpacks/%x/bin/detect /tmp/app 2>&1\n", md5.Sum([]byte(bpName)))
+		fmt.Fprintf(script, "printf '\\n%s %d %%d\\n' $?\n", detectEndMarker, i)
+	}
+
+	containerConfig := container.Config{
+		Image:      ref,
+		Cmd:        []string{"/bin/bash", "-c", script.String()},
+		User:       "vcap",
+		Env:        []string{fmt.Sprintf("CF_STACK=%s", d.stack)},
+		WorkingDir: "/home/vcap",
+	}
+
+	hostConfig := container.HostConfig{
+		NetworkMode: container.NetworkMode("none"),
+	}
+
+	resp, err := d.client.ContainerCreate(ctx, &containerConfig, &hostConfig, nil, platformFor(d.arch), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create detect container: %w", err)
+	}
+	defer func() {
+		_ = d.client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+	}()
+
+	for _, tarballPath := range []string{tarball, source} {
+		file, err := os.Open(tarballPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tarball: %w", err)
+		}
+
+		err = d.client.CopyToContainer(ctx, resp.ID, "/", file, container.CopyToContainerOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy tarball to container: %w", err)
+		}
+
+		err = file.Close()
+		if err != nil && !errors.Is(err, os.ErrClosed) {
+			return nil, fmt.Errorf("failed to close tarball: %w", err)
+		}
+	}
+
+	err = d.client.ContainerStart(ctx, resp.ID, container.StartOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+
+	var status container.WaitResponse
+	onExit, onErr := d.client.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-onErr:
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait on container: %w", err)
+		}
+	case status = <-onExit:
+	}
+
+	containerLogs, err := d.client.ContainerLogs(ctx, resp.ID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch container logs: %w", err)
+	}
+	defer containerLogs.Close()
+
+	output := bytes.NewBuffer(nil)
+	_, err = stdcopy.StdCopy(output, output, containerLogs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy container logs: %w", err)
+	}
+
+	if status.StatusCode != 0 {
+		return nil, fmt.Errorf("detect container exited with non-zero status code (%d)\n\nOutput:\n%s", status.StatusCode, output)
+	}
+
+	return parseDetectOutput(names, output.String())
+}
+
+// parseDetectOutput splits the output of the detect container into the
+// output and exit code of each buildpack, using the markers that the script
+// printed around them.
+func parseDetectOutput(names []string, output string) ([]DetectResult, error) {
+	results := make([]DetectResult, len(names))
+	for i, bpName := range names {
+		results[i].Buildpack = bpName
+	}
+
+	finished := make([]bool, len(names))
+
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, detectBeginMarker) {
+			lines = nil
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, detectEndMarker); ok {
+			var index, code int
+			_, err := fmt.Sscanf(rest, "%d %d", &index, &code)
+			if err != nil || index < 0 || index >= len(names) {
+				return nil, fmt.Errorf("failed to parse detect output: malformed marker %q", line)
+			}
+
+			results[index].Detected = code == 0
+			results[index].Output = strings.TrimSpace(strings.Join(lines, "\n"))
+			finished[index] = true
+			lines = nil
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	for i, ok := range finished {
+		if !ok {
+			return nil, fmt.Errorf("failed to parse detect output: missing result for %s\n\nOutput:\n%s", names[i], output)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,295 @@
+package docker_test
+
+import (
+	"bytes"
+	gocontext "context"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testDetect(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Run", func() {
+		var (
+			detect docker.Detect
+
+			client            *fakes.DetectClient
+			buildpacksBuilder *fakes.BuildpacksBuilder
+			archiver          *fakes.Archiver
+			workspace         string
+
+			copyToContainerInvocations []copyToContainerInvocation
+		)
+
+		setContainerOutput := func(output string) {
+			containerLogs := bytes.NewBuffer(nil)
+			_, err := stdcopy.NewStdWriter(containerLogs, stdcopy.Stdout).Write([]byte(output))
+			Expect(err).NotTo(HaveOccurred())
+
+			client.ContainerLogsCall.Returns.ReadCloser = io.NopCloser(containerLogs)
+		}
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			buildpacksBuilder = &fakes.BuildpacksBuilder{}
+			buildpacksBuilder.WithStackCall.Returns.BuildpacksBuilder = buildpacksBuilder
+			buildpacksBuilder.WithBuildpacksCall.Returns.BuildpacksBuilder = buildpacksBuilder
+			buildpacksBuilder.OrderCall.Returns.Order = "some-buildpack,other-buildpack"
+			buildpacksBuilder.BuildCall.Returns.Path = filepath.Join(workspace, "buildpacks", "some-app.tar.gz")
+			for _, name := range []string{"some-buildpack", "other-buildpack"} {
+				Expect(os.MkdirAll(filepath.Join(workspace, "buildpacks", "some-app", fmt.Sprintf("%x", md5.Sum([]byte(name)))), os.ModePerm)).To(Succeed())
+			}
+			Expect(os.WriteFile(filepath.Join(workspace, "buildpacks", "some-app.tar.gz"), []byte("buildpacks-content"), 0600)).To(Succeed())
+
+			archiver = &fakes.Archiver{}
+			archiver.WithPrefixCall.Returns.Archiver = archiver
+			Expect(os.MkdirAll(filepath.Join(workspace, "source"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "source", "some-app.tar.gz"), []byte("app-content"), 0600)).To(Succeed())
+
+			client = &fakes.DetectClient{}
+			client.ImagePullCall.Returns.ReadCloser = io.NopCloser(bytes.NewBuffer([]byte("Pulling image...\n")))
+			client.ContainerCreateCall.Returns.CreateResponse = container.CreateResponse{ID: "some-container-id"}
+			client.CopyToContainerCall.Stub = func(ctx gocontext.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+				b, err := io.ReadAll(content)
+				if err != nil {
+					return err
+				}
+
+				copyToContainerInvocations = append(copyToContainerInvocations, copyToContainerInvocation{
+					ContainerID: containerID,
+					DstPath:     dstPath,
+					Content:     string(b),
+				})
+
+				return nil
+			}
+
+			containerWaitOKBodyChannel := make(chan container.WaitResponse)
+			close(containerWaitOKBodyChannel)
+			client.ContainerWaitCall.Returns.WaitResponseChannel = containerWaitOKBodyChannel
+
+			setContainerOutput(fmt.Sprintf(
+				"switchblade-detect-begin 0\nsome-buildpack 1.2.3\n\nswitchblade-detect-end 0 0\n%s\nno\nswitchblade-detect-end 1 1\n",
+				"switchblade-detect-begin 1",
+			))
+
+			detect = docker.NewDetect(client, buildpacksBuilder, archiver, workspace, "default-stack")
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("runs the detect script of each buildpack", func() {
+			ctx := gocontext.Background()
+
+			results, err := detect.Run(ctx, "some-app", "/some/path/to/my/app", "some-buildpack", "other-buildpack")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(Equal([]docker.DetectResult{
+				{Buildpack: "some-buildpack", Detected: true, Output: "some-buildpack 1.2.3"},
+				{Buildpack: "other-buildpack", Detected: false, Output: "no"},
+			}))
+
+			Expect(buildpacksBuilder.WithStackCall.Receives.Stack).To(Equal("default-stack"))
+			Expect(buildpacksBuilder.WithBuildpacksCall.Receives.Buildpacks).To(Equal([]string{"some-buildpack", "other-buildpack"}))
+			Expect(buildpacksBuilder.BuildCall.Receives.Workspace).To(Equal(filepath.Join(workspace, "buildpacks")))
+			Expect(buildpacksBuilder.BuildCall.Receives.Name).To(Equal("some-app"))
+
+			Expect(archiver.WithPrefixCall.Receives.Prefix).To(Equal("/tmp/app"))
+			Expect(archiver.CompressCall.Receives.Input).To(Equal("/some/path/to/my/app"))
+			Expect(archiver.CompressCall.Receives.Output).To(Equal(filepath.Join(workspace, "source", "some-app.tar.gz")))
+
+			Expect(client.ImagePullCall.Receives.Ref).To(Equal("cloudfoundry/default-stack:latest"))
+			Expect(client.ImagePullCall.Receives.Options.Platform).To(Equal("linux/amd64"))
+
+			Expect(client.ContainerCreateCall.Receives.ContainerName).To(Equal("some-app"))
+			Expect(client.ContainerCreateCall.Receives.Config.Image).To(Equal("cloudfoundry/default-stack:latest"))
+			Expect(client.ContainerCreateCall.Receives.Config.Env).To(Equal([]string{"CF_STACK=default-stack"}))
+			Expect(client.ContainerCreateCall.Receives.Config.Cmd).To(HaveLen(3))
+			Expect(client.ContainerCreateCall.Receives.Config.Cmd[2]).To(ContainSubstring(
+				fmt.Sprintf("/tmp/buildpacks/%x/bin/detect /tmp/app", md5.Sum([]byte("some-buildpack"))),
+			))
+			Expect(client.ContainerCreateCall.Receives.Config.Cmd[2]).To(ContainSubstring(
+				fmt.Sprintf("/tmp/buildpacks/%x/bin/detect /tmp/app", md5.Sum([]byte("other-buildpack"))),
+			))
+			Expect(client.ContainerCreateCall.Receives.HostConfig.NetworkMode).To(Equal(container.NetworkMode("none")))
+			Expect(client.ContainerCreateCall.Receives.Platform).To(Equal(&specs.Platform{
+				OS:           "linux",
+				Architecture: "amd64",
+			}))
+
+			Expect(copyToContainerInvocations).To(Equal([]copyToContainerInvocation{
+				{ContainerID: "some-container-id", DstPath: "/", Content: "buildpacks-content"},
+				{ContainerID: "some-container-id", DstPath: "/", Content: "app-content"},
+			}))
+
+			Expect(client.ContainerStartCall.Receives.ContainerID).To(Equal("some-container-id"))
+			Expect(client.ContainerRemoveCall.Receives.ContainerID).To(Equal("some-container-id"))
+			Expect(client.ContainerRemoveCall.Receives.Options.Force).To(BeTrue())
+
+			Expect(filepath.Join(workspace, "buildpacks", "some-app.tar.gz")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(workspace, "source", "some-app.tar.gz")).NotTo(BeAnExistingFile())
+		})
+
+		context("when no buildpacks are given", func() {
+			it("detects with every buildpack for the stack", func() {
+				_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buildpacksBuilder.WithBuildpacksCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("WithArchitecture", func() {
+			it("runs the stack for that architecture", func() {
+				_, err := detect.
+					WithArchitecture("arm64").
+					Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ImagePullCall.Receives.Options.Platform).To(Equal("linux/arm64"))
+				Expect(client.ContainerCreateCall.Receives.Platform.Architecture).To(Equal("arm64"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the buildpacks cannot be ordered", func() {
+				it.Before(func() {
+					buildpacksBuilder.OrderCall.Returns.Err = errors.New("could not list buildpacks")
+				})
+
+				it("returns an error", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to determine buildpack ordering: could not list buildpacks"))
+				})
+			})
+
+			context("when the buildpacks cannot be built", func() {
+				it.Before(func() {
+					buildpacksBuilder.BuildCall.Returns.Err = errors.New("could not build buildpacks")
+				})
+
+				it("returns an error", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to build buildpacks: could not build buildpacks"))
+				})
+			})
+
+			context("when a buildpack is unknown", func() {
+				it.Before(func() {
+					buildpacksBuilder.OrderCall.Returns.Order = "some-buildpack,unknown-buildpack,other-unknown-buildpack"
+				})
+
+				it("returns an error before running the detect scripts", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app", "some-buildpack", "unknown-buildpack", "other-unknown-buildpack")
+					Expect(err).To(MatchError("failed to detect: unknown buildpacks unknown-buildpack, other-unknown-buildpack; provide them with Platform.Initialize"))
+
+					Expect(client.ContainerCreateCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the source cannot be archived", func() {
+				it.Before(func() {
+					archiver.CompressCall.Returns.Error = errors.New("could not archive source")
+				})
+
+				it("returns an error", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to archive source code: could not archive source"))
+				})
+			})
+
+			context("when the image cannot be pulled", func() {
+				it.Before(func() {
+					client.ImagePullCall.Returns.Error = errors.New("could not pull image")
+				})
+
+				it("returns an error", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to pull base image: could not pull image"))
+				})
+			})
+
+			context("when the container cannot be created", func() {
+				it.Before(func() {
+					client.ContainerCreateCall.Returns.Error = errors.New("could not create container")
+				})
+
+				it("returns an error", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to create detect container: could not create container"))
+				})
+			})
+
+			context("when the container cannot be started", func() {
+				it.Before(func() {
+					client.ContainerStartCall.Returns.Error = errors.New("could not start container")
+				})
+
+				it("returns an error and removes the container", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError("failed to start container: could not start container"))
+
+					Expect(client.ContainerRemoveCall.Receives.ContainerID).To(Equal("some-container-id"))
+				})
+			})
+
+			context("when the container exits with a non-zero status", func() {
+				it.Before(func() {
+					containerWaitOKBodyChannel := make(chan container.WaitResponse, 1)
+					containerWaitOKBodyChannel <- container.WaitResponse{StatusCode: 1}
+					close(containerWaitOKBodyChannel)
+					client.ContainerWaitCall.Returns.WaitResponseChannel = containerWaitOKBodyChannel
+
+					setContainerOutput("/bin/bash: not found\n")
+				})
+
+				it("returns an error", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("detect container exited with non-zero status code (1)")))
+					Expect(err).To(MatchError(ContainSubstring("/bin/bash: not found")))
+				})
+			})
+
+			context("when the output is missing the result of a buildpack", func() {
+				it.Before(func() {
+					setContainerOutput("switchblade-detect-begin 0\nsome-buildpack\n\nswitchblade-detect-end 0 0\n")
+				})
+
+				it("returns an error", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring("failed to parse detect output: missing result for other-buildpack")))
+				})
+			})
+
+			context("when the output has a malformed marker", func() {
+				it.Before(func() {
+					setContainerOutput("switchblade-detect-end 7 0\n")
+				})
+
+				it("returns an error", func() {
+					_, err := detect.Run(gocontext.Background(), "some-app", "/some/path/to/my/app")
+					Expect(err).To(MatchError(ContainSubstring(`failed to parse detect output: malformed marker "switchblade-detect-end 7 0"`)))
+				})
+			})
+		})
+	})
+}
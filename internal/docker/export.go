@@ -0,0 +1,146 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type ExportPhase interface {
+	Run(ctx context.Context, name, droplet, tag string, omit ...string) error
+}
+
+//go:generate faux --interface ExportClient --output fakes/export_client.go
+type ExportClient interface {
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.CreateResponse, error)
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+	ContainerCommit(ctx context.Context, container string, options container.CommitOptions) (types.IDResponse, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+}
+
+// Export builds a local image of an app out of the stack image, the lifecycle
+// launcher and the droplet that the app was started with. The image has the
+// same env, except for the variables that are left out because they hold
+// secrets, and start command as the app container, so that running it
+// reproduces the app without staging it again.
+type Export struct {
+	client       ExportClient
+	workspace    string
+	arch         string
+	archResolver ArchitectureResolver
+}
+
+func NewExport(client ExportClient, workspace string) Export {
+	return Export{
+		client:    client,
+		workspace: workspace,
+	}
+}
+
+// WithArchitecture sets the CPU architecture that the image is built for. It
+// must match the architecture the lifecycle was built for during setup.
+func (e Export) WithArchitecture(arch string) Export {
+	e.arch = arch
+	return e
+}
+
+// WithArchitectureResolver resolves the architecture with the given resolver
+// when it is not set with WithArchitecture.
+func (e Export) WithArchitectureResolver(resolver ArchitectureResolver) Export {
+	e.archResolver = resolver
+	return e
+}
+
+// Run exports the app with the given name as an image with the given tag. The
+// droplet is the one at the given path, or the one that was staged for the
+// app when the path is empty. The env variables with the given names are left
+// out of the image.
+func (e Export) Run(ctx context.Context, name, droplet, tag string, omit ...string) error {
+	e.arch = e.archResolver.Resolve(ctx, e.arch)
+
+	app, err := e.client.ContainerInspect(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to inspect app container: %w", err)
+	}
+
+	if app.Config == nil {
+		return fmt.Errorf("failed to inspect app container: %s has no config", name)
+	}
+
+	if droplet == "" {
+		droplet = filepath.Join(e.workspace, "droplets", fmt.Sprintf("%s.tar.gz", name))
+	}
+
+	omitted := map[string]bool{}
+	for _, key := range omit {
+		omitted[key] = true
+	}
+
+	var env []string
+	for _, variable := range app.Config.Env {
+		key, _, _ := strings.Cut(variable, "=")
+		if !omitted[key] {
+			env = append(env, variable)
+		}
+	}
+
+	// The image is built from a container that is never started, so that it
+	// does not include anything that the app wrote while it was running.
+	containerConfig := container.Config{
+		Image:        app.Config.Image,
+		Cmd:          app.Config.Cmd,
+		User:         app.Config.User,
+		Env:          env,
+		WorkingDir:   app.Config.WorkingDir,
+		ExposedPorts: app.Config.ExposedPorts,
+	}
+
+	resp, err := e.client.ContainerCreate(ctx, &containerConfig, &container.HostConfig{}, nil, platformFor(e.arch), "")
+	if err != nil {
+		return fmt.Errorf("failed to create export container: %w", err)
+	}
+	defer func() {
+		_ = e.client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true})
+	}()
+
+	lifecycleTarball, err := os.Open(filepath.Join(e.workspace, "lifecycle", e.arch, "lifecycle.tar.gz"))
+	if err != nil {
+		return fmt.Errorf("failed to open lifecycle: %w", err)
+	}
+	defer lifecycleTarball.Close()
+
+	err = e.client.CopyToContainer(ctx, resp.ID, "/", lifecycleTarball, container.CopyToContainerOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to copy lifecycle into container: %w", err)
+	}
+
+	dropletTarball, err := os.Open(droplet)
+	if err != nil {
+		return fmt.Errorf("failed to open droplet: %w", err)
+	}
+	defer dropletTarball.Close()
+
+	err = e.client.CopyToContainer(ctx, resp.ID, "/home/vcap/", dropletTarball, container.CopyToContainerOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to copy droplet into container: %w", err)
+	}
+
+	_, err = e.client.ContainerCommit(ctx, resp.ID, container.CommitOptions{
+		Reference: tag,
+		Config:    &containerConfig,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit image: %w", err)
+	}
+
+	return nil
+}
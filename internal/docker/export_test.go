@@ -0,0 +1,218 @@
+package docker_test
+
+import (
+	gocontext "context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testExport(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Run", func() {
+		var (
+			export docker.Export
+
+			client    *fakes.ExportClient
+			workspace string
+
+			copyToContainerInvocations []copyToContainerInvocation
+		)
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle", "amd64"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "amd64", "lifecycle.tar.gz"), []byte("lifecycle-content"), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workspace, "droplets"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "droplets", "some-app.tar.gz"), []byte("droplet-content"), 0600)).To(Succeed())
+
+			client = &fakes.ExportClient{}
+			client.ContainerInspectCall.Returns.ContainerJSON = types.ContainerJSON{
+				Config: &container.Config{
+					Hostname:     "some-hostname",
+					Image:        "cloudfoundry/some-stack:latest",
+					Cmd:          []string{"/tmp/lifecycle/launcher", "app", "some-command", ""},
+					User:         "vcap",
+					Env:          []string{"SOME_KEY=some-value"},
+					WorkingDir:   "/home/vcap",
+					ExposedPorts: nat.PortSet{"8080/tcp": struct{}{}},
+				},
+			}
+			client.ContainerCreateCall.Returns.CreateResponse = container.CreateResponse{ID: "some-container-id"}
+			client.CopyToContainerCall.Stub = func(ctx gocontext.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+				b, err := io.ReadAll(content)
+				if err != nil {
+					return err
+				}
+
+				copyToContainerInvocations = append(copyToContainerInvocations, copyToContainerInvocation{
+					ContainerID: containerID,
+					DstPath:     dstPath,
+					Content:     string(b),
+				})
+
+				return nil
+			}
+
+			export = docker.NewExport(client, workspace)
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("commits an image of the app", func() {
+			ctx := gocontext.Background()
+
+			err := export.Run(ctx, "some-app", "", "some-image:some-tag")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(client.ContainerInspectCall.Receives.ContainerID).To(Equal("some-app"))
+
+			config := container.Config{
+				Image:        "cloudfoundry/some-stack:latest",
+				Cmd:          []string{"/tmp/lifecycle/launcher", "app", "some-command", ""},
+				User:         "vcap",
+				Env:          []string{"SOME_KEY=some-value"},
+				WorkingDir:   "/home/vcap",
+				ExposedPorts: nat.PortSet{"8080/tcp": struct{}{}},
+			}
+
+			Expect(client.ContainerCreateCall.Receives.Config).To(Equal(&config))
+			Expect(client.ContainerCreateCall.Receives.Platform).To(Equal(&specs.Platform{OS: "linux", Architecture: "amd64"}))
+			Expect(client.ContainerCreateCall.Receives.ContainerName).To(Equal(""))
+
+			Expect(copyToContainerInvocations).To(Equal([]copyToContainerInvocation{
+				{
+					ContainerID: "some-container-id",
+					DstPath:     "/",
+					Content:     "lifecycle-content",
+				},
+				{
+					ContainerID: "some-container-id",
+					DstPath:     "/home/vcap/",
+					Content:     "droplet-content",
+				},
+			}))
+
+			Expect(client.ContainerCommitCall.Receives.Container).To(Equal("some-container-id"))
+			Expect(client.ContainerCommitCall.Receives.Options).To(Equal(container.CommitOptions{
+				Reference: "some-image:some-tag",
+				Config:    &config,
+			}))
+
+			Expect(client.ContainerRemoveCall.Receives.ContainerID).To(Equal("some-container-id"))
+			Expect(client.ContainerRemoveCall.Receives.Options).To(Equal(container.RemoveOptions{Force: true}))
+		})
+
+		context("when env variables are omitted", func() {
+			it.Before(func() {
+				client.ContainerInspectCall.Returns.ContainerJSON.Config.Env = []string{
+					"SOME_KEY=some-value",
+					"SECRET_KEY=secret-value",
+					`VCAP_SERVICES={"user-provided":[]}`,
+				}
+			})
+
+			it("leaves them out of the image", func() {
+				err := export.Run(gocontext.Background(), "some-app", "", "some-image:some-tag", "SECRET_KEY", "VCAP_SERVICES")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ContainerCreateCall.Receives.Config.Env).To(Equal([]string{"SOME_KEY=some-value"}))
+				Expect(client.ContainerCommitCall.Receives.Options.Config.Env).To(Equal([]string{"SOME_KEY=some-value"}))
+			})
+		})
+
+		context("when a droplet is given", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workspace, "other-droplet.tgz"), []byte("other-droplet-content"), 0600)).To(Succeed())
+			})
+
+			it("commits that droplet into the image", func() {
+				err := export.Run(gocontext.Background(), "some-app", filepath.Join(workspace, "other-droplet.tgz"), "some-image:some-tag")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(copyToContainerInvocations).To(ContainElement(copyToContainerInvocation{
+					ContainerID: "some-container-id",
+					DstPath:     "/home/vcap/",
+					Content:     "other-droplet-content",
+				}))
+			})
+		})
+
+		context("WithArchitecture", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle", "arm64"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "arm64", "lifecycle.tar.gz"), []byte("arm64-lifecycle-content"), 0600)).To(Succeed())
+			})
+
+			it("commits an image for that architecture", func() {
+				err := export.WithArchitecture("arm64").Run(gocontext.Background(), "some-app", "", "some-image:some-tag")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ContainerCreateCall.Receives.Platform).To(Equal(&specs.Platform{OS: "linux", Architecture: "arm64"}))
+				Expect(copyToContainerInvocations[0].Content).To(Equal("arm64-lifecycle-content"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the app container cannot be inspected", func() {
+				it.Before(func() {
+					client.ContainerInspectCall.Returns.Error = errors.New("no such container")
+				})
+
+				it("returns an error", func() {
+					err := export.Run(gocontext.Background(), "some-app", "", "some-image:some-tag")
+					Expect(err).To(MatchError("failed to inspect app container: no such container"))
+				})
+			})
+
+			context("when the container cannot be created", func() {
+				it.Before(func() {
+					client.ContainerCreateCall.Returns.Error = errors.New("could not create container")
+				})
+
+				it("returns an error", func() {
+					err := export.Run(gocontext.Background(), "some-app", "", "some-image:some-tag")
+					Expect(err).To(MatchError("failed to create export container: could not create container"))
+				})
+			})
+
+			context("when the droplet does not exist", func() {
+				it("returns an error and removes the container", func() {
+					err := export.Run(gocontext.Background(), "some-app", filepath.Join(workspace, "no-such-droplet.tgz"), "some-image:some-tag")
+					Expect(err).To(MatchError(ContainSubstring("failed to open droplet:")))
+					Expect(client.ContainerRemoveCall.CallCount).To(Equal(1))
+				})
+			})
+
+			context("when the image cannot be committed", func() {
+				it.Before(func() {
+					client.ContainerCommitCall.Returns.Error = errors.New("could not commit container")
+				})
+
+				it("returns an error", func() {
+					err := export.Run(gocontext.Background(), "some-app", "", "some-image:some-tag")
+					Expect(err).To(MatchError("failed to commit image: could not commit container"))
+				})
+			})
+		})
+	})
+}
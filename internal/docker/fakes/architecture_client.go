@@ -0,0 +1,34 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+)
+
+type ArchitectureClient struct {
+	ServerVersionCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx context.Context
+		}
+		Returns struct {
+			Version types.Version
+			Error   error
+		}
+		Stub func(context.Context) (types.Version, error)
+	}
+}
+
+func (f *ArchitectureClient) ServerVersion(param1 context.Context) (types.Version, error) {
+	f.ServerVersionCall.mutex.Lock()
+	defer f.ServerVersionCall.mutex.Unlock()
+	f.ServerVersionCall.CallCount++
+	f.ServerVersionCall.Receives.Ctx = param1
+	if f.ServerVersionCall.Stub != nil {
+		return f.ServerVersionCall.Stub(param1)
+	}
+	return f.ServerVersionCall.Returns.Version, f.ServerVersionCall.Returns.Error
+}
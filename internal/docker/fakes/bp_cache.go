@@ -10,23 +10,25 @@ type BPCache struct {
 		mutex     sync.Mutex
 		CallCount int
 		Receives  struct {
-			Url string
+			Url      string
+			Checksum string
 		}
 		Returns struct {
 			ReadCloser io.ReadCloser
 			Error      error
 		}
-		Stub func(string) (io.ReadCloser, error)
+		Stub func(string, string) (io.ReadCloser, error)
 	}
 }
 
-func (f *BPCache) Fetch(param1 string) (io.ReadCloser, error) {
+func (f *BPCache) Fetch(param1 string, param2 string) (io.ReadCloser, error) {
 	f.FetchCall.mutex.Lock()
 	defer f.FetchCall.mutex.Unlock()
 	f.FetchCall.CallCount++
 	f.FetchCall.Receives.Url = param1
+	f.FetchCall.Receives.Checksum = param2
 	if f.FetchCall.Stub != nil {
-		return f.FetchCall.Stub(param1)
+		return f.FetchCall.Stub(param1, param2)
 	}
 	return f.FetchCall.Returns.ReadCloser, f.FetchCall.Returns.Error
 }
@@ -10,11 +10,15 @@ type BPRegistry struct {
 	ListCall struct {
 		mutex     sync.Mutex
 		CallCount int
-		Returns   struct {
+		Receives  struct {
+			Stack string
+			Names []string
+		}
+		Returns struct {
 			BuildpackSlice []docker.Buildpack
 			Error          error
 		}
-		Stub func() ([]docker.Buildpack, error)
+		Stub func(string, ...string) ([]docker.Buildpack, error)
 	}
 	OverrideCall struct {
 		mutex     sync.Mutex
@@ -26,12 +30,14 @@ type BPRegistry struct {
 	}
 }
 
-func (f *BPRegistry) List() ([]docker.Buildpack, error) {
+func (f *BPRegistry) List(param1 string, param2 ...string) ([]docker.Buildpack, error) {
 	f.ListCall.mutex.Lock()
 	defer f.ListCall.mutex.Unlock()
 	f.ListCall.CallCount++
+	f.ListCall.Receives.Stack = param1
+	f.ListCall.Receives.Names = param2
 	if f.ListCall.Stub != nil {
-		return f.ListCall.Stub()
+		return f.ListCall.Stub(param1, param2...)
 	}
 	return f.ListCall.Returns.BuildpackSlice, f.ListCall.Returns.Error
 }
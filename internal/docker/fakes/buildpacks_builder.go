@@ -41,6 +41,17 @@ type BuildpacksBuilder struct {
 		}
 		Stub func(...string) docker.BuildpacksBuilder
 	}
+	WithStackCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Stack string
+		}
+		Returns struct {
+			BuildpacksBuilder docker.BuildpacksBuilder
+		}
+		Stub func(string) docker.BuildpacksBuilder
+	}
 }
 
 func (f *BuildpacksBuilder) Build(param1 string, param2 string) (string, error) {
@@ -73,3 +84,13 @@ func (f *BuildpacksBuilder) WithBuildpacks(param1 ...string) docker.BuildpacksBu
 	}
 	return f.WithBuildpacksCall.Returns.BuildpacksBuilder
 }
+func (f *BuildpacksBuilder) WithStack(param1 string) docker.BuildpacksBuilder {
+	f.WithStackCall.mutex.Lock()
+	defer f.WithStackCall.mutex.Unlock()
+	f.WithStackCall.CallCount++
+	f.WithStackCall.Receives.Stack = param1
+	if f.WithStackCall.Stub != nil {
+		return f.WithStackCall.Stub(param1)
+	}
+	return f.WithStackCall.Returns.BuildpacksBuilder
+}
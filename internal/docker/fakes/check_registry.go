@@ -0,0 +1,29 @@
+package fakes
+
+import "sync"
+
+type CheckRegistry struct {
+	UnresolvedCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Stack string
+		}
+		Returns struct {
+			StringSlice []string
+			Error       error
+		}
+		Stub func(string) ([]string, error)
+	}
+}
+
+func (f *CheckRegistry) Unresolved(param1 string) ([]string, error) {
+	f.UnresolvedCall.mutex.Lock()
+	defer f.UnresolvedCall.mutex.Unlock()
+	f.UnresolvedCall.CallCount++
+	f.UnresolvedCall.Receives.Stack = param1
+	if f.UnresolvedCall.Stub != nil {
+		return f.UnresolvedCall.Stub(param1)
+	}
+	return f.UnresolvedCall.Returns.StringSlice, f.UnresolvedCall.Returns.Error
+}
@@ -0,0 +1,36 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+)
+
+type CollectClient struct {
+	ContainerInspectCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+		}
+		Returns struct {
+			ContainerJSON types.ContainerJSON
+			Error         error
+		}
+		Stub func(context.Context, string) (types.ContainerJSON, error)
+	}
+}
+
+func (f *CollectClient) ContainerInspect(param1 context.Context, param2 string) (types.ContainerJSON, error) {
+	f.ContainerInspectCall.mutex.Lock()
+	defer f.ContainerInspectCall.mutex.Unlock()
+	f.ContainerInspectCall.CallCount++
+	f.ContainerInspectCall.Receives.Ctx = param1
+	f.ContainerInspectCall.Receives.ContainerID = param2
+	if f.ContainerInspectCall.Stub != nil {
+		return f.ContainerInspectCall.Stub(param1, param2)
+	}
+	return f.ContainerInspectCall.Returns.ContainerJSON, f.ContainerInspectCall.Returns.Error
+}
@@ -0,0 +1,259 @@
+package fakes
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type ContainerCompilerClient struct {
+	ContainerCreateCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx              context.Context
+			Config           *container.Config
+			HostConfig       *container.HostConfig
+			NetworkingConfig *network.NetworkingConfig
+			Platform         *specs.Platform
+			ContainerName    string
+		}
+		Returns struct {
+			CreateResponse container.CreateResponse
+			Error          error
+		}
+		Stub func(context.Context, *container.Config, *container.HostConfig, *network.NetworkingConfig, *specs.Platform, string) (container.CreateResponse, error)
+	}
+	ContainerLogsCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx       context.Context
+			Container string
+			Options   container.LogsOptions
+		}
+		Returns struct {
+			ReadCloser io.ReadCloser
+			Error      error
+		}
+		Stub func(context.Context, string, container.LogsOptions) (io.ReadCloser, error)
+	}
+	ContainerRemoveCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+			Options     container.RemoveOptions
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(context.Context, string, container.RemoveOptions) error
+	}
+	ContainerStartCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+			Options     container.StartOptions
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(context.Context, string, container.StartOptions) error
+	}
+	ContainerWaitCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+			Condition   container.WaitCondition
+		}
+		Returns struct {
+			WaitResponseChannel <-chan container.WaitResponse
+			ErrorChannel        <-chan error
+		}
+		Stub func(context.Context, string, container.WaitCondition) (<-chan container.WaitResponse, <-chan error)
+	}
+	CopyFromContainerCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+			SrcPath     string
+		}
+		Returns struct {
+			ReadCloser io.ReadCloser
+			PathStat   container.PathStat
+			Error      error
+		}
+		Stub func(context.Context, string, string) (io.ReadCloser, container.PathStat, error)
+	}
+	CopyToContainerCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+			DstPath     string
+			Content     io.Reader
+			Options     container.CopyToContainerOptions
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(context.Context, string, string, io.Reader, container.CopyToContainerOptions) error
+	}
+	ImageInspectWithRawCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx     context.Context
+			ImageID string
+		}
+		Returns struct {
+			ImageInspect types.ImageInspect
+			ByteSlice    []byte
+			Error        error
+		}
+		Stub func(context.Context, string) (types.ImageInspect, []byte, error)
+	}
+	ImagePullCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx     context.Context
+			Ref     string
+			Options image.PullOptions
+		}
+		Returns struct {
+			ReadCloser io.ReadCloser
+			Error      error
+		}
+		Stub func(context.Context, string, image.PullOptions) (io.ReadCloser, error)
+	}
+}
+
+func (f *ContainerCompilerClient) ContainerCreate(param1 context.Context, param2 *container.Config, param3 *container.HostConfig, param4 *network.NetworkingConfig, param5 *specs.Platform, param6 string) (container.CreateResponse, error) {
+	f.ContainerCreateCall.mutex.Lock()
+	defer f.ContainerCreateCall.mutex.Unlock()
+	f.ContainerCreateCall.CallCount++
+	f.ContainerCreateCall.Receives.Ctx = param1
+	f.ContainerCreateCall.Receives.Config = param2
+	f.ContainerCreateCall.Receives.HostConfig = param3
+	f.ContainerCreateCall.Receives.NetworkingConfig = param4
+	f.ContainerCreateCall.Receives.Platform = param5
+	f.ContainerCreateCall.Receives.ContainerName = param6
+	if f.ContainerCreateCall.Stub != nil {
+		return f.ContainerCreateCall.Stub(param1, param2, param3, param4, param5, param6)
+	}
+	return f.ContainerCreateCall.Returns.CreateResponse, f.ContainerCreateCall.Returns.Error
+}
+func (f *ContainerCompilerClient) ContainerLogs(param1 context.Context, param2 string, param3 container.LogsOptions) (io.ReadCloser, error) {
+	f.ContainerLogsCall.mutex.Lock()
+	defer f.ContainerLogsCall.mutex.Unlock()
+	f.ContainerLogsCall.CallCount++
+	f.ContainerLogsCall.Receives.Ctx = param1
+	f.ContainerLogsCall.Receives.Container = param2
+	f.ContainerLogsCall.Receives.Options = param3
+	if f.ContainerLogsCall.Stub != nil {
+		return f.ContainerLogsCall.Stub(param1, param2, param3)
+	}
+	return f.ContainerLogsCall.Returns.ReadCloser, f.ContainerLogsCall.Returns.Error
+}
+func (f *ContainerCompilerClient) ContainerRemove(param1 context.Context, param2 string, param3 container.RemoveOptions) error {
+	f.ContainerRemoveCall.mutex.Lock()
+	defer f.ContainerRemoveCall.mutex.Unlock()
+	f.ContainerRemoveCall.CallCount++
+	f.ContainerRemoveCall.Receives.Ctx = param1
+	f.ContainerRemoveCall.Receives.ContainerID = param2
+	f.ContainerRemoveCall.Receives.Options = param3
+	if f.ContainerRemoveCall.Stub != nil {
+		return f.ContainerRemoveCall.Stub(param1, param2, param3)
+	}
+	return f.ContainerRemoveCall.Returns.Error
+}
+func (f *ContainerCompilerClient) ContainerStart(param1 context.Context, param2 string, param3 container.StartOptions) error {
+	f.ContainerStartCall.mutex.Lock()
+	defer f.ContainerStartCall.mutex.Unlock()
+	f.ContainerStartCall.CallCount++
+	f.ContainerStartCall.Receives.Ctx = param1
+	f.ContainerStartCall.Receives.ContainerID = param2
+	f.ContainerStartCall.Receives.Options = param3
+	if f.ContainerStartCall.Stub != nil {
+		return f.ContainerStartCall.Stub(param1, param2, param3)
+	}
+	return f.ContainerStartCall.Returns.Error
+}
+func (f *ContainerCompilerClient) ContainerWait(param1 context.Context, param2 string, param3 container.WaitCondition) (<-chan container.WaitResponse, <-chan error) {
+	f.ContainerWaitCall.mutex.Lock()
+	defer f.ContainerWaitCall.mutex.Unlock()
+	f.ContainerWaitCall.CallCount++
+	f.ContainerWaitCall.Receives.Ctx = param1
+	f.ContainerWaitCall.Receives.ContainerID = param2
+	f.ContainerWaitCall.Receives.Condition = param3
+	if f.ContainerWaitCall.Stub != nil {
+		return f.ContainerWaitCall.Stub(param1, param2, param3)
+	}
+	return f.ContainerWaitCall.Returns.WaitResponseChannel, f.ContainerWaitCall.Returns.ErrorChannel
+}
+func (f *ContainerCompilerClient) CopyFromContainer(param1 context.Context, param2 string, param3 string) (io.ReadCloser, container.PathStat, error) {
+	f.CopyFromContainerCall.mutex.Lock()
+	defer f.CopyFromContainerCall.mutex.Unlock()
+	f.CopyFromContainerCall.CallCount++
+	f.CopyFromContainerCall.Receives.Ctx = param1
+	f.CopyFromContainerCall.Receives.ContainerID = param2
+	f.CopyFromContainerCall.Receives.SrcPath = param3
+	if f.CopyFromContainerCall.Stub != nil {
+		return f.CopyFromContainerCall.Stub(param1, param2, param3)
+	}
+	return f.CopyFromContainerCall.Returns.ReadCloser, f.CopyFromContainerCall.Returns.PathStat, f.CopyFromContainerCall.Returns.Error
+}
+func (f *ContainerCompilerClient) CopyToContainer(param1 context.Context, param2 string, param3 string, param4 io.Reader, param5 container.CopyToContainerOptions) error {
+	f.CopyToContainerCall.mutex.Lock()
+	defer f.CopyToContainerCall.mutex.Unlock()
+	f.CopyToContainerCall.CallCount++
+	f.CopyToContainerCall.Receives.Ctx = param1
+	f.CopyToContainerCall.Receives.ContainerID = param2
+	f.CopyToContainerCall.Receives.DstPath = param3
+	f.CopyToContainerCall.Receives.Content = param4
+	f.CopyToContainerCall.Receives.Options = param5
+	if f.CopyToContainerCall.Stub != nil {
+		return f.CopyToContainerCall.Stub(param1, param2, param3, param4, param5)
+	}
+	return f.CopyToContainerCall.Returns.Error
+}
+func (f *ContainerCompilerClient) ImageInspectWithRaw(param1 context.Context, param2 string) (types.ImageInspect, []byte, error) {
+	f.ImageInspectWithRawCall.mutex.Lock()
+	defer f.ImageInspectWithRawCall.mutex.Unlock()
+	f.ImageInspectWithRawCall.CallCount++
+	f.ImageInspectWithRawCall.Receives.Ctx = param1
+	f.ImageInspectWithRawCall.Receives.ImageID = param2
+	if f.ImageInspectWithRawCall.Stub != nil {
+		return f.ImageInspectWithRawCall.Stub(param1, param2)
+	}
+	return f.ImageInspectWithRawCall.Returns.ImageInspect, f.ImageInspectWithRawCall.Returns.ByteSlice, f.ImageInspectWithRawCall.Returns.Error
+}
+func (f *ContainerCompilerClient) ImagePull(param1 context.Context, param2 string, param3 image.PullOptions) (io.ReadCloser, error) {
+	f.ImagePullCall.mutex.Lock()
+	defer f.ImagePullCall.mutex.Unlock()
+	f.ImagePullCall.CallCount++
+	f.ImagePullCall.Receives.Ctx = param1
+	f.ImagePullCall.Receives.Ref = param2
+	f.ImagePullCall.Receives.Options = param3
+	if f.ImagePullCall.Stub != nil {
+		return f.ImagePullCall.Stub(param1, param2, param3)
+	}
+	return f.ImagePullCall.Returns.ReadCloser, f.ImagePullCall.Returns.Error
+}
@@ -9,23 +9,49 @@ type LifecycleBuilder struct {
 		Receives  struct {
 			SourceURI string
 			Workspace string
+			Arch      string
 		}
 		Returns struct {
 			Path string
 			Err  error
 		}
+		Stub func(string, string, string) (string, error)
+	}
+	RevisionCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			SourceURI string
+			Workspace string
+		}
+		Returns struct {
+			Revision string
+			Err      error
+		}
 		Stub func(string, string) (string, error)
 	}
 }
 
-func (f *LifecycleBuilder) Build(param1 string, param2 string) (string, error) {
+func (f *LifecycleBuilder) Build(param1 string, param2 string, param3 string) (string, error) {
 	f.BuildCall.mutex.Lock()
 	defer f.BuildCall.mutex.Unlock()
 	f.BuildCall.CallCount++
 	f.BuildCall.Receives.SourceURI = param1
 	f.BuildCall.Receives.Workspace = param2
+	f.BuildCall.Receives.Arch = param3
 	if f.BuildCall.Stub != nil {
-		return f.BuildCall.Stub(param1, param2)
+		return f.BuildCall.Stub(param1, param2, param3)
 	}
 	return f.BuildCall.Returns.Path, f.BuildCall.Returns.Err
 }
+func (f *LifecycleBuilder) Revision(param1 string, param2 string) (string, error) {
+	f.RevisionCall.mutex.Lock()
+	defer f.RevisionCall.mutex.Unlock()
+	f.RevisionCall.CallCount++
+	f.RevisionCall.Receives.SourceURI = param1
+	f.RevisionCall.Receives.Workspace = param2
+	if f.RevisionCall.Stub != nil {
+		return f.RevisionCall.Stub(param1, param2)
+	}
+	return f.RevisionCall.Returns.Revision, f.RevisionCall.Returns.Err
+}
@@ -0,0 +1,32 @@
+package fakes
+
+import "sync"
+
+type LifecycleCompiler struct {
+	CompileCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Repo   string
+			Output string
+			Arch   string
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(string, string, string) error
+	}
+}
+
+func (f *LifecycleCompiler) Compile(param1 string, param2 string, param3 string) error {
+	f.CompileCall.mutex.Lock()
+	defer f.CompileCall.mutex.Unlock()
+	f.CompileCall.CallCount++
+	f.CompileCall.Receives.Repo = param1
+	f.CompileCall.Receives.Output = param2
+	f.CompileCall.Receives.Arch = param3
+	if f.CompileCall.Stub != nil {
+		return f.CompileCall.Stub(param1, param2, param3)
+	}
+	return f.CompileCall.Returns.Error
+}
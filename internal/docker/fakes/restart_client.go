@@ -0,0 +1,152 @@
+package fakes
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type RestartClient struct {
+	ContainerCreateCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx              context.Context
+			Config           *container.Config
+			HostConfig       *container.HostConfig
+			NetworkingConfig *network.NetworkingConfig
+			Platform         *specs.Platform
+			ContainerName    string
+		}
+		Returns struct {
+			CreateResponse container.CreateResponse
+			Error          error
+		}
+		Stub func(context.Context, *container.Config, *container.HostConfig, *network.NetworkingConfig, *specs.Platform, string) (container.CreateResponse, error)
+	}
+	ContainerInspectCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+		}
+		Returns struct {
+			ContainerJSON types.ContainerJSON
+			Error         error
+		}
+		Stub func(context.Context, string) (types.ContainerJSON, error)
+	}
+	ContainerRemoveCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+			Options     container.RemoveOptions
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(context.Context, string, container.RemoveOptions) error
+	}
+	ContainerStartCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+			Options     container.StartOptions
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(context.Context, string, container.StartOptions) error
+	}
+	CopyToContainerCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Receives  struct {
+			Ctx         context.Context
+			ContainerID string
+			DstPath     string
+			Content     io.Reader
+			Options     container.CopyToContainerOptions
+		}
+		Returns struct {
+			Error error
+		}
+		Stub func(context.Context, string, string, io.Reader, container.CopyToContainerOptions) error
+	}
+}
+
+func (f *RestartClient) ContainerCreate(param1 context.Context, param2 *container.Config, param3 *container.HostConfig, param4 *network.NetworkingConfig, param5 *specs.Platform, param6 string) (container.CreateResponse, error) {
+	f.ContainerCreateCall.mutex.Lock()
+	defer f.ContainerCreateCall.mutex.Unlock()
+	f.ContainerCreateCall.CallCount++
+	f.ContainerCreateCall.Receives.Ctx = param1
+	f.ContainerCreateCall.Receives.Config = param2
+	f.ContainerCreateCall.Receives.HostConfig = param3
+	f.ContainerCreateCall.Receives.NetworkingConfig = param4
+	f.ContainerCreateCall.Receives.Platform = param5
+	f.ContainerCreateCall.Receives.ContainerName = param6
+	if f.ContainerCreateCall.Stub != nil {
+		return f.ContainerCreateCall.Stub(param1, param2, param3, param4, param5, param6)
+	}
+	return f.ContainerCreateCall.Returns.CreateResponse, f.ContainerCreateCall.Returns.Error
+}
+func (f *RestartClient) ContainerInspect(param1 context.Context, param2 string) (types.ContainerJSON, error) {
+	f.ContainerInspectCall.mutex.Lock()
+	defer f.ContainerInspectCall.mutex.Unlock()
+	f.ContainerInspectCall.CallCount++
+	f.ContainerInspectCall.Receives.Ctx = param1
+	f.ContainerInspectCall.Receives.ContainerID = param2
+	if f.ContainerInspectCall.Stub != nil {
+		return f.ContainerInspectCall.Stub(param1, param2)
+	}
+	return f.ContainerInspectCall.Returns.ContainerJSON, f.ContainerInspectCall.Returns.Error
+}
+func (f *RestartClient) ContainerRemove(param1 context.Context, param2 string, param3 container.RemoveOptions) error {
+	f.ContainerRemoveCall.mutex.Lock()
+	defer f.ContainerRemoveCall.mutex.Unlock()
+	f.ContainerRemoveCall.CallCount++
+	f.ContainerRemoveCall.Receives.Ctx = param1
+	f.ContainerRemoveCall.Receives.ContainerID = param2
+	f.ContainerRemoveCall.Receives.Options = param3
+	if f.ContainerRemoveCall.Stub != nil {
+		return f.ContainerRemoveCall.Stub(param1, param2, param3)
+	}
+	return f.ContainerRemoveCall.Returns.Error
+}
+func (f *RestartClient) ContainerStart(param1 context.Context, param2 string, param3 container.StartOptions) error {
+	f.ContainerStartCall.mutex.Lock()
+	defer f.ContainerStartCall.mutex.Unlock()
+	f.ContainerStartCall.CallCount++
+	f.ContainerStartCall.Receives.Ctx = param1
+	f.ContainerStartCall.Receives.ContainerID = param2
+	f.ContainerStartCall.Receives.Options = param3
+	if f.ContainerStartCall.Stub != nil {
+		return f.ContainerStartCall.Stub(param1, param2, param3)
+	}
+	return f.ContainerStartCall.Returns.Error
+}
+func (f *RestartClient) CopyToContainer(param1 context.Context, param2 string, param3 string, param4 io.Reader, param5 container.CopyToContainerOptions) error {
+	f.CopyToContainerCall.mutex.Lock()
+	defer f.CopyToContainerCall.mutex.Unlock()
+	f.CopyToContainerCall.CallCount++
+	f.CopyToContainerCall.Receives.Ctx = param1
+	f.CopyToContainerCall.Receives.ContainerID = param2
+	f.CopyToContainerCall.Receives.DstPath = param3
+	f.CopyToContainerCall.Receives.Content = param4
+	f.CopyToContainerCall.Receives.Options = param5
+	if f.CopyToContainerCall.Stub != nil {
+		return f.CopyToContainerCall.Stub(param1, param2, param3, param4, param5)
+	}
+	return f.CopyToContainerCall.Returns.Error
+}
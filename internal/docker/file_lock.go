@@ -0,0 +1,33 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockPath acquires an exclusive lock that is shared by every process using
+// the given path, creating the lock file if needed. The returned function
+// releases the lock.
+func lockPath(path string) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	err = lockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	return func() {
+		_ = unlockFile(file)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package docker
+
+import "os"
+
+// Platforms without flock(2) only serialize access within a single process,
+// which the callers already do with in-memory mutexes.
+func lockFile(file *os.File) error {
+	return nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
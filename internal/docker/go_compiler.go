@@ -0,0 +1,111 @@
+package docker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+)
+
+var goVersionRegexp = regexp.MustCompile(`go(\d+\.\d+)`)
+
+//go:generate faux --interface Executable --output fakes/executable.go
+type Executable interface {
+	Execute(pexec.Execution) error
+}
+
+// GoCompiler compiles the lifecycle using the Go toolchain installed on the
+// host.
+type GoCompiler struct {
+	golang Executable
+}
+
+func NewGoCompiler(golang Executable) GoCompiler {
+	return GoCompiler{
+		golang: golang,
+	}
+}
+
+func (c GoCompiler) Compile(repo, output, arch string) error {
+	env := append(os.Environ(), "GOOS=linux", fmt.Sprintf("GOARCH=%s", arch), "CGO_ENABLED=0", "GOFLAGS=-buildvcs=false")
+	buffer := bytes.NewBuffer(nil)
+
+	_, err := os.Stat(filepath.Join(repo, "go.mod"))
+	if errors.Is(err, os.ErrNotExist) {
+		err = c.golang.Execute(pexec.Execution{
+			Args:   []string{"mod", "init", "code.cloudfoundry.org/buildpackapplifecycle"},
+			Env:    env,
+			Dir:    repo,
+			Stdout: buffer,
+			Stderr: buffer,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize go module: %w\n\n%s", err, buffer)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to stat go.mod: %w", err)
+	}
+
+	versionBuffer := bytes.NewBuffer(nil)
+	err = c.golang.Execute(pexec.Execution{
+		Args:   []string{"version"},
+		Env:    env,
+		Dir:    repo,
+		Stdout: io.MultiWriter(versionBuffer, buffer),
+		Stderr: buffer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to identify go version: %w\n\n%s", err, buffer)
+	}
+
+	args := []string{"mod", "tidy"}
+	matches := goVersionRegexp.FindStringSubmatch(versionBuffer.String())
+	if len(matches) == 2 {
+		args = append(args, "-compat", matches[1])
+	}
+
+	err = c.golang.Execute(pexec.Execution{
+		Args:   args,
+		Env:    env,
+		Dir:    repo,
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tidy go module: %w\n\n%s", err, buffer)
+	}
+
+	err = os.MkdirAll(output, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	err = c.golang.Execute(pexec.Execution{
+		Args:   []string{"build", "-o", filepath.Join(output, "builder"), "./builder"},
+		Env:    env,
+		Dir:    repo,
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build lifecycle builder: %w\n\n%s", err, buffer)
+	}
+
+	err = c.golang.Execute(pexec.Execution{
+		Args:   []string{"build", "-o", filepath.Join(output, "launcher"), "./launcher"},
+		Env:    env,
+		Dir:    repo,
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build lifecycle launcher: %w\n\n%s", err, buffer)
+	}
+
+	return nil
+}
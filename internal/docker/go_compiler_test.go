@@ -0,0 +1,246 @@
+package docker_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+func testGoCompiler(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Compile", func() {
+		var (
+			repo       string
+			output     string
+			executable *fakes.Executable
+			executions []pexec.Execution
+
+			compiler docker.GoCompiler
+		)
+
+		it.Before(func() {
+			var err error
+			repo, err = os.MkdirTemp("", "repo")
+			Expect(err).NotTo(HaveOccurred())
+
+			output, err = os.MkdirTemp("", "output")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.RemoveAll(output)).To(Succeed())
+
+			executable = &fakes.Executable{}
+			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+				executions = append(executions, execution)
+				if strings.Contains(strings.Join(execution.Args, " "), "version") {
+					fmt.Fprint(execution.Stdout, "go version go1.19.1 darwin/amd64")
+				}
+
+				return nil
+			}
+
+			compiler = docker.NewGoCompiler(executable)
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(repo)).To(Succeed())
+			Expect(os.RemoveAll(output)).To(Succeed())
+		})
+
+		it("builds the lifecycle", func() {
+			err := compiler.Compile(repo, output, "amd64")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(executions).To(HaveLen(5))
+			Expect(executions[0]).To(MatchFields(IgnoreExtras, Fields{
+				"Args": Equal([]string{"mod", "init", "code.cloudfoundry.org/buildpackapplifecycle"}),
+				"Dir":  Equal(repo),
+			}))
+			Expect(executions[1]).To(MatchFields(IgnoreExtras, Fields{
+				"Args": Equal([]string{"version"}),
+				"Dir":  Equal(repo),
+			}))
+			Expect(executions[2]).To(MatchFields(IgnoreExtras, Fields{
+				"Args": Equal([]string{"mod", "tidy", "-compat", "1.19"}),
+				"Dir":  Equal(repo),
+			}))
+			Expect(executions[3]).To(MatchFields(IgnoreExtras, Fields{
+				"Args": Equal([]string{"build", "-o", filepath.Join(output, "builder"), "./builder"}),
+				"Env":  ContainElements("GOOS=linux", "GOARCH=amd64", "CGO_ENABLED=0", "GOFLAGS=-buildvcs=false"),
+				"Dir":  Equal(repo),
+			}))
+			Expect(executions[4]).To(MatchFields(IgnoreExtras, Fields{
+				"Args": Equal([]string{"build", "-o", filepath.Join(output, "launcher"), "./launcher"}),
+				"Env":  ContainElements("GOOS=linux", "GOARCH=amd64", "CGO_ENABLED=0", "GOFLAGS=-buildvcs=false"),
+				"Dir":  Equal(repo),
+			}))
+
+			Expect(output).To(BeADirectory())
+		})
+
+		context("when a go.mod file already exists", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(repo, "go.mod"), []byte("go-mod-content"), 0600)).To(Succeed())
+			})
+
+			it("builds the lifecycle", func() {
+				err := compiler.Compile(repo, output, "amd64")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(4))
+				Expect(executions[0]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"version"}),
+					"Dir":  Equal(repo),
+				}))
+				Expect(executions[1]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"mod", "tidy", "-compat", "1.19"}),
+					"Dir":  Equal(repo),
+				}))
+				Expect(executions[2]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"build", "-o", filepath.Join(output, "builder"), "./builder"}),
+					"Env":  ContainElements("GOOS=linux", "GOARCH=amd64"),
+					"Dir":  Equal(repo),
+				}))
+				Expect(executions[3]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"build", "-o", filepath.Join(output, "launcher"), "./launcher"}),
+					"Env":  ContainElements("GOOS=linux", "GOARCH=amd64"),
+					"Dir":  Equal(repo),
+				}))
+			})
+		})
+
+		context("when building for arm64", func() {
+			it("cross-compiles the lifecycle", func() {
+				err := compiler.Compile(repo, output, "arm64")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(executions).To(HaveLen(5))
+				Expect(executions[3]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"build", "-o", filepath.Join(output, "builder"), "./builder"}),
+					"Env":  ContainElements("GOOS=linux", "GOARCH=arm64"),
+				}))
+				Expect(executions[4]).To(MatchFields(IgnoreExtras, Fields{
+					"Args": Equal([]string{"build", "-o", filepath.Join(output, "launcher"), "./launcher"}),
+					"Env":  ContainElements("GOOS=linux", "GOARCH=arm64"),
+				}))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when initializing the go module fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						if strings.Contains(strings.Join(execution.Args, " "), "mod init") {
+							fmt.Fprintln(execution.Stdout, "stdout: could not initialize")
+							fmt.Fprintln(execution.Stderr, "stderr: could not initialize")
+							return errors.New("go mod init errored")
+						}
+
+						return nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(repo, output, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to initialize go module: go mod init errored")))
+					Expect(err).To(MatchError(ContainSubstring("stdout: could not initialize")))
+					Expect(err).To(MatchError(ContainSubstring("stderr: could not initialize")))
+				})
+			})
+
+			context("when fetching the go version fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						if strings.Contains(strings.Join(execution.Args, " "), "version") {
+							fmt.Fprintln(execution.Stdout, "stdout: could not version")
+							fmt.Fprintln(execution.Stderr, "stderr: could not version")
+							return errors.New("go version errored")
+						}
+
+						return nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(repo, output, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to identify go version: go version errored")))
+					Expect(err).To(MatchError(ContainSubstring("stdout: could not version")))
+					Expect(err).To(MatchError(ContainSubstring("stderr: could not version")))
+				})
+			})
+
+			context("when tidy-ing the go module fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						if strings.Contains(strings.Join(execution.Args, " "), "mod tidy") {
+							fmt.Fprintln(execution.Stdout, "stdout: could not tidy")
+							fmt.Fprintln(execution.Stderr, "stderr: could not tidy")
+							return errors.New("go mod tidy errored")
+						}
+
+						return nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(repo, output, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to tidy go module: go mod tidy errored")))
+					Expect(err).To(MatchError(ContainSubstring("stdout: could not tidy")))
+					Expect(err).To(MatchError(ContainSubstring("stderr: could not tidy")))
+				})
+			})
+
+			context("when building the builder fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						if strings.Contains(strings.Join(execution.Args, " "), "./builder") {
+							fmt.Fprintln(execution.Stdout, "stdout: could not build builder")
+							fmt.Fprintln(execution.Stderr, "stderr: could not build builder")
+							return errors.New("go build builder errored")
+						}
+
+						return nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(repo, output, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to build lifecycle builder: go build builder errored")))
+					Expect(err).To(MatchError(ContainSubstring("stdout: could not build builder")))
+					Expect(err).To(MatchError(ContainSubstring("stderr: could not build builder")))
+				})
+			})
+
+			context("when building the launcher fails", func() {
+				it.Before(func() {
+					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
+						if strings.Contains(strings.Join(execution.Args, " "), "./launcher") {
+							fmt.Fprintln(execution.Stdout, "stdout: could not build launcher")
+							fmt.Fprintln(execution.Stderr, "stderr: could not build launcher")
+							return errors.New("go build launcher errored")
+						}
+
+						return nil
+					}
+				})
+
+				it("returns an error", func() {
+					err := compiler.Compile(repo, output, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to build lifecycle launcher: go build launcher errored")))
+					Expect(err).To(MatchError(ContainSubstring("stdout: could not build launcher")))
+					Expect(err).To(MatchError(ContainSubstring("stderr: could not build launcher")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
+
+type InfoPhase interface {
+	Run() (PlatformInfo, error)
+}
+
+type PlatformInfo struct {
+	Architecture      string
+	LifecycleSource   string
+	LifecycleRevision string
+}
+
+type Info struct {
+	lifecycle    LifecycleBuilder
+	source       string
+	workspace    string
+	arch         string
+	archResolver ArchitectureResolver
+}
+
+func NewInfo(lifecycle LifecycleBuilder, source, workspace, arch string) Info {
+	return Info{
+		lifecycle: lifecycle,
+		source:    source,
+		workspace: workspace,
+		arch:      arch,
+	}
+}
+
+// WithArchitectureResolver resolves the architecture with the given resolver
+// when none was given to NewInfo.
+func (i Info) WithArchitectureResolver(resolver ArchitectureResolver) Info {
+	i.archResolver = resolver
+	return i
+}
+
+func (i Info) Run() (PlatformInfo, error) {
+	i.arch = i.archResolver.Resolve(context.Background(), i.arch)
+
+	revision, err := i.lifecycle.Revision(i.source, filepath.Join(i.workspace, "lifecycle", i.arch))
+	if err != nil {
+		return PlatformInfo{}, fmt.Errorf("failed to determine lifecycle revision: %w", err)
+	}
+
+	return PlatformInfo{
+		Architecture:      i.arch,
+		LifecycleSource:   i.source,
+		LifecycleRevision: revision,
+	}, nil
+}
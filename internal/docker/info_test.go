@@ -0,0 +1,58 @@
+package docker_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testInfo(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Run", func() {
+		var (
+			info docker.Info
+
+			lifecycleBuilder *fakes.LifecycleBuilder
+		)
+
+		it.Before(func() {
+			lifecycleBuilder = &fakes.LifecycleBuilder{}
+			lifecycleBuilder.RevisionCall.Returns.Revision = "some-revision"
+
+			info = docker.NewInfo(lifecycleBuilder, "some-lifecycle-source", "/some/workspace", "arm64")
+		})
+
+		it("reports the lifecycle source and revision", func() {
+			platformInfo, err := info.Run()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platformInfo).To(Equal(docker.PlatformInfo{
+				Architecture:      "arm64",
+				LifecycleSource:   "some-lifecycle-source",
+				LifecycleRevision: "some-revision",
+			}))
+
+			Expect(lifecycleBuilder.RevisionCall.Receives.SourceURI).To(Equal("some-lifecycle-source"))
+			Expect(lifecycleBuilder.RevisionCall.Receives.Workspace).To(Equal(filepath.Join("/some/workspace", "lifecycle", "arm64")))
+		})
+
+		context("failure cases", func() {
+			context("when the revision cannot be read", func() {
+				it.Before(func() {
+					lifecycleBuilder.RevisionCall.Returns.Err = errors.New("could not read revision")
+				})
+
+				it("returns an error", func() {
+					_, err := info.Run()
+					Expect(err).To(MatchError("failed to determine lifecycle revision: could not read revision"))
+				})
+			})
+		})
+	})
+}
@@ -12,13 +12,23 @@ func TestDocker(t *testing.T) {
 	format.MaxLength = 0
 
 	suite := spec.New("switchblade/internal/docker", spec.Report(report.Terminal{}), spec.Parallel())
+	suite("Architecture", testArchitecture)
 	suite("BuildpacksCache", testBuildpacksCache)
 	suite("BuildpacksManager", testBuildpacksManager)
 	suite("BuildpacksRegistry", testBuildpacksRegistry)
+	suite("Check", testCheck)
+	suite("Collect", testCollect)
+	suite("ContainerCompiler", testContainerCompiler)
 	suite("Deinitialize", testDeinitialize)
+	suite("Detect", testDetect)
+	suite("Export", testExport)
+	suite("GoCompiler", testGoCompiler)
+	suite("Info", testInfo)
 	suite("Initialize", testInitialize)
 	suite("LifecycleManager", testLifecycleManager)
 	suite("NetworkManager", testNetworkManager)
+	suite("PruneWorkspace", testPruneWorkspace)
+	suite("Restart", testRestart)
 	suite("Setup", testSetup)
 	suite("Stage", testStage)
 	suite("Start", testStart)
@@ -39,5 +49,6 @@ type copyFromContainerInvocation struct {
 }
 
 type cacheFetchInvocation struct {
-	URL string
+	URL      string
+	Checksum string
 }
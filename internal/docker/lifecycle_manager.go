@@ -1,45 +1,137 @@
 package docker
 
 import (
-	"bytes"
+	"archive/zip"
+	"bufio"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strings"
 	"sync"
 
-	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/packit/v2/vacation"
 )
 
-var goVersionRegexp = regexp.MustCompile(`go(\d+\.\d+)`)
-
-//go:generate faux --interface Executable --output fakes/executable.go
-type Executable interface {
-	Execute(pexec.Execution) error
+//go:generate faux --interface LifecycleCompiler --output fakes/lifecycle_compiler.go
+type LifecycleCompiler interface {
+	Compile(repo, output, arch string) error
 }
 
 type LifecycleManager struct {
-	golang   Executable
+	compiler LifecycleCompiler
 	archiver Archiver
+	client   *http.Client
+	offline  bool
 	m        *sync.Mutex
+	built    map[string]bool
 }
 
-func NewLifecycleManager(golang Executable, archiver Archiver) LifecycleManager {
+func NewLifecycleManager(compiler LifecycleCompiler, archiver Archiver) LifecycleManager {
 	return LifecycleManager{
-		golang:   golang,
+		compiler: compiler,
 		archiver: archiver,
+		client:   http.DefaultClient,
 		m:        &sync.Mutex{},
+		built:    map[string]bool{},
 	}
 }
 
-func (b LifecycleManager) Build(sourceURI, workspace string) (string, error) {
+func (b LifecycleManager) WithHTTPClient(client *http.Client) LifecycleManager {
+	b.client = client
+	return b
+}
+
+// WithOffline builds the lifecycle without making any network requests. A
+// lifecycle from a remote source is only used when it was already built into
+// the workspace, while local checkouts and prebuilt binaries are used as
+// usual.
+func (b LifecycleManager) WithOffline() LifecycleManager {
+	b.offline = true
+	return b
+}
+
+// LifecycleArchiveURL returns the GitHub archive URL for the given
+// buildpackapplifecycle ref. The ref can be a branch, tag, or commit SHA.
+func LifecycleArchiveURL(ref string) string {
+	return fmt.Sprintf("https://github.com/cloudfoundry/buildpackapplifecycle/archive/%s.zip", ref)
+}
+
+// Build produces a lifecycle tarball containing the builder and launcher
+// binaries for the given architecture. The sourceURI can be a remote zip
+// archive of the buildpackapplifecycle repository, a local checkout of that
+// repository, or a local directory containing prebuilt builder and launcher
+// binaries.
+func (b LifecycleManager) Build(sourceURI, workspace, arch string) (string, error) {
 	b.m.Lock()
 	defer b.m.Unlock()
 
+	// Other processes may be sharing the workspace, so builds into it are also
+	// serialized through a lock file that sits next to it.
+	unlock, err := lockPath(fmt.Sprintf("%s.lock", filepath.Clean(workspace)))
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	u, err := url.Parse(sourceURI)
+	if err != nil || u.IsAbs() {
+		if b.offline {
+			return b.buildCached(sourceURI, workspace)
+		}
+
+		return b.buildRemote(sourceURI, workspace, arch)
+	}
+
+	info, err := os.Stat(sourceURI)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat lifecycle source: %w", err)
+	}
+
+	if !info.IsDir() {
+		return "", fmt.Errorf("failed to use lifecycle source: %s is not a directory", sourceURI)
+	}
+
+	if isPrebuiltLifecycle(sourceURI) {
+		return b.buildPrebuilt(sourceURI, workspace)
+	}
+
+	return b.buildLocal(sourceURI, workspace, arch)
+}
+
+// Revision returns the revision of the lifecycle that was most recently built
+// into the given workspace from the given source. It is empty when nothing
+// has been built from that source yet, such as when the source was changed
+// since the last build.
+func (b LifecycleManager) Revision(sourceURI, workspace string) (string, error) {
+	source, err := os.ReadFile(filepath.Join(workspace, "source"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read lifecycle source: %w", err)
+	}
+
+	// As with the etag, workspaces that predate source tracking keep reporting
+	// their revision.
+	if len(source) > 0 && string(source) != sourceURI {
+		return "", nil
+	}
+
+	revision, err := os.ReadFile(filepath.Join(workspace, "revision"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+
+		return "", fmt.Errorf("failed to read lifecycle revision: %w", err)
+	}
+
+	return string(revision), nil
+}
+
+func (b LifecycleManager) buildRemote(sourceURI, workspace, arch string) (string, error) {
 	req, err := http.NewRequest("GET", sourceURI, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
@@ -50,11 +142,19 @@ func (b LifecycleManager) Build(sourceURI, workspace string) (string, error) {
 		return "", fmt.Errorf("failed to read etag: %w", err)
 	}
 
-	if len(etag) > 0 {
+	source, err := os.ReadFile(filepath.Join(workspace, "source"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read lifecycle source: %w", err)
+	}
+
+	// The etag is only meaningful for the source it was recorded against.
+	// Workspaces that predate source tracking have no source file and keep
+	// using their etag.
+	if len(etag) > 0 && (len(source) == 0 || string(source) == sourceURI) {
 		req.Header.Set("If-None-Match", string(etag))
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := b.client.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to complete request: %w", err)
 	}
@@ -65,62 +165,130 @@ func (b LifecycleManager) Build(sourceURI, workspace string) (string, error) {
 		return output, nil
 	}
 
-	err = os.RemoveAll(workspace)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download lifecycle: received unexpected response status %q from %s", resp.Status, sourceURI)
+	}
+
+	err = clearWorkspace(workspace)
+	if err != nil {
+		return "", err
+	}
+
+	archive, err := os.Create(filepath.Join(workspace, "repo.zip"))
+	if err != nil {
+		return "", fmt.Errorf("failed to create lifecycle archive: %w", err)
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	size, err := io.Copy(archive, resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to clear workspace: %w", err)
+		return "", fmt.Errorf("failed to download lifecycle: %w", err)
 	}
 
-	err = vacation.NewZipArchive(resp.Body).StripComponents(1).Decompress(filepath.Join(workspace, "repo"))
+	_, err = archive.Seek(0, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to rewind lifecycle archive: %w", err)
+	}
+
+	err = vacation.NewZipArchive(archive).StripComponents(1).Decompress(filepath.Join(workspace, "repo"))
 	if err != nil {
 		return "", fmt.Errorf("failed to decompress lifecycle repo: %w", err)
 	}
 
-	env := append(os.Environ(), "GOOS=linux", "GOARCH=amd64")
-	buffer := bytes.NewBuffer(nil)
+	// GitHub records the commit SHA of the archived tree in the zip comment.
+	// When that is not available, fall back to the etag of the archive.
+	revision := strings.Trim(strings.TrimPrefix(resp.Header.Get("ETag"), "W/"), `"`)
+	zr, err := zip.NewReader(archive, size)
+	if err == nil && strings.TrimSpace(zr.Comment) != "" {
+		revision = strings.TrimSpace(zr.Comment)
+	}
 
-	_, err = os.Stat(filepath.Join(workspace, "repo", "go.mod"))
-	if errors.Is(err, os.ErrNotExist) {
-		err = b.golang.Execute(pexec.Execution{
-			Args:   []string{"mod", "init", "code.cloudfoundry.org/buildpackapplifecycle"},
-			Env:    env,
-			Dir:    filepath.Join(workspace, "repo"),
-			Stdout: buffer,
-			Stderr: buffer,
-		})
-		if err != nil {
-			return "", fmt.Errorf("failed to initialize go module: %w\n\n%s", err, buffer)
-		}
-	} else if err != nil {
-		return "", fmt.Errorf("failed to stat go.mod: %w", err)
+	err = b.compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), arch)
+	if err != nil {
+		return "", err
 	}
 
-	versionBuffer := bytes.NewBuffer(nil)
-	err = b.golang.Execute(pexec.Execution{
-		Args:   []string{"version"},
-		Env:    env,
-		Dir:    filepath.Join(workspace, "repo"),
-		Stdout: io.MultiWriter(versionBuffer, buffer),
-		Stderr: buffer,
-	})
+	err = b.archive(workspace, output, sourceURI, revision)
 	if err != nil {
-		return "", fmt.Errorf("failed to identify go version: %w\n\n%s", err, buffer)
+		return "", err
 	}
 
-	args := []string{"mod", "tidy"}
-	matches := goVersionRegexp.FindStringSubmatch(versionBuffer.String())
-	if len(matches) == 2 {
-		args = append(args, "-compat", matches[1])
+	err = os.WriteFile(filepath.Join(workspace, "etag"), []byte(resp.Header.Get("ETag")), 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to write lifecycle etag file: %w", err)
 	}
 
-	err = b.golang.Execute(pexec.Execution{
-		Args:   args,
-		Env:    env,
-		Dir:    filepath.Join(workspace, "repo"),
-		Stdout: buffer,
-		Stderr: buffer,
-	})
+	return output, nil
+}
+
+// buildCached returns the lifecycle that was last built from the given remote
+// source, without checking whether the source has changed since.
+func (b LifecycleManager) buildCached(sourceURI, workspace string) (string, error) {
+	output := filepath.Join(workspace, "lifecycle.tar.gz")
+
+	source, err := os.ReadFile(filepath.Join(workspace, "source"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read lifecycle source: %w", err)
+	}
+
+	// As with the etag, workspaces that predate source tracking keep using
+	// their lifecycle.
+	_, err = os.Stat(output)
+	if errors.Is(err, os.ErrNotExist) || (len(source) > 0 && string(source) != sourceURI) {
+		return "", fmt.Errorf("failed to build lifecycle offline: no lifecycle built from %s is cached in %s, deploy once while online or use a local checkout or prebuilt binaries as the lifecycle source", sourceURI, workspace)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to tidy go module: %w\n\n%s", err, buffer)
+		return "", fmt.Errorf("failed to stat cached lifecycle: %w", err)
+	}
+
+	return output, nil
+}
+
+func (b LifecycleManager) buildLocal(sourceURI, workspace, arch string) (string, error) {
+	output := filepath.Join(workspace, "lifecycle.tar.gz")
+
+	// Local checkouts are compiled once per process so that every deployment
+	// in a test suite does not pay for a rebuild.
+	key := fmt.Sprintf("%s:%s:%s", sourceURI, workspace, arch)
+	if b.built[key] {
+		return output, nil
+	}
+
+	err := clearWorkspace(workspace)
+	if err != nil {
+		return "", err
+	}
+
+	err = fs.Copy(sourceURI, filepath.Join(workspace, "repo"))
+	if err != nil {
+		return "", fmt.Errorf("failed to copy lifecycle repo: %w", err)
+	}
+
+	err = b.compiler.Compile(filepath.Join(workspace, "repo"), filepath.Join(workspace, "output"), arch)
+	if err != nil {
+		return "", err
+	}
+
+	revision, err := gitRevision(sourceURI)
+	if err != nil {
+		return "", err
+	}
+
+	err = b.archive(workspace, output, sourceURI, revision)
+	if err != nil {
+		return "", err
+	}
+
+	b.built[key] = true
+
+	return output, nil
+}
+
+func (b LifecycleManager) buildPrebuilt(sourceURI, workspace string) (string, error) {
+	err := clearWorkspace(workspace)
+	if err != nil {
+		return "", err
 	}
 
 	err = os.MkdirAll(filepath.Join(workspace, "output"), os.ModePerm)
@@ -128,37 +296,115 @@ func (b LifecycleManager) Build(sourceURI, workspace string) (string, error) {
 		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	err = b.golang.Execute(pexec.Execution{
-		Args:   []string{"build", "-o", filepath.Join(workspace, "output", "builder"), "./builder"},
-		Env:    env,
-		Dir:    filepath.Join(workspace, "repo"),
-		Stdout: buffer,
-		Stderr: buffer,
-	})
+	for _, binary := range []string{"builder", "launcher"} {
+		err = fs.Copy(filepath.Join(sourceURI, binary), filepath.Join(workspace, "output", binary))
+		if err != nil {
+			return "", fmt.Errorf("failed to copy lifecycle %s: %w", binary, err)
+		}
+	}
+
+	output := filepath.Join(workspace, "lifecycle.tar.gz")
+	err = b.archive(workspace, output, sourceURI, "prebuilt")
 	if err != nil {
-		return "", fmt.Errorf("failed to build lifecycle builder: %w\n\n%s", err, buffer)
+		return "", err
 	}
 
-	err = b.golang.Execute(pexec.Execution{
-		Args:   []string{"build", "-o", filepath.Join(workspace, "output", "launcher"), "./launcher"},
-		Env:    env,
-		Dir:    filepath.Join(workspace, "repo"),
-		Stdout: buffer,
-		Stderr: buffer,
-	})
+	return output, nil
+}
+
+func (b LifecycleManager) archive(workspace, output, source, revision string) error {
+	err := b.archiver.WithPrefix("/tmp/lifecycle").Compress(filepath.Join(workspace, "output"), output)
 	if err != nil {
-		return "", fmt.Errorf("failed to build lifecycle launcher: %w\n\n%s", err, buffer)
+		return fmt.Errorf("failed to archive lifecycle: %w", err)
 	}
 
-	err = b.archiver.WithPrefix("/tmp/lifecycle").Compress(filepath.Join(workspace, "output"), output)
+	err = os.WriteFile(filepath.Join(workspace, "source"), []byte(source), 0600)
 	if err != nil {
-		return "", fmt.Errorf("failed to archive lifecycle: %w", err)
+		return fmt.Errorf("failed to write lifecycle source file: %w", err)
 	}
 
-	err = os.WriteFile(filepath.Join(workspace, "etag"), []byte(resp.Header.Get("ETag")), 0600)
+	err = os.WriteFile(filepath.Join(workspace, "revision"), []byte(revision), 0600)
 	if err != nil {
-		return "", fmt.Errorf("failed to write lifecycle etag file: %w", err)
+		return fmt.Errorf("failed to write lifecycle revision file: %w", err)
 	}
 
-	return output, nil
+	return nil
+}
+
+// clearWorkspace removes the intermediate files of a previous build. The
+// lifecycle tarball itself is left in place until it is atomically replaced,
+// so that other processes can keep copying it while a rebuild is underway.
+func clearWorkspace(workspace string) error {
+	for _, name := range []string{"repo", "repo.zip", "output"} {
+		err := os.RemoveAll(filepath.Join(workspace, name))
+		if err != nil {
+			return fmt.Errorf("failed to clear workspace: %w", err)
+		}
+	}
+
+	err := os.MkdirAll(workspace, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+
+	return nil
+}
+
+func isPrebuiltLifecycle(dir string) bool {
+	for _, binary := range []string{"builder", "launcher"} {
+		info, err := os.Stat(filepath.Join(dir, binary))
+		if err != nil || !info.Mode().IsRegular() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// gitRevision resolves the commit checked out in the given directory by
+// reading the .git metadata directly so that no git executable is required.
+// Directories that are not git checkouts are reported as "local".
+func gitRevision(dir string) (string, error) {
+	head, err := os.ReadFile(filepath.Join(dir, ".git", "HEAD"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "local", nil
+		}
+
+		return "", fmt.Errorf("failed to read git HEAD: %w", err)
+	}
+
+	ref, ok := strings.CutPrefix(strings.TrimSpace(string(head)), "ref: ")
+	if !ok {
+		return strings.TrimSpace(string(head)), nil
+	}
+
+	sha, err := os.ReadFile(filepath.Join(dir, ".git", filepath.FromSlash(ref)))
+	if err == nil {
+		return strings.TrimSpace(string(sha)), nil
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read git ref: %w", err)
+	}
+
+	packedRefs, err := os.Open(filepath.Join(dir, ".git", "packed-refs"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "local", nil
+		}
+
+		return "", fmt.Errorf("failed to read git packed-refs: %w", err)
+	}
+	defer packedRefs.Close()
+
+	scanner := bufio.NewScanner(packedRefs)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == ref {
+			return fields[0], nil
+		}
+	}
+
+	return "local", nil
 }
@@ -8,16 +8,15 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/cloudfoundry/switchblade/internal/docker"
 	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
-	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
-	. "github.com/onsi/gomega/gstruct"
 )
 
 func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
@@ -25,11 +24,10 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 
 	context("Build", func() {
 		var (
-			workspace  string
-			executable *fakes.Executable
-			executions []pexec.Execution
-			server     *httptest.Server
-			archiver   *fakes.Archiver
+			workspace string
+			compiler  *fakes.LifecycleCompiler
+			server    *httptest.Server
+			archiver  *fakes.Archiver
 
 			manager docker.LifecycleManager
 		)
@@ -40,16 +38,10 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(os.WriteFile(filepath.Join(workspace, "extra-file"), nil, 0600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workspace, "output"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "output", "stale-binary"), nil, 0600)).To(Succeed())
 
-			executable = &fakes.Executable{}
-			executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
-				executions = append(executions, execution)
-				if strings.Contains(strings.Join(execution.Args, " "), "version") {
-					fmt.Fprint(execution.Stdout, "go version go1.19.1 darwin/amd64")
-				}
-
-				return nil
-			}
+			compiler = &fakes.LifecycleCompiler{}
 
 			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 				w.Header().Set("ETag", "some-etag")
@@ -78,18 +70,10 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 					return
 				}
 
-				if req.URL.Path == "/with-go-modules" {
-					f, err := writer.Create(filepath.Join("some-dir", "go.mod"))
-					if err != nil {
-						http.Error(w, err.Error(), http.StatusInternalServerError)
-						return
-					}
-
-					_, err = f.Write([]byte("go-mod-content"))
-					if err != nil {
-						http.Error(w, err.Error(), http.StatusInternalServerError)
-						return
-					}
+				err = writer.SetComment("some-commit-sha")
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
 				}
 
 				err = writer.Close()
@@ -102,15 +86,16 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 			archiver = &fakes.Archiver{}
 			archiver.WithPrefixCall.Returns.Archiver = archiver
 
-			manager = docker.NewLifecycleManager(executable, archiver)
+			manager = docker.NewLifecycleManager(compiler, archiver)
 		})
 
 		it.After(func() {
 			Expect(os.RemoveAll(workspace)).To(Succeed())
+			Expect(os.RemoveAll(fmt.Sprintf("%s.lock", workspace))).To(Succeed())
 		})
 
 		it("builds the lifecycle", func() {
-			path, err := manager.Build(server.URL, workspace)
+			path, err := manager.Build(server.URL, workspace, "arm64")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(path).To(Equal(filepath.Join(workspace, "lifecycle.tar.gz")))
 
@@ -118,29 +103,14 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(rel).To(Equal("lifecycle.tar.gz"))
 
-			Expect(executions).To(HaveLen(5))
-			Expect(executions[0]).To(MatchFields(IgnoreExtras, Fields{
-				"Args": Equal([]string{"mod", "init", "code.cloudfoundry.org/buildpackapplifecycle"}),
-				"Dir":  Equal(filepath.Join(workspace, "repo")),
-			}))
-			Expect(executions[1]).To(MatchFields(IgnoreExtras, Fields{
-				"Args": Equal([]string{"version"}),
-				"Dir":  Equal(filepath.Join(workspace, "repo")),
-			}))
-			Expect(executions[2]).To(MatchFields(IgnoreExtras, Fields{
-				"Args": Equal([]string{"mod", "tidy", "-compat", "1.19"}),
-				"Dir":  Equal(filepath.Join(workspace, "repo")),
-			}))
-			Expect(executions[3]).To(MatchFields(IgnoreExtras, Fields{
-				"Args": Equal([]string{"build", "-o", filepath.Join(workspace, "output", "builder"), "./builder"}),
-				"Env":  ContainElements("GOOS=linux", "GOARCH=amd64"),
-				"Dir":  Equal(filepath.Join(workspace, "repo")),
-			}))
-			Expect(executions[4]).To(MatchFields(IgnoreExtras, Fields{
-				"Args": Equal([]string{"build", "-o", filepath.Join(workspace, "output", "launcher"), "./launcher"}),
-				"Env":  ContainElements("GOOS=linux", "GOARCH=amd64"),
-				"Dir":  Equal(filepath.Join(workspace, "repo")),
-			}))
+			Expect(compiler.CompileCall.CallCount).To(Equal(1))
+			Expect(compiler.CompileCall.Receives.Repo).To(Equal(filepath.Join(workspace, "repo")))
+			Expect(compiler.CompileCall.Receives.Output).To(Equal(filepath.Join(workspace, "output")))
+			Expect(compiler.CompileCall.Receives.Arch).To(Equal("arm64"))
+
+			content, err := os.ReadFile(filepath.Join(workspace, "repo", "some-file"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("repo-content"))
 
 			Expect(archiver.WithPrefixCall.Receives.Prefix).To(Equal("/tmp/lifecycle"))
 			Expect(archiver.CompressCall.Receives.Input).To(Equal(filepath.Join(workspace, "output")))
@@ -150,42 +120,132 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(string(etag)).To(Equal("some-etag"))
 
-			Expect(filepath.Join(workspace, "extra-file")).NotTo(BeAnExistingFile())
+			source, err := os.ReadFile(filepath.Join(workspace, "source"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(source)).To(Equal(server.URL))
+
+			revision, err := manager.Revision(server.URL, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revision).To(Equal("some-commit-sha"))
+
+			revision, err = manager.Revision("https://example.com/other-lifecycle.zip", workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(revision).To(BeEmpty())
+
+			Expect(filepath.Join(workspace, "output", "stale-binary")).NotTo(BeAnExistingFile())
+			Expect(fmt.Sprintf("%s.lock", workspace)).To(BeAnExistingFile())
+			Expect(filepath.Join(workspace, "repo.zip")).NotTo(BeAnExistingFile())
 		})
 
-		context("when a go.mod file already exists", func() {
-			it("builds the lifecycle", func() {
-				path, err := manager.Build(fmt.Sprintf("%s/with-go-modules", server.URL), workspace)
+		context("when the source is a local checkout", func() {
+			var checkout string
+
+			it.Before(func() {
+				var err error
+				checkout, err = os.MkdirTemp("", "checkout")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.MkdirAll(filepath.Join(checkout, ".git", "refs", "heads"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(checkout, ".git", "HEAD"), []byte("ref: refs/heads/some-branch\n"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(checkout, ".git", "refs", "heads", "some-branch"), []byte("some-local-sha\n"), 0600)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(checkout, "builder"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(checkout, "builder", "main.go"), []byte("package main"), 0600)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(checkout)).To(Succeed())
+			})
+
+			it("builds the lifecycle from the checkout once", func() {
+				path, err := manager.Build(checkout, workspace, "amd64")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(path).To(Equal(filepath.Join(workspace, "lifecycle.tar.gz")))
 
-				rel, err := filepath.Rel(workspace, path)
+				Expect(filepath.Join(workspace, "repo", "builder", "main.go")).To(BeARegularFile())
+
+				Expect(compiler.CompileCall.CallCount).To(Equal(1))
+				Expect(compiler.CompileCall.Receives.Repo).To(Equal(filepath.Join(workspace, "repo")))
+				Expect(compiler.CompileCall.Receives.Output).To(Equal(filepath.Join(workspace, "output")))
+
+				revision, err := manager.Revision(checkout, workspace)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(rel).To(Equal("lifecycle.tar.gz"))
-
-				Expect(executions).To(HaveLen(4))
-				Expect(executions[0]).To(MatchFields(IgnoreExtras, Fields{
-					"Args": Equal([]string{"version"}),
-					"Dir":  Equal(filepath.Join(workspace, "repo")),
-				}))
-				Expect(executions[1]).To(MatchFields(IgnoreExtras, Fields{
-					"Args": Equal([]string{"mod", "tidy", "-compat", "1.19"}),
-					"Dir":  Equal(filepath.Join(workspace, "repo")),
-				}))
-				Expect(executions[2]).To(MatchFields(IgnoreExtras, Fields{
-					"Args": Equal([]string{"build", "-o", filepath.Join(workspace, "output", "builder"), "./builder"}),
-					"Env":  ContainElements("GOOS=linux", "GOARCH=amd64"),
-					"Dir":  Equal(filepath.Join(workspace, "repo")),
-				}))
-				Expect(executions[3]).To(MatchFields(IgnoreExtras, Fields{
-					"Args": Equal([]string{"build", "-o", filepath.Join(workspace, "output", "launcher"), "./launcher"}),
-					"Env":  ContainElements("GOOS=linux", "GOARCH=amd64"),
-					"Dir":  Equal(filepath.Join(workspace, "repo")),
-				}))
+				Expect(revision).To(Equal("some-local-sha"))
+
+				_, err = manager.Build(checkout, workspace, "amd64")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(compiler.CompileCall.CallCount).To(Equal(1))
+			})
+
+			context("when the ref is packed", func() {
+				it.Before(func() {
+					Expect(os.Remove(filepath.Join(checkout, ".git", "refs", "heads", "some-branch"))).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(checkout, ".git", "packed-refs"), []byte("# pack-refs with: peeled\nsome-packed-sha refs/heads/some-branch\n"), 0600)).To(Succeed())
+				})
+
+				it("reports the packed revision", func() {
+					_, err := manager.Build(checkout, workspace, "amd64")
+					Expect(err).NotTo(HaveOccurred())
+
+					revision, err := manager.Revision(checkout, workspace)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(revision).To(Equal("some-packed-sha"))
+				})
+			})
+
+			context("when the checkout is not a git repository", func() {
+				it.Before(func() {
+					Expect(os.RemoveAll(filepath.Join(checkout, ".git"))).To(Succeed())
+				})
+
+				it("reports a local revision", func() {
+					_, err := manager.Build(checkout, workspace, "amd64")
+					Expect(err).NotTo(HaveOccurred())
+
+					revision, err := manager.Revision(checkout, workspace)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(revision).To(Equal("local"))
+				})
+			})
+		})
+
+		context("when the source contains prebuilt binaries", func() {
+			var binaries string
+
+			it.Before(func() {
+				var err error
+				binaries, err = os.MkdirTemp("", "binaries")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(os.WriteFile(filepath.Join(binaries, "builder"), []byte("builder-binary"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(binaries, "launcher"), []byte("launcher-binary"), 0755)).To(Succeed())
+			})
+
+			it.After(func() {
+				Expect(os.RemoveAll(binaries)).To(Succeed())
+			})
+
+			it("archives the binaries without building them", func() {
+				path, err := manager.Build(binaries, workspace, "amd64")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(path).To(Equal(filepath.Join(workspace, "lifecycle.tar.gz")))
+
+				Expect(compiler.CompileCall.CallCount).To(Equal(0))
+
+				content, err := os.ReadFile(filepath.Join(workspace, "output", "builder"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("builder-binary"))
+
+				content, err = os.ReadFile(filepath.Join(workspace, "output", "launcher"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(content)).To(Equal("launcher-binary"))
 
 				Expect(archiver.WithPrefixCall.Receives.Prefix).To(Equal("/tmp/lifecycle"))
 				Expect(archiver.CompressCall.Receives.Input).To(Equal(filepath.Join(workspace, "output")))
 				Expect(archiver.CompressCall.Receives.Output).To(Equal(filepath.Join(workspace, "lifecycle.tar.gz")))
+
+				revision, err := manager.Revision(binaries, workspace)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(revision).To(Equal("prebuilt"))
 			})
 		})
 
@@ -196,16 +256,31 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("skips building the lifecycle", func() {
-				path, err := manager.Build(server.URL, workspace)
+				path, err := manager.Build(server.URL, workspace, "amd64")
 				Expect(err).NotTo(HaveOccurred())
 				Expect(path).To(Equal(filepath.Join(workspace, "lifecycle.tar.gz")))
 
-				Expect(executions).To(HaveLen(0))
+				Expect(compiler.CompileCall.CallCount).To(Equal(0))
 
 				Expect(archiver.WithPrefixCall.CallCount).To(Equal(0))
 				Expect(archiver.CompressCall.CallCount).To(Equal(0))
 			})
 
+			context("when the lifecycle was built from a different source", func() {
+				it.Before(func() {
+					err := os.WriteFile(filepath.Join(workspace, "source"), []byte("some-other-source"), 0600)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				it("rebuilds the lifecycle", func() {
+					_, err := manager.Build(server.URL, workspace, "amd64")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(compiler.CompileCall.CallCount).To(Equal(1))
+					Expect(archiver.CompressCall.CallCount).To(Equal(1))
+				})
+			})
+
 			context("failure cases", func() {
 				context("when the etag cannot be read", func() {
 					it.Before(func() {
@@ -213,7 +288,7 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 					})
 
 					it("returns an error", func() {
-						_, err := manager.Build(server.URL, workspace)
+						_, err := manager.Build(server.URL, workspace, "amd64")
 						Expect(err).To(MatchError(ContainSubstring("failed to read etag:")))
 						Expect(err).To(MatchError(ContainSubstring("permission denied")))
 					})
@@ -221,10 +296,127 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("WithOffline", func() {
+			it.Before(func() {
+				manager = manager.WithOffline()
+
+				Expect(os.WriteFile(filepath.Join(workspace, "lifecycle.tar.gz"), []byte("lifecycle-content"), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workspace, "source"), []byte(server.URL), 0600)).To(Succeed())
+
+				server.Close()
+			})
+
+			it("uses the lifecycle that was built from the remote source", func() {
+				path, err := manager.Build(server.URL, workspace, "amd64")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(path).To(Equal(filepath.Join(workspace, "lifecycle.tar.gz")))
+
+				Expect(compiler.CompileCall.CallCount).To(Equal(0))
+				Expect(archiver.CompressCall.CallCount).To(Equal(0))
+			})
+
+			context("when the source contains prebuilt binaries", func() {
+				var source string
+
+				it.Before(func() {
+					var err error
+					source, err = os.MkdirTemp("", "prebuilt")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(os.WriteFile(filepath.Join(source, "builder"), []byte("builder"), 0700)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(source, "launcher"), []byte("launcher"), 0700)).To(Succeed())
+				})
+
+				it.After(func() {
+					Expect(os.RemoveAll(source)).To(Succeed())
+				})
+
+				it("archives the binaries", func() {
+					_, err := manager.Build(source, workspace, "amd64")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(archiver.CompressCall.CallCount).To(Equal(1))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when no lifecycle was built", func() {
+					it.Before(func() {
+						Expect(os.Remove(filepath.Join(workspace, "lifecycle.tar.gz"))).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, err := manager.Build(server.URL, workspace, "amd64")
+						Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("failed to build lifecycle offline: no lifecycle built from %s is cached in %s", server.URL, workspace))))
+					})
+				})
+
+				context("when the lifecycle was built from a different source", func() {
+					it.Before(func() {
+						Expect(os.WriteFile(filepath.Join(workspace, "source"), []byte("some-other-source"), 0600)).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, err := manager.Build(server.URL, workspace, "amd64")
+						Expect(err).To(MatchError(ContainSubstring("failed to build lifecycle offline:")))
+					})
+				})
+			})
+		})
+
+		context("when another process is building into the same workspace", func() {
+			it("waits for that build to finish", func() {
+				checkout := filepath.Join(workspace, "..", fmt.Sprintf("%s-checkout", filepath.Base(workspace)))
+				Expect(os.MkdirAll(checkout, os.ModePerm)).To(Succeed())
+				defer os.RemoveAll(checkout)
+
+				var (
+					m       sync.Mutex
+					active  int
+					overlap bool
+				)
+
+				stub := func(repo, output, arch string) error {
+					m.Lock()
+					active++
+					overlap = overlap || active > 1
+					m.Unlock()
+
+					time.Sleep(50 * time.Millisecond)
+
+					m.Lock()
+					active--
+					m.Unlock()
+
+					return nil
+				}
+
+				var wg sync.WaitGroup
+				for i := 0; i < 2; i++ {
+					compiler := &fakes.LifecycleCompiler{}
+					compiler.CompileCall.Stub = stub
+
+					// Each manager has its own in-memory lock, as it would in a
+					// separate test process.
+					other := docker.NewLifecycleManager(compiler, archiver)
+
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						_, err := other.Build(checkout, workspace, "amd64")
+						Expect(err).NotTo(HaveOccurred())
+					}()
+				}
+				wg.Wait()
+
+				Expect(overlap).To(BeFalse())
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the source uri is malformed", func() {
 				it("returns an error", func() {
-					_, err := manager.Build("%%%", workspace)
+					_, err := manager.Build("%%%", workspace, "amd64")
 					Expect(err).To(MatchError(ContainSubstring("failed to create request:")))
 					Expect(err).To(MatchError(ContainSubstring("invalid URL escape")))
 				})
@@ -232,16 +424,16 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 
 			context("when the request fails", func() {
 				it("returns an error", func() {
-					_, err := manager.Build("http://localhost:0", workspace)
+					_, err := manager.Build("http://localhost:0", workspace, "amd64")
 					Expect(err).To(MatchError(ContainSubstring("failed to complete request:")))
 					Expect(err).To(MatchError(ContainSubstring("dial tcp")))
 				})
 			})
 
-			context("when the response is not a valid zip file", func() {
+			context("when the response status is not OK", func() {
 				it.Before(func() {
 					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-						fmt.Fprint(w, "this is not a zip file")
+						w.WriteHeader(http.StatusNotFound)
 					}))
 				})
 
@@ -250,114 +442,52 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("returns an error", func() {
-					_, err := manager.Build(server.URL, workspace)
-					Expect(err).To(MatchError(ContainSubstring("failed to decompress lifecycle repo:")))
-					Expect(err).To(MatchError(ContainSubstring("not a valid zip file")))
+					_, err := manager.Build(server.URL, workspace, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to download lifecycle: received unexpected response status \"404 Not Found\"")))
 				})
 			})
 
-			context("when initializing the go module fails", func() {
-				it.Before(func() {
-					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
-						if strings.Contains(strings.Join(execution.Args, " "), "mod init") {
-							fmt.Fprintln(execution.Stdout, "stdout: could not initialize")
-							fmt.Fprintln(execution.Stderr, "stderr: could not initialize")
-							return errors.New("go mod init errored")
-						}
-
-						return nil
-					}
-				})
-
+			context("when the local source does not exist", func() {
 				it("returns an error", func() {
-					_, err := manager.Build(server.URL, workspace)
-					Expect(err).To(MatchError(ContainSubstring("failed to initialize go module: go mod init errored")))
-					Expect(err).To(MatchError(ContainSubstring("stdout: could not initialize")))
-					Expect(err).To(MatchError(ContainSubstring("stderr: could not initialize")))
+					_, err := manager.Build("/no/such/lifecycle", workspace, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to stat lifecycle source:")))
+					Expect(err).To(MatchError(ContainSubstring("no such file or directory")))
 				})
 			})
 
-			context("when fetching the go version fails", func() {
-				it.Before(func() {
-					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
-						if strings.Contains(strings.Join(execution.Args, " "), "version") {
-							fmt.Fprintln(execution.Stdout, "stdout: could not version")
-							fmt.Fprintln(execution.Stderr, "stderr: could not version")
-							return errors.New("go version errored")
-						}
-
-						return nil
-					}
-				})
-
+			context("when the local source is not a directory", func() {
 				it("returns an error", func() {
-					_, err := manager.Build(server.URL, workspace)
-					Expect(err).To(MatchError(ContainSubstring("failed to identify go version: go version errored")))
-					Expect(err).To(MatchError(ContainSubstring("stdout: could not version")))
-					Expect(err).To(MatchError(ContainSubstring("stderr: could not version")))
+					_, err := manager.Build(filepath.Join(workspace, "extra-file"), workspace, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("is not a directory")))
 				})
 			})
 
-			context("when tidy-ing the go module fails", func() {
+			context("when the response is not a valid zip file", func() {
 				it.Before(func() {
-					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
-						if strings.Contains(strings.Join(execution.Args, " "), "mod tidy") {
-							fmt.Fprintln(execution.Stdout, "stdout: could not tidy")
-							fmt.Fprintln(execution.Stderr, "stderr: could not tidy")
-							return errors.New("go mod tidy errored")
-						}
-
-						return nil
-					}
-				})
-
-				it("returns an error", func() {
-					_, err := manager.Build(server.URL, workspace)
-					Expect(err).To(MatchError(ContainSubstring("failed to tidy go module: go mod tidy errored")))
-					Expect(err).To(MatchError(ContainSubstring("stdout: could not tidy")))
-					Expect(err).To(MatchError(ContainSubstring("stderr: could not tidy")))
+					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						fmt.Fprint(w, "this is not a zip file")
+					}))
 				})
-			})
 
-			context("when building the builder fails", func() {
-				it.Before(func() {
-					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
-						if strings.Contains(strings.Join(execution.Args, " "), "./builder") {
-							fmt.Fprintln(execution.Stdout, "stdout: could not build builder")
-							fmt.Fprintln(execution.Stderr, "stderr: could not build builder")
-							return errors.New("go build builder errored")
-						}
-
-						return nil
-					}
+				it.After(func() {
+					server.Close()
 				})
 
 				it("returns an error", func() {
-					_, err := manager.Build(server.URL, workspace)
-					Expect(err).To(MatchError(ContainSubstring("failed to build lifecycle builder: go build builder errored")))
-					Expect(err).To(MatchError(ContainSubstring("stdout: could not build builder")))
-					Expect(err).To(MatchError(ContainSubstring("stderr: could not build builder")))
+					_, err := manager.Build(server.URL, workspace, "amd64")
+					Expect(err).To(MatchError(ContainSubstring("failed to decompress lifecycle repo:")))
+					Expect(err).To(MatchError(ContainSubstring("not a valid zip file")))
 				})
 			})
 
-			context("when building the launcher fails", func() {
+			context("when the lifecycle cannot be compiled", func() {
 				it.Before(func() {
-					executable.ExecuteCall.Stub = func(execution pexec.Execution) error {
-						if strings.Contains(strings.Join(execution.Args, " "), "./launcher") {
-							fmt.Fprintln(execution.Stdout, "stdout: could not build launcher")
-							fmt.Fprintln(execution.Stderr, "stderr: could not build launcher")
-							return errors.New("go build launcher errored")
-						}
-
-						return nil
-					}
+					compiler.CompileCall.Returns.Error = errors.New("could not compile lifecycle")
 				})
 
 				it("returns an error", func() {
-					_, err := manager.Build(server.URL, workspace)
-					Expect(err).To(MatchError(ContainSubstring("failed to build lifecycle launcher: go build launcher errored")))
-					Expect(err).To(MatchError(ContainSubstring("stdout: could not build launcher")))
-					Expect(err).To(MatchError(ContainSubstring("stderr: could not build launcher")))
+					_, err := manager.Build(server.URL, workspace, "amd64")
+					Expect(err).To(MatchError("could not compile lifecycle"))
 				})
 			})
 
@@ -367,7 +497,7 @@ func testLifecycleManager(t *testing.T, context spec.G, it spec.S) {
 				})
 
 				it("returns an error", func() {
-					_, err := manager.Build(server.URL, workspace)
+					_, err := manager.Build(server.URL, workspace, "amd64")
 					Expect(err).To(MatchError("failed to archive lifecycle: could not compress lifecycle"))
 				})
 			})
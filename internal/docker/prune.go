@@ -0,0 +1,86 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// PruneWorkspace removes cached buildpacks, droplets, source tarballs, and
+// build caches from the workspace that have not been modified within the
+// given duration.
+func PruneWorkspace(workspace string, olderThan time.Duration) error {
+	cutoff := time.Now().Add(-olderThan)
+
+	for _, dir := range []string{"buildpacks-cache", "droplets", "source", "build-cache"} {
+		entries, err := os.ReadDir(filepath.Join(workspace, dir))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+
+			return fmt.Errorf("failed to read %s directory: %w", dir, err)
+		}
+
+		for _, entry := range entries {
+			// Lock files may be held by another process, and removing one
+			// would let a second process acquire the same lock.
+			if strings.HasSuffix(entry.Name(), ".lock") {
+				continue
+			}
+
+			path := filepath.Join(workspace, dir, entry.Name())
+
+			modTime, err := lastModified(path)
+			if err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+
+				return fmt.Errorf("failed to stat %s: %w", entry.Name(), err)
+			}
+
+			if !modTime.Before(cutoff) {
+				continue
+			}
+
+			err = os.RemoveAll(path)
+			if err != nil {
+				return fmt.Errorf("failed to remove %s: %w", entry.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// lastModified returns the most recent modification time of the given file,
+// or of anything within the given directory.
+func lastModified(path string) (time.Time, error) {
+	var latest time.Time
+	err := filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,97 @@
+package docker_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testPruneWorkspace(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		workspace string
+	)
+
+	it.Before(func() {
+		var err error
+		workspace, err = os.MkdirTemp("", "workspace")
+		Expect(err).NotTo(HaveOccurred())
+
+		old := time.Now().Add(-48 * time.Hour)
+		for _, dir := range []string{"buildpacks-cache", "droplets", "source", "build-cache", "lifecycle"} {
+			Expect(os.MkdirAll(filepath.Join(workspace, dir), os.ModePerm)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(workspace, dir, "stale"), nil, 0600)).To(Succeed())
+			Expect(os.Chtimes(filepath.Join(workspace, dir, "stale"), old, old)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(workspace, dir, "fresh"), nil, 0600)).To(Succeed())
+		}
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(workspace)).To(Succeed())
+	})
+
+	it("removes cached files older than the given duration", func() {
+		err := docker.PruneWorkspace(workspace, 24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, dir := range []string{"buildpacks-cache", "droplets", "source", "build-cache"} {
+			Expect(filepath.Join(workspace, dir, "stale")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(workspace, dir, "fresh")).To(BeAnExistingFile())
+		}
+
+		Expect(filepath.Join(workspace, "lifecycle", "stale")).To(BeAnExistingFile())
+	})
+
+	context("when the buildpacks cache holds lock files", func() {
+		it.Before(func() {
+			old := time.Now().Add(-48 * time.Hour)
+			Expect(os.WriteFile(filepath.Join(workspace, "buildpacks-cache", "stale.lock"), nil, 0600)).To(Succeed())
+			Expect(os.Chtimes(filepath.Join(workspace, "buildpacks-cache", "stale.lock"), old, old)).To(Succeed())
+		})
+
+		it("keeps them, since another process may hold them", func() {
+			err := docker.PruneWorkspace(workspace, 24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(workspace, "buildpacks-cache", "stale.lock")).To(BeAnExistingFile())
+		})
+	})
+
+	context("when the build cache of an app is a directory", func() {
+		it.Before(func() {
+			old := time.Now().Add(-48 * time.Hour)
+			for _, name := range []string{"stale-app", "fresh-app"} {
+				Expect(os.MkdirAll(filepath.Join(workspace, "build-cache", name, "some-dir"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workspace, "build-cache", name, "some-dir", "some-file"), nil, 0600)).To(Succeed())
+				Expect(os.Chtimes(filepath.Join(workspace, "build-cache", name, "some-dir"), old, old)).To(Succeed())
+				Expect(os.Chtimes(filepath.Join(workspace, "build-cache", name), old, old)).To(Succeed())
+			}
+
+			Expect(os.Chtimes(filepath.Join(workspace, "build-cache", "stale-app", "some-dir", "some-file"), old, old)).To(Succeed())
+		})
+
+		it("removes the directory once nothing within it has been modified", func() {
+			err := docker.PruneWorkspace(workspace, 24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(filepath.Join(workspace, "build-cache", "stale-app")).NotTo(BeADirectory())
+			Expect(filepath.Join(workspace, "build-cache", "fresh-app", "some-dir", "some-file")).To(BeAnExistingFile())
+		})
+	})
+
+	context("when the workspace does not exist", func() {
+		it("does nothing", func() {
+			err := docker.PruneWorkspace(filepath.Join(workspace, "missing"), time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+}
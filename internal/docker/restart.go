@@ -0,0 +1,232 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type RestartPhase interface {
+	Run(ctx context.Context, name, droplet string, env map[string]string, unset []string) (externalURL, internalURL string, err error)
+	BindService(ctx context.Context, name, droplet, service string, credentials map[string]interface{}) (externalURL, internalURL string, err error)
+	UnbindService(ctx context.Context, name, droplet, service string) (externalURL, internalURL string, err error)
+}
+
+//go:generate faux --interface RestartClient --output fakes/restart_client.go
+type RestartClient interface {
+	ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
+	ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.CreateResponse, error)
+	CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error
+	ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error
+}
+
+// Restart recreates the container of a running app with a changed
+// environment. An app that was started from a droplet is started from the
+// same droplet again, so that its .profile.d scripts see the new environment,
+// without staging it again.
+type Restart struct {
+	client       RestartClient
+	networks     StartNetworkManager
+	workspace    string
+	daemonHost   string
+	arch         string
+	archResolver ArchitectureResolver
+}
+
+// NewRestart returns a Restart that recreates apps on the Docker daemon at
+// the given daemonHost, in the same way as NewStart.
+func NewRestart(client RestartClient, networks StartNetworkManager, workspace, daemonHost string) Restart {
+	return Restart{
+		client:     client,
+		networks:   networks,
+		workspace:  workspace,
+		daemonHost: daemonHost,
+	}
+}
+
+// WithArchitecture sets the CPU architecture that the app container is run
+// as. It must match the architecture the lifecycle was built for during
+// setup.
+func (r Restart) WithArchitecture(arch string) Restart {
+	r.arch = arch
+	return r
+}
+
+// WithArchitectureResolver resolves the architecture with the given resolver
+// when it is not set with WithArchitecture.
+func (r Restart) WithArchitectureResolver(resolver ArchitectureResolver) Restart {
+	r.archResolver = resolver
+	return r
+}
+
+// Run restarts the app with the given name after setting the given env and
+// removing the unset keys from it. The droplet is the one at the given path,
+// or the one that was staged for the app when the path is empty.
+func (r Restart) Run(ctx context.Context, name, droplet string, env map[string]string, unset []string) (string, string, error) {
+	return r.recreate(ctx, name, droplet, func(variables []string) ([]string, error) {
+		return updateEnv(variables, env, unset), nil
+	})
+}
+
+// BindService restarts the app with the given name after adding a
+// user-provided service with the given credentials to its VCAP_SERVICES, or
+// replacing the credentials of the service when it is already there. The
+// service is named after the app, in the same way as the services that the
+// app was deployed with.
+func (r Restart) BindService(ctx context.Context, name, droplet, service string, credentials map[string]interface{}) (string, string, error) {
+	if credentials == nil {
+		credentials = map[string]interface{}{}
+	}
+
+	return r.recreate(ctx, name, droplet, func(variables []string) ([]string, error) {
+		return updateServices(variables, fmt.Sprintf("%s-%s", name, service), credentials)
+	})
+}
+
+// UnbindService restarts the app with the given name after removing a
+// user-provided service from its VCAP_SERVICES.
+func (r Restart) UnbindService(ctx context.Context, name, droplet, service string) (string, string, error) {
+	return r.recreate(ctx, name, droplet, func(variables []string) ([]string, error) {
+		return updateServices(variables, fmt.Sprintf("%s-%s", name, service), nil)
+	})
+}
+
+// recreate removes the app container and creates it again with the env that
+// is returned by the given update.
+func (r Restart) recreate(ctx context.Context, name, droplet string, update func(env []string) ([]string, error)) (string, string, error) {
+	r.arch = r.archResolver.Resolve(ctx, r.arch)
+
+	app, err := r.client.ContainerInspect(ctx, name)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to inspect app container: %w", err)
+	}
+
+	if app.Config == nil {
+		return "", "", fmt.Errorf("failed to inspect app container: %s has no config", name)
+	}
+
+	env, err := update(app.Config.Env)
+	if err != nil {
+		return "", "", err
+	}
+
+	containerConfig := container.Config{
+		Image:        app.Config.Image,
+		Cmd:          app.Config.Cmd,
+		User:         app.Config.User,
+		Env:          env,
+		WorkingDir:   app.Config.WorkingDir,
+		ExposedPorts: app.Config.ExposedPorts,
+	}
+
+	// Only an app that is run by the launcher was started from a droplet,
+	// rather than from an image.
+	if len(containerConfig.Cmd) > 0 && containerConfig.Cmd[0] == launcherPath {
+		if droplet == "" {
+			droplet = filepath.Join(r.workspace, "droplets", fmt.Sprintf("%s.tar.gz", name))
+		}
+
+		// The app is only removed once it is known that it can be recreated.
+		_, err = os.Stat(droplet)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to open droplet: %w", err)
+		}
+	} else {
+		droplet = ""
+	}
+
+	err = r.client.ContainerRemove(ctx, app.ID, container.RemoveOptions{Force: true})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to remove app container: %w", err)
+	}
+
+	return runApp(ctx, r.client, r.networks, r.workspace, r.daemonHost, r.arch, name, containerConfig, droplet)
+}
+
+// updateEnv returns the given env, as KEY=VALUE pairs, with the given keys
+// set and unset. The keys that are set are added at the end in sorted order.
+func updateEnv(env []string, set map[string]string, unset []string) []string {
+	removed := make(map[string]bool)
+	for _, key := range unset {
+		removed[key] = true
+	}
+	for key := range set {
+		removed[key] = true
+	}
+
+	var result []string
+	for _, variable := range env {
+		key, _, _ := strings.Cut(variable, "=")
+		if !removed[key] {
+			result = append(result, variable)
+		}
+	}
+
+	var keys []string
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		result = append(result, fmt.Sprintf("%s=%s", key, set[key]))
+	}
+
+	return result
+}
+
+// updateServices returns the given env, as KEY=VALUE pairs, with the
+// user-provided service of the given name in its VCAP_SERVICES replaced by
+// one with the given credentials, or removed when they are nil.
+func updateServices(env []string, name string, credentials map[string]interface{}) ([]string, error) {
+	services := map[string][]map[string]interface{}{}
+	for _, variable := range env {
+		if value, ok := strings.CutPrefix(variable, "VCAP_SERVICES="); ok {
+			err := json.Unmarshal([]byte(value), &services)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse services json: %w", err)
+			}
+		}
+	}
+
+	var userProvided []map[string]interface{}
+	for _, service := range services["user-provided"] {
+		if service["name"] != name {
+			userProvided = append(userProvided, service)
+		}
+	}
+
+	if credentials != nil {
+		userProvided = append(userProvided, map[string]interface{}{
+			"name":        name,
+			"credentials": credentials,
+		})
+	}
+
+	sort.Slice(userProvided, func(i, j int) bool {
+		return fmt.Sprint(userProvided[i]["name"]) < fmt.Sprint(userProvided[j]["name"])
+	})
+
+	delete(services, "user-provided")
+	if len(userProvided) > 0 {
+		services["user-provided"] = userProvided
+	}
+
+	content, err := json.Marshal(services)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal services json: %w", err)
+	}
+
+	return updateEnv(env, map[string]string{"VCAP_SERVICES": string(content)}, nil), nil
+}
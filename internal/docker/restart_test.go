@@ -0,0 +1,335 @@
+package docker_test
+
+import (
+	gocontext "context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+	"github.com/cloudfoundry/switchblade/internal/docker/fakes"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testRestart(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Run", func() {
+		var (
+			restart docker.Restart
+
+			client         *fakes.RestartClient
+			networkManager *fakes.StartNetworkManager
+			workspace      string
+
+			copyToContainerInvocations []copyToContainerInvocation
+		)
+
+		it.Before(func() {
+			var err error
+			workspace, err = os.MkdirTemp("", "workspace")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle", "amd64"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "amd64", "lifecycle.tar.gz"), []byte("lifecycle-content"), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workspace, "droplets"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "droplets", "some-app.tar.gz"), []byte("droplet-content"), 0600)).To(Succeed())
+
+			client = &fakes.RestartClient{}
+			client.ContainerInspectCall.Stub = func(ctx gocontext.Context, containerID string) (types.ContainerJSON, error) {
+				if containerID == "some-app" {
+					return types.ContainerJSON{
+						ContainerJSONBase: &types.ContainerJSONBase{ID: "some-old-container-id"},
+						Config: &container.Config{
+							Image:        "cloudfoundry/some-stack:latest",
+							Cmd:          []string{"/tmp/lifecycle/launcher", "app", "some-command", ""},
+							User:         "vcap",
+							Env:          []string{"PORT=8080", "SOME_KEY=some-value", "OTHER_KEY=other-value"},
+							WorkingDir:   "/home/vcap",
+							ExposedPorts: nat.PortSet{"8080/tcp": struct{}{}},
+						},
+					}, nil
+				}
+
+				return types.ContainerJSON{
+					NetworkSettings: &types.NetworkSettings{
+						NetworkSettingsBase: types.NetworkSettingsBase{
+							Ports: nat.PortMap{
+								"8080/tcp": []nat.PortBinding{{HostIP: "0.0.0.0", HostPort: "23456"}},
+							},
+						},
+						Networks: map[string]*network.EndpointSettings{
+							"switchblade-internal": {IPAddress: "172.19.0.3"},
+						},
+					},
+				}, nil
+			}
+			client.ContainerCreateCall.Returns.CreateResponse = container.CreateResponse{ID: "some-new-container-id"}
+			client.CopyToContainerCall.Stub = func(ctx gocontext.Context, containerID, dstPath string, content io.Reader, options container.CopyToContainerOptions) error {
+				b, err := io.ReadAll(content)
+				if err != nil {
+					return err
+				}
+
+				copyToContainerInvocations = append(copyToContainerInvocations, copyToContainerInvocation{
+					ContainerID: containerID,
+					DstPath:     dstPath,
+					Content:     string(b),
+				})
+
+				return nil
+			}
+
+			networkManager = &fakes.StartNetworkManager{}
+
+			restart = docker.NewRestart(client, networkManager, workspace, "unix:///var/run/docker.sock")
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(workspace)).To(Succeed())
+		})
+
+		it("recreates the app container with the new env", func() {
+			ctx := gocontext.Background()
+
+			externalURL, internalURL, err := restart.Run(ctx, "some-app", "", map[string]string{
+				"SOME_KEY": "new-value",
+				"NEW_KEY":  "new-value",
+			}, []string{"OTHER_KEY"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(externalURL).To(Equal("http://localhost:23456"))
+			Expect(internalURL).To(Equal("http://172.19.0.3:8080"))
+
+			Expect(client.ContainerRemoveCall.Receives.ContainerID).To(Equal("some-old-container-id"))
+			Expect(client.ContainerRemoveCall.Receives.Options).To(Equal(container.RemoveOptions{Force: true}))
+
+			Expect(client.ContainerCreateCall.Receives.Config).To(Equal(&container.Config{
+				Image:        "cloudfoundry/some-stack:latest",
+				Cmd:          []string{"/tmp/lifecycle/launcher", "app", "some-command", ""},
+				User:         "vcap",
+				Env:          []string{"PORT=8080", "NEW_KEY=new-value", "SOME_KEY=new-value"},
+				WorkingDir:   "/home/vcap",
+				ExposedPorts: nat.PortSet{"8080/tcp": struct{}{}},
+			}))
+			Expect(client.ContainerCreateCall.Receives.HostConfig).To(Equal(&container.HostConfig{
+				PublishAllPorts: true,
+				NetworkMode:     container.NetworkMode("switchblade-internal"),
+			}))
+			Expect(client.ContainerCreateCall.Receives.ContainerName).To(Equal("some-app"))
+
+			Expect(networkManager.ConnectCall.Receives.ContainerID).To(Equal("some-new-container-id"))
+			Expect(networkManager.ConnectCall.Receives.Name).To(Equal("bridge"))
+
+			Expect(copyToContainerInvocations).To(Equal([]copyToContainerInvocation{
+				{
+					ContainerID: "some-new-container-id",
+					DstPath:     "/",
+					Content:     "lifecycle-content",
+				},
+				{
+					ContainerID: "some-new-container-id",
+					DstPath:     "/home/vcap/",
+					Content:     "droplet-content",
+				},
+			}))
+
+			Expect(client.ContainerStartCall.Receives.ContainerID).To(Equal("some-new-container-id"))
+		})
+
+		context("when the Docker daemon is remote", func() {
+			it.Before(func() {
+				restart = docker.NewRestart(client, networkManager, workspace, "tcp://some-docker-host:2376")
+			})
+
+			it("returns an external url on the daemon host", func() {
+				externalURL, _, err := restart.Run(gocontext.Background(), "some-app", "", nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(externalURL).To(Equal("http://some-docker-host:23456"))
+			})
+		})
+
+		context("when a droplet is given", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workspace, "other-droplet.tgz"), []byte("other-droplet-content"), 0600)).To(Succeed())
+			})
+
+			it("restarts the app from that droplet", func() {
+				_, _, err := restart.Run(gocontext.Background(), "some-app", filepath.Join(workspace, "other-droplet.tgz"), nil, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(copyToContainerInvocations).To(ContainElement(copyToContainerInvocation{
+					ContainerID: "some-new-container-id",
+					DstPath:     "/home/vcap/",
+					Content:     "other-droplet-content",
+				}))
+			})
+		})
+
+		context("when the app was started from an image", func() {
+			it.Before(func() {
+				stub := client.ContainerInspectCall.Stub
+				client.ContainerInspectCall.Stub = func(ctx gocontext.Context, containerID string) (types.ContainerJSON, error) {
+					ctnr, err := stub(ctx, containerID)
+					if containerID == "some-app" {
+						ctnr.Config.Image = "some-org/some-image:some-tag"
+						ctnr.Config.Cmd = nil
+					}
+
+					return ctnr, err
+				}
+			})
+
+			it("recreates the app container without a droplet", func() {
+				_, _, err := restart.Run(gocontext.Background(), "some-app", "", map[string]string{"SOME_KEY": "new-value"}, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ContainerCreateCall.Receives.Config.Image).To(Equal("some-org/some-image:some-tag"))
+				Expect(client.ContainerCreateCall.Receives.Config.Env).To(ContainElement("SOME_KEY=new-value"))
+				Expect(copyToContainerInvocations).To(BeEmpty())
+			})
+		})
+
+		context("BindService", func() {
+			it.Before(func() {
+				stub := client.ContainerInspectCall.Stub
+				client.ContainerInspectCall.Stub = func(ctx gocontext.Context, containerID string) (types.ContainerJSON, error) {
+					ctnr, err := stub(ctx, containerID)
+					if containerID == "some-app" {
+						ctnr.Config.Env = []string{
+							"PORT=8080",
+							`VCAP_SERVICES={"user-provided":[{"credentials":{"some-key":"some-value"},"name":"some-app-some-service"}]}`,
+						}
+					}
+
+					return ctnr, err
+				}
+			})
+
+			it("recreates the app container with the service in VCAP_SERVICES", func() {
+				externalURL, _, err := restart.BindService(gocontext.Background(), "some-app", "", "other-service", map[string]interface{}{
+					"other-key": "other-value",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(externalURL).To(Equal("http://localhost:23456"))
+
+				Expect(client.ContainerRemoveCall.Receives.ContainerID).To(Equal("some-old-container-id"))
+				Expect(client.ContainerCreateCall.Receives.Config.Env).To(Equal([]string{
+					"PORT=8080",
+					`VCAP_SERVICES={"user-provided":[{"credentials":{"other-key":"other-value"},"name":"some-app-other-service"},{"credentials":{"some-key":"some-value"},"name":"some-app-some-service"}]}`,
+				}))
+			})
+
+			context("when the service is already bound", func() {
+				it("replaces its credentials", func() {
+					_, _, err := restart.BindService(gocontext.Background(), "some-app", "", "some-service", map[string]interface{}{
+						"some-key": "rotated-value",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(client.ContainerCreateCall.Receives.Config.Env).To(ContainElement(
+						`VCAP_SERVICES={"user-provided":[{"credentials":{"some-key":"rotated-value"},"name":"some-app-some-service"}]}`,
+					))
+				})
+			})
+
+			context("when VCAP_SERVICES cannot be parsed", func() {
+				it.Before(func() {
+					client.ContainerInspectCall.Stub = func(ctx gocontext.Context, containerID string) (types.ContainerJSON, error) {
+						return types.ContainerJSON{
+							ContainerJSONBase: &types.ContainerJSONBase{ID: "some-old-container-id"},
+							Config:            &container.Config{Env: []string{"VCAP_SERVICES=%%%"}},
+						}, nil
+					}
+				})
+
+				it("returns an error without removing the app container", func() {
+					_, _, err := restart.BindService(gocontext.Background(), "some-app", "", "some-service", nil)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse services json:")))
+
+					Expect(client.ContainerRemoveCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
+		context("UnbindService", func() {
+			it.Before(func() {
+				stub := client.ContainerInspectCall.Stub
+				client.ContainerInspectCall.Stub = func(ctx gocontext.Context, containerID string) (types.ContainerJSON, error) {
+					ctnr, err := stub(ctx, containerID)
+					if containerID == "some-app" {
+						ctnr.Config.Env = []string{
+							`VCAP_SERVICES={"user-provided":[{"credentials":{"some-key":"some-value"},"name":"some-app-some-service"}]}`,
+							"PORT=8080",
+						}
+					}
+
+					return ctnr, err
+				}
+			})
+
+			it("recreates the app container without the service in VCAP_SERVICES", func() {
+				_, _, err := restart.UnbindService(gocontext.Background(), "some-app", "", "some-service")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ContainerCreateCall.Receives.Config.Env).To(Equal([]string{
+					"PORT=8080",
+					"VCAP_SERVICES={}",
+				}))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the app container cannot be inspected", func() {
+				it.Before(func() {
+					client.ContainerInspectCall.Stub = nil
+					client.ContainerInspectCall.Returns.Error = errors.New("no such container")
+				})
+
+				it("returns an error", func() {
+					_, _, err := restart.Run(gocontext.Background(), "some-app", "", nil, nil)
+					Expect(err).To(MatchError("failed to inspect app container: no such container"))
+				})
+			})
+
+			context("when the droplet does not exist", func() {
+				it("returns an error without removing the app container", func() {
+					_, _, err := restart.Run(gocontext.Background(), "some-app", filepath.Join(workspace, "no-such-droplet.tgz"), nil, nil)
+					Expect(err).To(MatchError(ContainSubstring("failed to open droplet:")))
+
+					Expect(client.ContainerRemoveCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the app container cannot be removed", func() {
+				it.Before(func() {
+					client.ContainerRemoveCall.Returns.Error = errors.New("could not remove container")
+				})
+
+				it("returns an error", func() {
+					_, _, err := restart.Run(gocontext.Background(), "some-app", "", nil, nil)
+					Expect(err).To(MatchError("failed to remove app container: could not remove container"))
+				})
+			})
+
+			context("when the container cannot be created", func() {
+				it.Before(func() {
+					client.ContainerCreateCall.Returns.Error = errors.New("could not create container")
+				})
+
+				it("returns an error", func() {
+					_, _, err := restart.Run(gocontext.Background(), "some-app", "", nil, nil)
+					Expect(err).To(MatchError("failed to create running container: could not create container"))
+				})
+			})
+		})
+	})
+}
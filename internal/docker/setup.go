@@ -30,6 +30,9 @@ type SetupPhase interface {
 	WithEnv(env map[string]string) SetupPhase
 	WithoutInternetAccess() SetupPhase
 	WithServices(services map[string]map[string]interface{}) SetupPhase
+	WithObserver(observer PhaseObserver) SetupPhase
+	WithoutStaging() SetupPhase
+	WithImage(image string) SetupPhase
 }
 
 //go:generate faux --interface SetupClient --output fakes/setup_client.go
@@ -43,7 +46,8 @@ type SetupClient interface {
 
 //go:generate faux --interface LifecycleBuilder --output fakes/lifecycle_builder.go
 type LifecycleBuilder interface {
-	Build(sourceURI, workspace string) (path string, err error)
+	Build(sourceURI, workspace, arch string) (path string, err error)
+	Revision(sourceURI, workspace string) (revision string, err error)
 }
 
 //go:generate faux --interface BuildpacksBuilder --output fakes/buildpacks_builder.go
@@ -51,6 +55,7 @@ type BuildpacksBuilder interface {
 	Order() (order string, skipDetect bool, err error)
 	Build(workspace, name string) (path string, err error)
 	WithBuildpacks(buildpacks ...string) BuildpacksBuilder
+	WithStack(stack string) BuildpacksBuilder
 }
 
 //go:generate faux --interface Archiver --output fakes/archiver.go
@@ -68,6 +73,9 @@ type SetupNetworkManager interface {
 type Setup struct {
 	client             SetupClient
 	lifecycle          LifecycleBuilder
+	lifecycleSource    string
+	arch               string
+	archResolver       ArchitectureResolver
 	archiver           Archiver
 	buildpacks         BuildpacksBuilder
 	stack              string
@@ -76,27 +84,72 @@ type Setup struct {
 	env                map[string]string
 	disconnectInternet bool
 	services           map[string]map[string]interface{}
+	observer           PhaseObserver
+	withoutStaging     bool
+	image              string
 }
 
 func NewSetup(client SetupClient, lifecycle LifecycleBuilder, buildpacks BuildpacksBuilder, archiver Archiver, networks SetupNetworkManager, workspace, stack string) Setup {
 	return Setup{
-		client:     client,
-		lifecycle:  lifecycle,
-		stack:      stack,
-		buildpacks: buildpacks,
-		archiver:   archiver,
-		networks:   networks,
-		workspace:  workspace,
+		client:          client,
+		lifecycle:       lifecycle,
+		lifecycleSource: BuildpackAppLifecycleRepoURL,
+		stack:           stack,
+		buildpacks:      buildpacks,
+		archiver:        archiver,
+		networks:        networks,
+		workspace:       workspace,
+		observer:        noopObserver{},
 	}
 }
 
 func (s Setup) Run(ctx context.Context, logs io.Writer, name, path string) (string, error) {
-	lifecycle, err := s.lifecycle.Build(BuildpackAppLifecycleRepoURL, filepath.Join(s.workspace, "lifecycle"))
+	s.arch = s.archResolver.Resolve(ctx, s.arch)
+
+	if s.image != "" {
+		s.observer.Started("image-pull")
+		err := s.pullImage(ctx, logs, s.image)
+		s.observer.Finished("image-pull", err)
+		if err != nil {
+			return "", err
+		}
+
+		err = s.removeContainer(ctx, name)
+		if err != nil {
+			return "", err
+		}
+
+		return "", nil
+	}
+
+	s.observer.Started("lifecycle-build")
+	lifecycle, err := s.lifecycle.Build(s.lifecycleSource, filepath.Join(s.workspace, "lifecycle", s.arch), s.arch)
+	s.observer.Finished("lifecycle-build", err)
 	if err != nil {
 		return "", fmt.Errorf("failed to build lifecycle: %w", err)
 	}
 
-	buildpacks, err := s.buildpacks.Build(filepath.Join(s.workspace, "buildpacks"), name)
+	if s.withoutStaging {
+		s.observer.Started("image-pull")
+		err = s.pullImage(ctx, logs, fmt.Sprintf("cloudfoundry/%s:latest", s.stack))
+		s.observer.Finished("image-pull", err)
+		if err != nil {
+			return "", err
+		}
+
+		err = s.removeContainer(ctx, name)
+		if err != nil {
+			return "", err
+		}
+
+		return "", nil
+	}
+
+	builder := s.buildpacks.WithStack(s.stack)
+
+	s.observer.Started("buildpack-fetch")
+	buildpacks, err := builder.Build(filepath.Join(s.workspace, "buildpacks"), name)
+	s.observer.Finished("buildpack-fetch", err)
 	if err != nil {
 		return "", fmt.Errorf("failed to build buildpacks: %w", err)
 	}
@@ -107,15 +160,11 @@ func (s Setup) Run(ctx context.Context, logs io.Writer, name, path string) (stri
 		return "", fmt.Errorf("failed to archive source code: %w", err)
 	}
 
-	pullLogs, err := s.client.ImagePull(ctx, fmt.Sprintf("cloudfoundry/%s:latest", s.stack), image.PullOptions{})
+	s.observer.Started("image-pull")
+	err = s.pullImage(ctx, logs, fmt.Sprintf("cloudfoundry/%s:latest", s.stack))
+	s.observer.Finished("image-pull", err)
 	if err != nil {
-		return "", fmt.Errorf("failed to pull base image: %w", err)
-	}
-	defer pullLogs.Close()
-
-	_, err = io.Copy(logs, pullLogs)
-	if err != nil {
-		return "", fmt.Errorf("failed to copy image pull logs: %w", err)
+		return "", err
 	}
 
 	env := []string{fmt.Sprintf("CF_STACK=%s", s.stack)}
@@ -150,20 +199,14 @@ func (s Setup) Run(ctx context.Context, logs io.Writer, name, path string) (stri
 		env = append(env, "VCAP_SERVICES={}")
 	}
 
-	order, skipDetect, err := s.buildpacks.Order()
+	order, skipDetect, err := builder.Order()
 	if err != nil {
 		return "", fmt.Errorf("failed to determine buildpack ordering: %w", err)
 	}
 
-	ctnr, err := s.client.ContainerInspect(ctx, name)
-	if err != nil && !errdefs.IsNotFound(err) {
-		return "", fmt.Errorf("failed to inspect staging container: %w", err)
-	}
-	if err == nil {
-		err = s.client.ContainerRemove(ctx, ctnr.ID, container.RemoveOptions{Force: true})
-		if err != nil {
-			return "", fmt.Errorf("failed to remove conflicting container: %w", err)
-		}
+	err = s.removeContainer(ctx, name)
+	if err != nil {
+		return "", err
 	}
 
 	containerConfig := container.Config{
@@ -188,7 +231,7 @@ func (s Setup) Run(ctx context.Context, logs io.Writer, name, path string) (stri
 		NetworkMode: container.NetworkMode(InternalNetworkName),
 	}
 
-	resp, err := s.client.ContainerCreate(ctx, &containerConfig, &hostConfig, nil, nil, name)
+	resp, err := s.client.ContainerCreate(ctx, &containerConfig, &hostConfig, nil, platformFor(s.arch), name)
 	if err != nil {
 		return "", fmt.Errorf("failed to create staging container: %w", err)
 	}
@@ -228,11 +271,91 @@ func (s Setup) Run(ctx context.Context, logs io.Writer, name, path string) (stri
 	return resp.ID, nil
 }
 
+// removeContainer removes any container that is left over from a previous
+// deployment with the same name.
+func (s Setup) removeContainer(ctx context.Context, name string) error {
+	ctnr, err := s.client.ContainerInspect(ctx, name)
+	if err != nil && !errdefs.IsNotFound(err) {
+		return fmt.Errorf("failed to inspect staging container: %w", err)
+	}
+	if err == nil {
+		err = s.client.ContainerRemove(ctx, ctnr.ID, container.RemoveOptions{Force: true})
+		if err != nil {
+			return fmt.Errorf("failed to remove conflicting container: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// pullImage pulls the given image, which completes once its pull logs have
+// been read in full.
+func (s Setup) pullImage(ctx context.Context, logs io.Writer, ref string) error {
+	pullLogs, err := s.client.ImagePull(ctx, ref, image.PullOptions{
+		Platform: fmt.Sprintf("linux/%s", s.arch),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pull base image: %w", err)
+	}
+	defer pullLogs.Close()
+
+	_, err = io.Copy(logs, pullLogs)
+	if err != nil {
+		return fmt.Errorf("failed to copy image pull logs: %w", err)
+	}
+
+	return nil
+}
+
+// WithLifecycleSource overrides the source the lifecycle is built from. See
+// LifecycleManager.Build for the supported kinds of source.
+func (s Setup) WithLifecycleSource(source string) Setup {
+	s.lifecycleSource = source
+	return s
+}
+
+// WithArchitecture sets the CPU architecture that the lifecycle is built for
+// and that the stack image is pulled and run as.
+func (s Setup) WithArchitecture(arch string) Setup {
+	s.arch = arch
+	return s
+}
+
+// WithArchitectureResolver resolves the architecture with the given resolver
+// when it is not set with WithArchitecture.
+func (s Setup) WithArchitectureResolver(resolver ArchitectureResolver) Setup {
+	s.archResolver = resolver
+	return s
+}
+
 func (s Setup) WithBuildpacks(buildpacks ...string) SetupPhase {
 	s.buildpacks = s.buildpacks.WithBuildpacks(buildpacks...)
 	return s
 }
 
+// WithObserver reports the time taken to build the lifecycle, fetch the
+// buildpacks, and pull the stack image to the given observer.
+func (s Setup) WithObserver(observer PhaseObserver) SetupPhase {
+	s.observer = observer
+	return s
+}
+
+// WithoutStaging only prepares to run a droplet that was staged before, by
+// building the lifecycle and pulling the stack image. It does not create a
+// staging container, so the returned container ID is empty.
+func (s Setup) WithoutStaging() SetupPhase {
+	s.withoutStaging = true
+	return s
+}
+
+// WithImage only prepares to run the Docker image with the given reference, by
+// pulling it. Neither the lifecycle nor the buildpacks are needed, so the
+// returned container ID is empty.
+func (s Setup) WithImage(image string) SetupPhase {
+	s.image = image
+	return s
+}
+
 func (s Setup) WithStack(stack string) SetupPhase {
 	s.stack = stack
 	return s
@@ -17,6 +17,7 @@ import (
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/errdefs"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sclevine/spec"
 
 	. "github.com/cloudfoundry/switchblade/matchers"
@@ -46,9 +47,9 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 			Expect(err).NotTo(HaveOccurred())
 
 			lifecycleBuilder = &fakes.LifecycleBuilder{}
-			lifecycleBuilder.BuildCall.Returns.Path = filepath.Join(workspace, "lifecycle", "lifecycle.tar.gz")
-			Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle"), os.ModePerm)).To(Succeed())
-			Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "lifecycle.tar.gz"), []byte("lifecycle-content"), 0600)).To(Succeed())
+			lifecycleBuilder.BuildCall.Returns.Path = filepath.Join(workspace, "lifecycle", "amd64", "lifecycle.tar.gz")
+			Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle", "amd64"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "amd64", "lifecycle.tar.gz"), []byte("lifecycle-content"), 0600)).To(Succeed())
 
 			buildpacksBuilder = &fakes.BuildpacksBuilder{}
 			buildpacksBuilder.BuildCall.Returns.Path = filepath.Join(workspace, "buildpacks", "some-app.tar.gz")
@@ -56,6 +57,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 			Expect(os.WriteFile(filepath.Join(workspace, "buildpacks", "some-app.tar.gz"), []byte("buildpacks-content"), 0600)).To(Succeed())
 
 			buildpacksBuilder.OrderCall.Returns.Order = "some-buildpack,other-buildpack"
+			buildpacksBuilder.WithStackCall.Returns.BuildpacksBuilder = buildpacksBuilder
 
 			archiver = &fakes.Archiver{}
 			archiver.WithPrefixCall.Returns.Archiver = archiver
@@ -99,7 +101,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 			Expect(containerID).To(Equal("some-container-id"))
 
 			Expect(lifecycleBuilder.BuildCall.Receives.SourceURI).To(Equal("https://github.com/cloudfoundry/buildpackapplifecycle/archive/refs/heads/main.zip"))
-			Expect(lifecycleBuilder.BuildCall.Receives.Workspace).To(Equal(filepath.Join(workspace, "lifecycle")))
+			Expect(lifecycleBuilder.BuildCall.Receives.Workspace).To(Equal(filepath.Join(workspace, "lifecycle", "amd64")))
 
 			Expect(archiver.WithPrefixCall.Receives.Prefix).To(Equal("/tmp/app"))
 			Expect(archiver.CompressCall.Receives.Input).To(Equal("/some/path/to/my/app"))
@@ -107,8 +109,10 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 			Expect(buildpacksBuilder.BuildCall.Receives.Workspace).To(Equal(filepath.Join(workspace, "buildpacks")))
 			Expect(buildpacksBuilder.BuildCall.Receives.Name).To(Equal("some-app"))
+			Expect(buildpacksBuilder.WithStackCall.Receives.Stack).To(Equal("default-stack"))
 
 			Expect(client.ImagePullCall.Receives.Ref).To(Equal("cloudfoundry/default-stack:latest"))
+			Expect(client.ImagePullCall.Receives.Options.Platform).To(Equal("linux/amd64"))
 
 			Expect(client.ContainerInspectCall.Receives.ContainerID).To(Equal("some-app"))
 			Expect(client.ContainerRemoveCall.CallCount).To(Equal(0))
@@ -138,6 +142,11 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 				NetworkMode: container.NetworkMode("switchblade-internal"),
 			}))
 
+			Expect(client.ContainerCreateCall.Receives.Platform).To(Equal(&specs.Platform{
+				OS:           "linux",
+				Architecture: "amd64",
+			}))
+
 			Expect(client.ContainerCreateCall.Receives.ContainerName).To(Equal("some-app"))
 
 			Expect(networkManager.ConnectCall.Receives.ContainerID).To(Equal("some-container-id"))
@@ -163,6 +172,42 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 			Expect(logs).To(ContainLines("Pulling image..."))
 		})
 
+		context("WithArchitecture", func() {
+			it("builds the lifecycle and runs the stack for that architecture", func() {
+				ctx := gocontext.Background()
+				logs := bytes.NewBuffer(nil)
+
+				_, err := setup.
+					WithArchitecture("arm64").
+					Run(ctx, logs, "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lifecycleBuilder.BuildCall.Receives.Workspace).To(Equal(filepath.Join(workspace, "lifecycle", "arm64")))
+				Expect(lifecycleBuilder.BuildCall.Receives.Arch).To(Equal("arm64"))
+
+				Expect(client.ImagePullCall.Receives.Options.Platform).To(Equal("linux/arm64"))
+				Expect(client.ContainerCreateCall.Receives.Platform).To(Equal(&specs.Platform{
+					OS:           "linux",
+					Architecture: "arm64",
+				}))
+			})
+		})
+
+		context("WithLifecycleSource", func() {
+			it("builds the lifecycle from that source", func() {
+				ctx := gocontext.Background()
+				logs := bytes.NewBuffer(nil)
+
+				_, err := setup.
+					WithLifecycleSource("/some/lifecycle/checkout").
+					Run(ctx, logs, "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(lifecycleBuilder.BuildCall.Receives.SourceURI).To(Equal("/some/lifecycle/checkout"))
+				Expect(lifecycleBuilder.BuildCall.Receives.Workspace).To(Equal(filepath.Join(workspace, "lifecycle", "amd64")))
+			})
+		})
+
 		context("WithBuildpacks", func() {
 			it.Before(func() {
 				buildpacksBuilder.WithBuildpacksCall.Returns.BuildpacksBuilder = buildpacksBuilder
@@ -210,6 +255,8 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 				Expect(client.ContainerCreateCall.Receives.Config.Env).To(ContainElement(
 					"CF_STACK=some-stack",
 				))
+
+				Expect(buildpacksBuilder.WithStackCall.Receives.Stack).To(Equal("some-stack"))
 			})
 		})
 
@@ -273,6 +320,87 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("WithObserver", func() {
+			it("reports the steps of the setup to the observer", func() {
+				var steps []string
+				observer := &fakes.PhaseObserver{}
+				observer.StartedCall.Stub = func(phase string) {
+					steps = append(steps, "started "+phase)
+				}
+				observer.FinishedCall.Stub = func(phase string, err error) {
+					steps = append(steps, "finished "+phase)
+				}
+
+				_, err := setup.
+					WithObserver(observer).
+					Run(gocontext.Background(), bytes.NewBuffer(nil), "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(steps).To(Equal([]string{
+					"started lifecycle-build",
+					"finished lifecycle-build",
+					"started buildpack-fetch",
+					"finished buildpack-fetch",
+					"started image-pull",
+					"finished image-pull",
+				}))
+			})
+		})
+
+		context("WithoutStaging", func() {
+			it.Before(func() {
+				client.ContainerInspectCall.Returns.ContainerJSON = types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{ID: "some-container-id"}}
+				client.ContainerInspectCall.Returns.Error = nil
+			})
+
+			it("builds the lifecycle and pulls the image without creating a staging container", func() {
+				logs := bytes.NewBuffer(nil)
+
+				containerID, err := setup.
+					WithoutStaging().
+					Run(gocontext.Background(), logs, "some-app", "/some/path/to/my/app")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(containerID).To(BeEmpty())
+
+				Expect(lifecycleBuilder.BuildCall.CallCount).To(Equal(1))
+				Expect(client.ImagePullCall.Receives.Ref).To(Equal("cloudfoundry/default-stack:latest"))
+				Expect(logs).To(ContainLines("Pulling image..."))
+
+				Expect(client.ContainerRemoveCall.Receives.ContainerID).To(Equal("some-container-id"))
+
+				Expect(buildpacksBuilder.BuildCall.CallCount).To(Equal(0))
+				Expect(archiver.CompressCall.CallCount).To(Equal(0))
+				Expect(client.ContainerCreateCall.CallCount).To(Equal(0))
+			})
+		})
+
+		context("WithImage", func() {
+			it.Before(func() {
+				client.ContainerInspectCall.Returns.ContainerJSON = types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{ID: "some-container-id"}}
+				client.ContainerInspectCall.Returns.Error = nil
+			})
+
+			it("pulls that image without building the lifecycle or creating a staging container", func() {
+				logs := bytes.NewBuffer(nil)
+
+				containerID, err := setup.
+					WithImage("some-org/some-image:some-tag").
+					Run(gocontext.Background(), logs, "some-app", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(containerID).To(BeEmpty())
+
+				Expect(client.ImagePullCall.Receives.Ref).To(Equal("some-org/some-image:some-tag"))
+				Expect(logs).To(ContainLines("Pulling image..."))
+
+				Expect(client.ContainerRemoveCall.Receives.ContainerID).To(Equal("some-container-id"))
+
+				Expect(lifecycleBuilder.BuildCall.CallCount).To(Equal(0))
+				Expect(buildpacksBuilder.BuildCall.CallCount).To(Equal(0))
+				Expect(archiver.CompressCall.CallCount).To(Equal(0))
+				Expect(client.ContainerCreateCall.CallCount).To(Equal(0))
+			})
+		})
+
 		context("when a conflicting container already exists", func() {
 			it.Before(func() {
 				client.ContainerInspectCall.Returns.ContainerJSON = types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{ID: "some-container-id"}}
@@ -494,7 +622,7 @@ func testSetup(t *testing.T, context spec.G, it spec.S) {
 
 			context("when the lifecycle cannot be opened", func() {
 				it.Before(func() {
-					Expect(os.Chmod(filepath.Join(workspace, "lifecycle", "lifecycle.tar.gz"), 0000)).To(Succeed())
+					Expect(os.Chmod(filepath.Join(workspace, "lifecycle", "amd64", "lifecycle.tar.gz"), 0000)).To(Succeed())
 				})
 
 				it("returns an error", func() {
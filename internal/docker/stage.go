@@ -27,6 +27,16 @@ type StageClient interface {
 	ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error
 }
 
+// StagingError is returned when the staging container exits unsuccessfully.
+// The ExitCode is the exit code of the buildpackapplifecycle builder.
+type StagingError struct {
+	ExitCode int
+}
+
+func (e StagingError) Error() string {
+	return fmt.Sprintf("App staging failed: container exited with non-zero status code (%d)", e.ExitCode)
+}
+
 type Stage struct {
 	client    StageClient
 	archiver  Archiver
@@ -77,7 +87,7 @@ func (s Stage) Run(ctx context.Context, logs io.Writer, containerID, name string
 			return "", fmt.Errorf("failed to remove container: %w", err)
 		}
 
-		return "", fmt.Errorf("App staging failed: container exited with non-zero status code (%d)", status.StatusCode)
+		return "", StagingError{ExitCode: int(status.StatusCode)}
 	}
 
 	droplet, _, err := s.client.CopyFromContainer(ctx, containerID, "/tmp/droplet")
@@ -182,6 +192,13 @@ func (s Stage) Run(ctx context.Context, logs io.Writer, containerID, name string
 		}
 	}
 
+	// The result is kept alongside the droplet so that it can be collected
+	// as an artifact of the deployment.
+	err = os.WriteFile(filepath.Join(s.workspace, "droplets", fmt.Sprintf("%s.json", name)), buffer.Bytes(), 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to save result.json: %w", err)
+	}
+
 	var resultContent struct {
 		Processes []struct {
 			Type    string `json:"type"`
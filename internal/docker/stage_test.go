@@ -128,6 +128,10 @@ func testStage(t *testing.T, context spec.G, it spec.S) {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(string(content)).To(Equal("some-droplet-contents"))
 
+			content, err = os.ReadFile(filepath.Join(workspace, "droplets", "some-app.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`"processes"`))
+
 			buildCache, err := os.Open(filepath.Join(workspace, "build-cache", "some-app.tar.gz"))
 			Expect(err).NotTo(HaveOccurred())
 
@@ -158,6 +162,7 @@ func testStage(t *testing.T, context spec.G, it spec.S) {
 
 				_, err := stage.Run(ctx, logs, "some-container-id", "some-app")
 				Expect(err).To(MatchError("App staging failed: container exited with non-zero status code (223)"))
+				Expect(err).To(Equal(docker.StagingError{ExitCode: 223}))
 
 				Expect(client.ContainerStartCall.Receives.ContainerID).To(Equal("some-container-id"))
 
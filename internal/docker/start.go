@@ -1,6 +1,8 @@
 package docker
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -18,12 +20,18 @@ import (
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+// launcherPath is where the lifecycle launcher that runs droplets is copied
+// to in the app container.
+const launcherPath = "/tmp/lifecycle/launcher"
+
 type StartPhase interface {
 	Run(ctx context.Context, logs io.Writer, name, command string) (externalURL, internalURL string, err error)
 	WithStack(stack string) StartPhase
 	WithEnv(env map[string]string) StartPhase
 	WithServices(services map[string]map[string]interface{}) StartPhase
 	WithStartCommand(command string) StartPhase
+	WithDroplet(path string) StartPhase
+	WithImage(image string) StartPhase
 }
 
 //go:generate faux --interface StartClient --output fakes/start_client.go
@@ -43,22 +51,33 @@ type Start struct {
 	client       StartClient
 	networks     StartNetworkManager
 	workspace    string
+	daemonHost   string
 	stack        string
+	arch         string
+	archResolver ArchitectureResolver
 	env          map[string]string
 	services     map[string]map[string]interface{}
 	startCommand string
+	droplet      string
+	image        string
 }
 
-func NewStart(client StartClient, networks StartNetworkManager, workspace, stack string) Start {
+// NewStart returns a Start that runs apps on the Docker daemon at the given
+// daemonHost, which is also the host of their external URLs unless it is a
+// unix socket.
+func NewStart(client StartClient, networks StartNetworkManager, workspace, daemonHost, stack string) Start {
 	return Start{
-		client:    client,
-		networks:  networks,
-		workspace: workspace,
-		stack:     stack,
+		client:     client,
+		networks:   networks,
+		workspace:  workspace,
+		daemonHost: daemonHost,
+		stack:      stack,
 	}
 }
 
 func (s Start) Run(ctx context.Context, logs io.Writer, name, command string) (string, string, error) {
+	s.arch = s.archResolver.Resolve(ctx, s.arch)
+
 	env := []string{
 		"LANG=en_US.UTF-8",
 		"MEMORY_LIMIT=1024m",
@@ -102,6 +121,35 @@ func (s Start) Run(ctx context.Context, logs io.Writer, name, command string) (s
 		command = s.startCommand
 	}
 
+	// An image is run with its own entrypoint and user, and with its own
+	// command unless another is given.
+	if s.image != "" {
+		containerConfig := container.Config{
+			Image:        s.image,
+			Env:          env,
+			ExposedPorts: nat.PortSet{"8080/tcp": struct{}{}},
+		}
+
+		if command != "" {
+			containerConfig.Cmd = []string{"/bin/sh", "-c", command}
+		}
+
+		return runApp(ctx, s.client, s.networks, s.workspace, s.daemonHost, s.arch, name, containerConfig, "")
+	}
+
+	droplet := filepath.Join(s.workspace, "droplets", fmt.Sprintf("%s.tar.gz", name))
+	if s.droplet != "" {
+		droplet = s.droplet
+
+		if command == "" {
+			var err error
+			command, err = dropletStartCommand(droplet)
+			if err != nil {
+				return "", "", err
+			}
+		}
+	}
+
 	if command == "" {
 		return "", "", fmt.Errorf("error: Start command not specified")
 	}
@@ -109,7 +157,7 @@ func (s Start) Run(ctx context.Context, logs io.Writer, name, command string) (s
 	containerConfig := container.Config{
 		Image: fmt.Sprintf("cloudfoundry/%s:latest", s.stack),
 		Cmd: []string{
-			"/tmp/lifecycle/launcher",
+			launcherPath,
 			"app",
 			command,
 			"",
@@ -120,49 +168,57 @@ func (s Start) Run(ctx context.Context, logs io.Writer, name, command string) (s
 		ExposedPorts: nat.PortSet{"8080/tcp": struct{}{}},
 	}
 
+	return runApp(ctx, s.client, s.networks, s.workspace, s.daemonHost, s.arch, name, containerConfig, droplet)
+}
+
+// runApp creates and starts the app container, copying the lifecycle and the
+// given droplet into it unless the droplet is empty, and returns its URLs.
+func runApp(ctx context.Context, client StartClient, networks StartNetworkManager, workspace, daemonHost, arch, name string, containerConfig container.Config, droplet string) (string, string, error) {
 	hostConfig := container.HostConfig{
 		PublishAllPorts: true,
 		NetworkMode:     container.NetworkMode(InternalNetworkName),
 	}
 
-	resp, err := s.client.ContainerCreate(ctx, &containerConfig, &hostConfig, nil, nil, name)
+	resp, err := client.ContainerCreate(ctx, &containerConfig, &hostConfig, nil, platformFor(arch), name)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to create running container: %w", err)
 	}
 
-	err = s.networks.Connect(ctx, resp.ID, BridgeNetworkName)
+	err = networks.Connect(ctx, resp.ID, BridgeNetworkName)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to connect container to network: %w", err)
 	}
 
-	lifecycleTarball, err := os.Open(filepath.Join(s.workspace, "lifecycle", "lifecycle.tar.gz"))
-	if err != nil {
-		return "", "", fmt.Errorf("failed to open lifecycle: %w", err)
-	}
-	defer lifecycleTarball.Close()
+	if droplet != "" {
+		lifecycleTarball, err := os.Open(filepath.Join(workspace, "lifecycle", arch, "lifecycle.tar.gz"))
+		if err != nil {
+			return "", "", fmt.Errorf("failed to open lifecycle: %w", err)
+		}
+		defer lifecycleTarball.Close()
 
-	err = s.client.CopyToContainer(ctx, resp.ID, "/", lifecycleTarball, container.CopyToContainerOptions{})
-	if err != nil {
-		return "", "", fmt.Errorf("failed to copy lifecycle into container: %w", err)
-	}
+		err = client.CopyToContainer(ctx, resp.ID, "/", lifecycleTarball, container.CopyToContainerOptions{})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to copy lifecycle into container: %w", err)
+		}
 
-	dropletTarball, err := os.Open(filepath.Join(s.workspace, "droplets", fmt.Sprintf("%s.tar.gz", name)))
-	if err != nil {
-		return "", "", fmt.Errorf("failed to open droplet: %w", err)
-	}
-	defer dropletTarball.Close()
+		dropletTarball, err := os.Open(droplet)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to open droplet: %w", err)
+		}
+		defer dropletTarball.Close()
 
-	err = s.client.CopyToContainer(ctx, resp.ID, "/home/vcap/", dropletTarball, container.CopyToContainerOptions{})
-	if err != nil {
-		return "", "", fmt.Errorf("failed to copy droplet into container: %w", err)
+		err = client.CopyToContainer(ctx, resp.ID, "/home/vcap/", dropletTarball, container.CopyToContainerOptions{})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to copy droplet into container: %w", err)
+		}
 	}
 
-	err = s.client.ContainerStart(ctx, resp.ID, container.StartOptions{})
+	err = client.ContainerStart(ctx, resp.ID, container.StartOptions{})
 	if err != nil {
 		return "", "", fmt.Errorf("failed to start container: %w", err)
 	}
 
-	container, err := s.client.ContainerInspect(ctx, resp.ID)
+	container, err := client.ContainerInspect(ctx, resp.ID)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to inspect container: %w", err)
 	}
@@ -172,7 +228,7 @@ func (s Start) Run(ctx context.Context, logs io.Writer, name, command string) (s
 	if ok {
 		for _, binding := range bindings {
 			if binding.HostIP == "0.0.0.0" {
-				externalURL = fmt.Sprintf("http://%s:%s", host(), binding.HostPort)
+				externalURL = fmt.Sprintf("http://%s:%s", hostname(daemonHost), binding.HostPort)
 			}
 		}
 	}
@@ -186,20 +242,77 @@ func (s Start) Run(ctx context.Context, logs io.Writer, name, command string) (s
 	return externalURL, internalURL, nil
 }
 
-func host() string {
-	val, ok := os.LookupEnv("DOCKER_HOST")
-	if !ok || strings.HasPrefix(val, "unix://") {
-		return "localhost"
+// dropletStartCommand reads the start command from the staging_info.yml that
+// the builder writes into every droplet.
+func dropletStartCommand(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open droplet: %w", err)
 	}
+	defer file.Close()
 
-	url, err := url.Parse(val)
+	gr, err := gzip.NewReader(file)
 	if err != nil {
+		return "", fmt.Errorf("failed to read droplet: %w", err)
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("failed to find staging_info.yml in droplet")
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read droplet: %w", err)
+		}
+
+		if filepath.Clean(hdr.Name) != "staging_info.yml" {
+			continue
+		}
+
+		// The staging_info.yml is written as JSON, which is also valid YAML.
+		var info struct {
+			StartCommand string `json:"start_command"`
+		}
+		err = json.NewDecoder(tr).Decode(&info)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse staging_info.yml: %w", err)
+		}
+
+		return info.StartCommand, nil
+	}
+}
+
+// hostname returns the host that ports published by the Docker daemon at the
+// given address are reachable on.
+func hostname(daemonHost string) string {
+	if daemonHost == "" || strings.HasPrefix(daemonHost, "unix://") {
+		return "localhost"
+	}
+
+	url, err := url.Parse(daemonHost)
+	if err != nil || url.Hostname() == "" {
 		return "localhost"
 	}
 
 	return url.Hostname()
 }
 
+// WithArchitecture sets the CPU architecture that the stack image is run as.
+// It must match the architecture the lifecycle was built for during setup.
+func (s Start) WithArchitecture(arch string) Start {
+	s.arch = arch
+	return s
+}
+
+// WithArchitectureResolver resolves the architecture with the given resolver
+// when it is not set with WithArchitecture.
+func (s Start) WithArchitectureResolver(resolver ArchitectureResolver) Start {
+	s.archResolver = resolver
+	return s
+}
+
 func (s Start) WithStack(stack string) StartPhase {
 	s.stack = stack
 	return s
@@ -219,3 +332,19 @@ func (s Start) WithStartCommand(command string) StartPhase {
 	s.startCommand = command
 	return s
 }
+
+// WithImage runs the Docker image with the given reference instead of a
+// droplet, with the same environment and networks as any other app. Unless a
+// start command is given, the image is run with its own command.
+func (s Start) WithImage(image string) StartPhase {
+	s.image = image
+	return s
+}
+
+// WithDroplet runs the droplet at the given path instead of the one that was
+// staged for the app. Unless a start command is given, the app is started
+// with the start command of the droplet.
+func (s Start) WithDroplet(path string) StartPhase {
+	s.droplet = path
+	return s
+}
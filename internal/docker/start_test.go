@@ -1,7 +1,9 @@
 package docker_test
 
 import (
+	"archive/tar"
 	"bytes"
+	"compress/gzip"
 	gocontext "context"
 	"errors"
 	"io"
@@ -15,7 +17,9 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/go-connections/nat"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
@@ -40,8 +44,8 @@ func testStart(t *testing.T, context spec.G, it spec.S) {
 			workspace, err = os.MkdirTemp("", "workspace")
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle"), os.ModePerm)).To(Succeed())
-			Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "lifecycle.tar.gz"), []byte("lifecycle-content"), 0600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle", "amd64"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "amd64", "lifecycle.tar.gz"), []byte("lifecycle-content"), 0600)).To(Succeed())
 
 			Expect(os.MkdirAll(filepath.Join(workspace, "droplets"), os.ModePerm)).To(Succeed())
 			Expect(os.WriteFile(filepath.Join(workspace, "droplets", "some-app.tar.gz"), []byte("droplet-content"), 0600)).To(Succeed())
@@ -88,7 +92,7 @@ func testStart(t *testing.T, context spec.G, it spec.S) {
 
 			networkManager = &fakes.StartNetworkManager{}
 
-			start = docker.NewStart(client, networkManager, workspace, "default-stack")
+			start = docker.NewStart(client, networkManager, workspace, "unix:///var/run/docker.sock", "default-stack")
 		})
 
 		it.After(func() {
@@ -133,6 +137,11 @@ func testStart(t *testing.T, context spec.G, it spec.S) {
 				NetworkMode:     container.NetworkMode("switchblade-internal"),
 			}))
 
+			Expect(client.ContainerCreateCall.Receives.Platform).To(Equal(&specs.Platform{
+				OS:           "linux",
+				Architecture: "amd64",
+			}))
+
 			Expect(networkManager.ConnectCall.Receives.ContainerID).To(Equal("some-container-id"))
 			Expect(networkManager.ConnectCall.Receives.Name).To(Equal("bridge"))
 
@@ -153,6 +162,44 @@ func testStart(t *testing.T, context spec.G, it spec.S) {
 			Expect(client.ContainerInspectCall.Receives.ContainerID).To(Equal("some-container-id"))
 		})
 
+		context("when the Docker daemon is remote", func() {
+			it.Before(func() {
+				start = docker.NewStart(client, networkManager, workspace, "tcp://some-docker-host:2376", "default-stack")
+			})
+
+			it("returns an external url on the daemon host", func() {
+				externalURL, _, err := start.Run(gocontext.Background(), bytes.NewBuffer(nil), "some-app", "some-command")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(externalURL).To(Equal("http://some-docker-host:12345"))
+			})
+		})
+
+		context("WithArchitecture", func() {
+			it("runs the stack for that architecture with the matching lifecycle", func() {
+				Expect(os.MkdirAll(filepath.Join(workspace, "lifecycle", "arm64"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workspace, "lifecycle", "arm64", "lifecycle.tar.gz"), []byte("arm64-lifecycle-content"), 0600)).To(Succeed())
+
+				ctx := gocontext.Background()
+				logs := bytes.NewBuffer(nil)
+
+				_, _, err := start.
+					WithArchitecture("arm64").
+					Run(ctx, logs, "some-app", "some-command")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ContainerCreateCall.Receives.Platform).To(Equal(&specs.Platform{
+					OS:           "linux",
+					Architecture: "arm64",
+				}))
+
+				Expect(copyToContainerInvocations[0]).To(Equal(copyToContainerInvocation{
+					ContainerID: "some-container-id",
+					DstPath:     "/",
+					Content:     "arm64-lifecycle-content",
+				}))
+			})
+		})
+
 		context("WithStack", func() {
 			it("sets the image for the container", func() {
 				ctx := gocontext.Background()
@@ -239,6 +286,122 @@ func testStart(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("WithImage", func() {
+			it("runs that image with the app environment and networks", func() {
+				ctx := gocontext.Background()
+				logs := bytes.NewBuffer(nil)
+
+				externalURL, internalURL, err := start.
+					WithImage("some-org/some-image:some-tag").
+					Run(ctx, logs, "some-app", "")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(externalURL).To(Equal("http://localhost:12345"))
+				Expect(internalURL).To(Equal("http://172.19.0.2:8080"))
+
+				Expect(client.ContainerCreateCall.Receives.Config.Image).To(Equal("some-org/some-image:some-tag"))
+				Expect(client.ContainerCreateCall.Receives.Config.Cmd).To(BeEmpty())
+				Expect(client.ContainerCreateCall.Receives.Config.User).To(BeEmpty())
+				Expect(client.ContainerCreateCall.Receives.Config.Env).To(ContainElements(
+					"PORT=8080",
+					`VCAP_APPLICATION={"application_name":"some-app","name":"some-app","process_type":"web","limits":{"mem":1024}}`,
+					"VCAP_SERVICES={}",
+				))
+				Expect(client.ContainerCreateCall.Receives.Config.ExposedPorts).To(HaveKey(nat.Port("8080/tcp")))
+				Expect(client.ContainerCreateCall.Receives.HostConfig.NetworkMode).To(Equal(container.NetworkMode("switchblade-internal")))
+				Expect(client.ContainerCreateCall.Receives.ContainerName).To(Equal("some-app"))
+
+				Expect(networkManager.ConnectCall.Receives.Name).To(Equal("bridge"))
+				Expect(copyToContainerInvocations).To(BeEmpty())
+				Expect(client.ContainerStartCall.CallCount).To(Equal(1))
+			})
+
+			context("when a start command is given", func() {
+				it("runs the image with that command", func() {
+					_, _, err := start.
+						WithImage("some-org/some-image:some-tag").
+						WithStartCommand("some-start-command").
+						Run(gocontext.Background(), bytes.NewBuffer(nil), "some-app", "")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(client.ContainerCreateCall.Receives.Config.Cmd).To(Equal(strslice.StrSlice{"/bin/sh", "-c", "some-start-command"}))
+				})
+			})
+		})
+
+		context("WithDroplet", func() {
+			var droplet string
+
+			it.Before(func() {
+				droplet = filepath.Join(workspace, "other-droplet.tgz")
+				Expect(generateStagingInfo(droplet, `{"detected_buildpack":"","start_command":"some-droplet-command"}`)).To(Succeed())
+			})
+
+			it("runs that droplet with its start command", func() {
+				ctx := gocontext.Background()
+				logs := bytes.NewBuffer(nil)
+
+				_, _, err := start.
+					WithDroplet(droplet).
+					Run(ctx, logs, "some-app", "")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(client.ContainerCreateCall.Receives.Config.Cmd).To(ContainElement("some-droplet-command"))
+
+				content, err := os.ReadFile(droplet)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(copyToContainerInvocations).To(HaveLen(2))
+				Expect(copyToContainerInvocations[1].DstPath).To(Equal("/home/vcap/"))
+				Expect(copyToContainerInvocations[1].Content).To(Equal(string(content)))
+			})
+
+			context("when a start command is given", func() {
+				it("runs the droplet with that command", func() {
+					_, _, err := start.
+						WithDroplet(droplet).
+						WithStartCommand("some-start-command").
+						Run(gocontext.Background(), bytes.NewBuffer(nil), "some-app", "")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(client.ContainerCreateCall.Receives.Config.Cmd).To(ContainElement("some-start-command"))
+					Expect(client.ContainerCreateCall.Receives.Config.Cmd).NotTo(ContainElement("some-droplet-command"))
+				})
+			})
+
+			context("failure cases", func() {
+				context("when the droplet does not exist", func() {
+					it("returns an error", func() {
+						_, _, err := start.
+							WithDroplet(filepath.Join(workspace, "no-such-droplet.tgz")).
+							Run(gocontext.Background(), bytes.NewBuffer(nil), "some-app", "")
+						Expect(err).To(MatchError(ContainSubstring("failed to open droplet:")))
+					})
+				})
+
+				context("when the droplet is not a tarball", func() {
+					it("returns an error", func() {
+						_, _, err := start.
+							WithDroplet(filepath.Join(workspace, "droplets", "some-app.tar.gz")).
+							Run(gocontext.Background(), bytes.NewBuffer(nil), "some-app", "")
+						Expect(err).To(MatchError(ContainSubstring("failed to read droplet:")))
+					})
+				})
+
+				context("when the droplet has no staging_info.yml", func() {
+					it.Before(func() {
+						Expect(generateStagingInfo(droplet, "")).To(Succeed())
+					})
+
+					it("returns an error", func() {
+						_, _, err := start.
+							WithDroplet(droplet).
+							Run(gocontext.Background(), bytes.NewBuffer(nil), "some-app", "")
+						Expect(err).To(MatchError("failed to find staging_info.yml in droplet"))
+					})
+				})
+			})
+		})
+
 		context("failure cases", func() {
 			context("when service bindings cannot be marshalled to json", func() {
 				it("returns an error", func() {
@@ -287,7 +450,7 @@ func testStart(t *testing.T, context spec.G, it spec.S) {
 
 			context("when the lifecycle cannot be read", func() {
 				it.Before(func() {
-					Expect(os.Chmod(filepath.Join(workspace, "lifecycle", "lifecycle.tar.gz"), 0000)).To(Succeed())
+					Expect(os.Chmod(filepath.Join(workspace, "lifecycle", "amd64", "lifecycle.tar.gz"), 0000)).To(Succeed())
 				})
 
 				it("returns an error", func() {
@@ -405,3 +568,40 @@ func testStart(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 }
+
+// generateStagingInfo writes a droplet to the given path with the given
+// staging_info.yml, or without one when it is empty.
+func generateStagingInfo(path, info string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gw)
+
+	if info != "" {
+		err = tw.WriteHeader(&tar.Header{Name: "./staging_info.yml", Mode: 0600, Size: int64(len(info))})
+		if err != nil {
+			return err
+		}
+
+		_, err = tw.Write([]byte(info))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return err
+	}
+
+	err = gw.Close()
+	if err != nil {
+		return err
+	}
+
+	return file.Close()
+}
@@ -43,6 +43,11 @@ func (t Teardown) Run(ctx context.Context, name string) error {
 		return fmt.Errorf("failed to delete droplet tarball: %w", err)
 	}
 
+	err = os.Remove(filepath.Join(t.workspace, "droplets", fmt.Sprintf("%s.json", name)))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete staging result: %w", err)
+	}
+
 	err = os.Remove(filepath.Join(t.workspace, "source", fmt.Sprintf("%s.tar.gz", name)))
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return fmt.Errorf("failed to delete source tarball: %w", err)
@@ -38,6 +38,8 @@ func testTeardown(t *testing.T, context spec.G, it spec.S) {
 			Expect(err).NotTo(HaveOccurred())
 			err = os.WriteFile(filepath.Join(workspace, "droplets", "some-app.tar.gz"), []byte("some-droplet-contents"), 0600)
 			Expect(err).NotTo(HaveOccurred())
+			err = os.WriteFile(filepath.Join(workspace, "droplets", "some-app.json"), []byte(`{"processes":[]}`), 0600)
+			Expect(err).NotTo(HaveOccurred())
 
 			err = os.Mkdir(filepath.Join(workspace, "source"), os.ModePerm)
 			Expect(err).NotTo(HaveOccurred())
@@ -76,6 +78,7 @@ func testTeardown(t *testing.T, context spec.G, it spec.S) {
 			}))
 
 			Expect(filepath.Join(workspace, "droplets", "some-app.tar.gz")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(workspace, "droplets", "some-app.json")).NotTo(BeAnExistingFile())
 			Expect(filepath.Join(workspace, "source", "some-app.tar.gz")).NotTo(BeAnExistingFile())
 			Expect(filepath.Join(workspace, "buildpacks", "some-app.tar.gz")).NotTo(BeAnExistingFile())
 			Expect(filepath.Join(workspace, "buildpacks", "some-app", "some-buildpack")).NotTo(BeAnExistingFile())
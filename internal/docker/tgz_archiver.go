@@ -25,18 +25,47 @@ func (a TGZArchiver) WithPrefix(prefix string) Archiver {
 	return a
 }
 
+// Compress writes the input into a tarball at the output path. The tarball is
+// written to a temporary file and renamed into place so that concurrent
+// readers never observe a partially written output.
 func (a TGZArchiver) Compress(input, output string) error {
 	err := os.MkdirAll(filepath.Dir(output), os.ModePerm)
 	if err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	file, err := os.Create(output)
+	file, err := os.CreateTemp(filepath.Dir(output), fmt.Sprintf(".%s.*", filepath.Base(output)))
 	if err != nil {
 		return fmt.Errorf("failed to create output file: %w", err)
 	}
-	defer file.Close()
+	defer os.Remove(file.Name())
 
+	err = a.write(input, file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Chmod(0644)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to set output file permissions: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+
+	err = os.Rename(file.Name(), output)
+	if err != nil {
+		return fmt.Errorf("failed to move output file: %w", err)
+	}
+
+	return nil
+}
+
+func (a TGZArchiver) write(input string, file io.Writer) error {
 	gw := gzip.NewWriter(file)
 	defer gw.Close()
 
@@ -50,12 +79,27 @@ func (a TGZArchiver) Compress(input, output string) error {
 
 	switch {
 	case info.IsDir():
-		return a.fromDirectory(input, tw)
+		err = a.fromDirectory(input, tw)
 	case info.Mode()&fs.ModeType == 0:
-		return a.fromFile(input, tw)
+		err = a.fromFile(input, tw)
 	default:
-		return errors.New("unknown file type")
+		err = errors.New("unknown file type")
 	}
+	if err != nil {
+		return err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close tar writer: %w", err)
+	}
+
+	err = gw.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close gzip writer: %w", err)
+	}
+
+	return nil
 }
 
 func (a TGZArchiver) fromDirectory(input string, tw *tar.Writer) error {
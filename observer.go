@@ -0,0 +1,105 @@
+package switchblade
+
+import "time"
+
+// The phases of a deployment that are reported to an Observer. The setup
+// phase contains the lifecycle build, buildpack fetch, and image pull phases
+// on Docker, and the push phase on Cloud Foundry. On Cloud Foundry, the stage
+// phase also starts the application, since `cf start` does both.
+const (
+	PhaseSetup          = "setup"
+	PhaseLifecycleBuild = "lifecycle-build"
+	PhaseBuildpackFetch = "buildpack-fetch"
+	PhaseImagePull      = "image-pull"
+	PhasePush           = "push"
+	PhaseStage          = "stage"
+	PhaseStart          = "start"
+	PhaseTeardown       = "teardown"
+)
+
+// Observer is notified when each phase of a deployment starts and finishes.
+// It is given to Platform.WithObserver, and is called synchronously from the
+// goroutine that deploys or deletes the application.
+type Observer interface {
+	PhaseStarted(event PhaseEvent)
+	PhaseFinished(event PhaseEvent)
+}
+
+// PhaseEvent describes a phase of the deployment with the given Name. The
+// Duration and Err are only set once the phase has finished.
+type PhaseEvent struct {
+	Platform string
+	Name     string
+	Phase    string
+	Start    time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// PhaseTiming is the time taken by a phase of a deployment.
+type PhaseTiming struct {
+	Phase    string        `json:"phase"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// phaseTimer times the phases of a single deployment, reporting each to the
+// observer as it starts and finishes.
+type phaseTimer struct {
+	platform string
+	name     string
+	observer Observer
+	started  map[string]int
+	timings  []PhaseTiming
+}
+
+func newPhaseTimer(platform, name string, observer Observer) *phaseTimer {
+	return &phaseTimer{
+		platform: platform,
+		name:     name,
+		observer: observer,
+		started:  map[string]int{},
+	}
+}
+
+func (t *phaseTimer) Started(phase string) {
+	start := time.Now()
+
+	t.started[phase] = len(t.timings)
+	t.timings = append(t.timings, PhaseTiming{Phase: phase, Start: start})
+
+	if t.observer != nil {
+		t.observer.PhaseStarted(PhaseEvent{
+			Platform: t.platform,
+			Name:     t.name,
+			Phase:    phase,
+			Start:    start,
+		})
+	}
+}
+
+func (t *phaseTimer) Finished(phase string, err error) {
+	index, ok := t.started[phase]
+	if !ok {
+		return
+	}
+
+	timing := &t.timings[index]
+	timing.Duration = time.Since(timing.Start)
+
+	if t.observer != nil {
+		t.observer.PhaseFinished(PhaseEvent{
+			Platform: t.platform,
+			Name:     t.name,
+			Phase:    phase,
+			Start:    timing.Start,
+			Duration: timing.Duration,
+			Err:      err,
+		})
+	}
+}
+
+// Timings returns the timings of the phases in the order they started.
+func (t *phaseTimer) Timings() []PhaseTiming {
+	return append([]PhaseTiming(nil), t.timings...)
+}
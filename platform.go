@@ -1,19 +1,14 @@
 package switchblade
 
-import (
-	"fmt"
-	"os"
-	"path/filepath"
-
-	"github.com/cloudfoundry/switchblade/internal/cloudfoundry"
-	"github.com/cloudfoundry/switchblade/internal/docker"
-	"github.com/docker/docker/client"
-	"github.com/paketo-buildpacks/packit/v2/pexec"
-)
+import "fmt"
 
 type Buildpack struct {
 	Name string
 	URI  string
+
+	// SHA256 is an optional checksum that the Docker platform verifies the
+	// downloaded buildpack against.
+	SHA256 string
 }
 
 type Service map[string]interface{}
@@ -21,6 +16,10 @@ type Service map[string]interface{}
 type Platform struct {
 	initialize   initializeProcess
 	deinitialize deinitializeProcess
+	info         infoProcess
+	attach       attachProcess
+	check        checkProcess
+	detect       detectProcess
 
 	Deploy DeployProcess
 	Delete DeleteProcess
@@ -30,15 +29,31 @@ type DeployProcess interface {
 	WithBuildpacks(buildpacks ...string) DeployProcess
 	WithStack(stack string) DeployProcess
 	WithEnv(env map[string]string) DeployProcess
+	WithSecretEnv(env map[string]string) DeployProcess
 	WithoutInternetAccess() DeployProcess
 	WithServices(map[string]Service) DeployProcess
 	WithStartCommand(command string) DeployProcess
 	WithHealthCheckType(healthCheckType string) DeployProcess
+	WithErrorLogLines(lines int) DeployProcess
+	WithArtifactsDir(dir string) DeployProcess
+	WithDropletArtifact() DeployProcess
+	WithObserver(observer Observer) DeployProcess
+	WithoutStart() DeployProcess
+	WithDroplet(path string) DeployProcess
 
 	Execute(name, path string) (Deployment, fmt.Stringer, error)
+
+	// ExecuteImage deploys the Docker image with the given reference instead
+	// of staging an application, such as for a fake service broker that is
+	// needed alongside it. Only the options that apply to running an
+	// application, such as WithEnv, WithServices and WithStartCommand, are
+	// used.
+	ExecuteImage(name, image string) (Deployment, fmt.Stringer, error)
 }
 
 type DeleteProcess interface {
+	WithObserver(observer Observer) DeleteProcess
+
 	Execute(name string) error
 }
 
@@ -50,55 +65,60 @@ type deinitializeProcess interface {
 	Execute() error
 }
 
+type infoProcess interface {
+	Execute() (PlatformInfo, error)
+}
+
+type attachProcess interface {
+	Execute(name string) Deployment
+}
+
+type checkProcess interface {
+	Execute() error
+}
+
+type detectProcess interface {
+	Execute(path string, buildpacks ...string) ([]DetectResult, error)
+}
+
+// PlatformInfo describes the platform that deployments are run against.
+type PlatformInfo struct {
+	Type         string
+	Architecture string
+	Lifecycle    LifecycleInfo
+}
+
+// LifecycleInfo describes the buildpackapplifecycle used by the platform. It
+// is only populated for the Docker platform. The Revision is empty until the
+// lifecycle has been built from the Source, including after the Source was
+// changed until the next deployment.
+type LifecycleInfo struct {
+	Source   string
+	Revision string
+}
+
 const (
 	CloudFoundry = "cf"
 	Docker       = "docker"
 )
 
-func NewPlatform(platformType, token, stack string) (Platform, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return Platform{}, err
-	}
+// errMissingPhase is returned by the features of a platform that rely on a
+// phase that the platform was created without.
+func errMissingPhase(phase string) error {
+	return fmt.Errorf("the platform was created without the %s phase", phase)
+}
 
-	switch platformType {
-	case CloudFoundry:
-		cli := pexec.NewExecutable("cf")
-
-		initialize := cloudfoundry.NewInitialize(cli, stack)
-		deinitialize := cloudfoundry.NewDeinitialize()
-		setup := cloudfoundry.NewSetup(cli, filepath.Join(home, ".cf"), stack)
-		stage := cloudfoundry.NewStage(cli)
-		teardown := cloudfoundry.NewTeardown(cli)
-
-		return NewCloudFoundry(initialize, deinitialize, setup, stage, teardown, os.TempDir(), cli), nil
-	case Docker:
-		dockerClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-		if err != nil {
-			return Platform{}, err
-		}
-
-		workspace := filepath.Join(home, ".switchblade")
-
-		golang := pexec.NewExecutable("go")
-		archiver := docker.NewTGZArchiver()
-		lifecycleManager := docker.NewLifecycleManager(golang, archiver)
-		buildpacksCache := docker.NewBuildpacksCache(filepath.Join(workspace, "buildpacks-cache"))
-		buildpacksRegistry := docker.NewBuildpacksRegistry("https://api.github.com", token)
-		buildpacksManager := docker.NewBuildpacksManager(archiver, buildpacksCache, buildpacksRegistry)
-		networkManager := docker.NewNetworkManager(dockerClient)
-
-		initialize := docker.NewInitialize(buildpacksRegistry, networkManager)
-		deinitialize := docker.NewDeinitialize(networkManager)
-		setup := docker.NewSetup(dockerClient, lifecycleManager, buildpacksManager, archiver, networkManager, workspace, stack)
-		stage := docker.NewStage(dockerClient, archiver, workspace)
-		start := docker.NewStart(dockerClient, networkManager, workspace, stack)
-		teardown := docker.NewTeardown(dockerClient, workspace)
-
-		return NewDocker(initialize, deinitialize, setup, stage, start, teardown, dockerClient), nil
+func NewPlatform(platformType, token, stack string, options ...PlatformOption) (Platform, error) {
+	config := Config{
+		Type:  platformType,
+		Token: token,
+		Stack: stack,
+	}
+	for _, option := range options {
+		option(&config)
 	}
 
-	return Platform{}, fmt.Errorf("unknown platform type: %q", platformType)
+	return NewPlatformWithConfig(config)
 }
 
 func (p Platform) Initialize(buildpacks ...Buildpack) error {
@@ -108,3 +128,53 @@ func (p Platform) Initialize(buildpacks ...Buildpack) error {
 func (p Platform) Deinitialize() error {
 	return p.deinitialize.Execute()
 }
+
+// Info reports details about the platform, including the revision of the
+// buildpackapplifecycle that was actually used to stage and run applications
+// on the Docker platform.
+func (p Platform) Info() (PlatformInfo, error) {
+	return p.info.Execute()
+}
+
+// Check verifies that the platform is ready to deploy applications. On
+// Docker, it checks that the daemon is reachable, that the stack image and a
+// way to compile the lifecycle are available, and, when buildpacks still need
+// to be looked up rather than coming from Initialize or the index, that the
+// GitHub API token is valid and not rate-limited. On Cloud Foundry, it checks
+// that the cf CLI is installed and logged in as an admin, and that a TCP
+// router group exists. Every problem that is found is described in the
+// returned error, along with how to resolve it.
+func (p Platform) Check() error {
+	return p.check.Execute()
+}
+
+// Detect reports whether each of the given buildpacks detects the application
+// at the given path, or each buildpack on the platform when none are given,
+// without staging it.
+//
+// On Docker, the bin/detect script of every buildpack is run in a container
+// of the stack image. On Cloud Foundry, buildpacks can only be detected as
+// part of staging, which stops at the first buildpack that detects. The
+// application is staged without starting it and then deleted, and only the
+// buildpack that staged it is reported as detected. The others are reported as
+// Unknown, unless none of the buildpacks detected the application.
+func (p Platform) Detect(path string, buildpacks ...string) ([]DetectResult, error) {
+	return p.detect.Execute(path, buildpacks...)
+}
+
+// WithObserver returns a copy of the platform whose deployments report the
+// start and end of each of their phases to the given observer, including
+// when they are deleted.
+func (p Platform) WithObserver(observer Observer) Platform {
+	p.Deploy = p.Deploy.WithObserver(observer)
+	p.Delete = p.Delete.WithObserver(observer)
+	return p
+}
+
+// Attach returns the Deployment for an application that was previously
+// deployed with the given name, such as by another process. Only the Name of
+// the returned Deployment is populated, but its RuntimeLogs can be retrieved
+// and it can be restarted.
+func (p Platform) Attach(name string) Deployment {
+	return p.attach.Execute(name)
+}
@@ -0,0 +1,25 @@
+package switchblade
+
+import (
+	"time"
+
+	"github.com/cloudfoundry/switchblade/internal/docker"
+)
+
+// PruneCache removes buildpack zips, droplets, source tarballs, and build
+// caches in the default ~/.switchblade workspace of the Docker platform that
+// have not been modified within the given duration.
+func PruneCache(olderThan time.Duration) error {
+	workspace, err := Config{}.workspace()
+	if err != nil {
+		return err
+	}
+
+	return PruneCacheIn(workspace, olderThan)
+}
+
+// PruneCacheIn prunes the given workspace like PruneCache. It is meant for
+// platforms created with a Config that sets Workspace.
+func PruneCacheIn(workspace string, olderThan time.Duration) error {
+	return docker.PruneWorkspace(workspace, olderThan)
+}
@@ -0,0 +1,155 @@
+package switchblade
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces secret values in the logs and errors that are returned
+// from a deployment.
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the length below which values are not redacted, since
+// short values such as "true" or "80" would redact unrelated output.
+const minSecretLength = 4
+
+// redactor replaces the values of secret environment variables and the
+// credentials of services wherever they appear in a string.
+type redactor struct {
+	secrets []string
+}
+
+func newRedactor(env map[string]string, services map[string]Service) redactor {
+	return redactor{}.with(env, services)
+}
+
+// with returns a redactor that also redacts the given secret environment
+// variables and service credentials.
+func (r redactor) with(env map[string]string, services map[string]Service) redactor {
+	seen := map[string]bool{}
+	for _, secret := range r.secrets {
+		seen[secret] = true
+	}
+
+	secrets := append([]string(nil), r.secrets...)
+	add := func(value string) {
+		if len(value) < minSecretLength || seen[value] {
+			return
+		}
+
+		seen[value] = true
+		secrets = append(secrets, value)
+
+		// The value may also appear escaped within JSON, such as in
+		// VCAP_SERVICES.
+		content, err := json.Marshal(value)
+		if err == nil {
+			escaped := strings.Trim(string(content), `"`)
+			if escaped != value && !seen[escaped] {
+				seen[escaped] = true
+				secrets = append(secrets, escaped)
+			}
+		}
+	}
+
+	for _, value := range env {
+		add(value)
+	}
+
+	for _, service := range services {
+		for _, value := range credentialValues(map[string]interface{}(service)) {
+			add(value)
+		}
+	}
+
+	// Longer secrets are replaced first, so that a secret that contains
+	// another is redacted in full.
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+
+	return redactor{secrets: secrets}
+}
+
+// credentialValues returns the scalar values within the given service
+// credentials, including those that are nested in objects and arrays.
+func credentialValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case map[string]interface{}:
+		var values []string
+		for _, element := range v {
+			values = append(values, credentialValues(element)...)
+		}
+		return values
+	case Service:
+		return credentialValues(map[string]interface{}(v))
+	case []interface{}:
+		var values []string
+		for _, element := range v {
+			values = append(values, credentialValues(element)...)
+		}
+		return values
+	case nil, bool:
+		return nil
+	default:
+		content, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		return []string{string(content)}
+	}
+}
+
+func (r redactor) redact(s string) string {
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+
+	return s
+}
+
+// logs returns a copy of the given deployment logs with the secrets redacted.
+func (r redactor) logs(buffer *bytes.Buffer) *bytes.Buffer {
+	if len(r.secrets) == 0 {
+		return buffer
+	}
+
+	return bytes.NewBufferString(r.redact(buffer.String()))
+}
+
+// error returns an error whose message is redacted, but that still unwraps to
+// the original error.
+func (r redactor) error(err error) error {
+	if len(r.secrets) == 0 {
+		return err
+	}
+
+	return redactedError{err: err, message: r.redact(err.Error())}
+}
+
+type redactedError struct {
+	err     error
+	message string
+}
+
+func (e redactedError) Error() string { return e.message }
+func (e redactedError) Unwrap() error { return e.err }
+
+// mergeEnv returns the environment variables with the secret environment
+// variables added, which take precedence.
+func mergeEnv(env, secretEnv map[string]string) map[string]string {
+	merged := make(map[string]string, len(env)+len(secretEnv))
+	for key, value := range env {
+		merged[key] = value
+	}
+
+	for key, value := range secretEnv {
+		merged[key] = value
+	}
+
+	return merged
+}